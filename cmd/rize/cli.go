@@ -0,0 +1,527 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/alienxp03/rize/internal/commands"
+	"github.com/alienxp03/rize/internal/config"
+	"github.com/alienxp03/rize/internal/docker"
+	"github.com/alienxp03/rize/internal/ui"
+)
+
+// command is a node of the command tree
+type command struct {
+	name    string
+	aliases []string
+	// args describes the positional arguments in the usage line
+	args    string
+	summary string
+	// group is the section of the parent's help the command is listed in
+	group string
+	// minArgs and maxArgs bound the positional arguments; -1 is unbounded
+	minArgs, maxArgs int
+	// passthrough commands take their arguments verbatim, flags included, so
+	// they reach the agent or program they run untouched
+	passthrough bool
+	hidden      bool
+	// json commands print JSON with --output json, or their --json flag
+	json bool
+	// setup declares the command's flags and returns the function running it.
+	// Commands without setup only group subcommands.
+	setup func(fs *flag.FlagSet) func(args []string) error
+	// complete returns the shell completion candidates of the positional
+	// argument following args
+	complete func(args []string) []string
+	commands []*command
+	parent   *command
+}
+
+// add adds subcommands
+func (c *command) add(subs ...*command) *command {
+	for _, sub := range subs {
+		sub.parent = c
+		c.commands = append(c.commands, sub)
+	}
+	return c
+}
+
+// find returns the subcommand with a name or alias
+func (c *command) find(name string) *command {
+	for _, sub := range c.commands {
+		if sub.name == name {
+			return sub
+		}
+		for _, alias := range sub.aliases {
+			if alias == name {
+				return sub
+			}
+		}
+	}
+	return nil
+}
+
+// path returns the full command name, like "rize services logs"
+func (c *command) path() string {
+	if c.parent == nil {
+		return c.name
+	}
+	return c.parent.path() + " " + c.name
+}
+
+// usage returns the usage line of the command
+func (c *command) usage() string {
+	parts := []string{c.path()}
+	switch {
+	case len(c.commands) > 0 && c.setup == nil:
+		parts = append(parts, "<command>")
+	case c.passthrough:
+		parts = append(parts, c.args)
+	default:
+		if hasFlags(c) {
+			parts = append(parts, "[flags]")
+		}
+		if c.args != "" {
+			parts = append(parts, c.args)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// noArgs wraps a command function without flags or arguments
+func noArgs(run func() error) func(*flag.FlagSet) func([]string) error {
+	return func(*flag.FlagSet) func([]string) error {
+		return func([]string) error { return run() }
+	}
+}
+
+// withArgs wraps a command function taking the positional arguments
+func withArgs(run func(args []string) error) func(*flag.FlagSet) func([]string) error {
+	return func(*flag.FlagSet) func([]string) error { return run }
+}
+
+// options are the global flags, accepted before the command and among the
+// flags of every command that is not a passthrough command
+type options struct {
+	config     string
+	projectDir string
+	image      string
+	output     string
+	quiet      bool
+	verbose    bool
+	trace      bool
+	logFile    string
+	noServices bool
+	noColor    bool
+}
+
+func (o *options) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.config, "config", o.config, "config file (default ~/.config/rize/config.yml)")
+	fs.StringVar(&o.projectDir, "project-dir", o.projectDir, "run as if rize was started in `dir`")
+	fs.StringVar(&o.image, "image", o.image, "rize image to run (default $RIZE_IMAGE or the tag of this version)")
+	fs.StringVar(&o.output, "output", o.output, "output `format` of the commands supporting it: text or json (default text)")
+	fs.BoolVar(&o.quiet, "quiet", o.quiet, "only print warnings and errors")
+	fs.BoolVar(&o.verbose, "verbose", o.verbose, "print debug messages")
+	fs.BoolVar(&o.verbose, "v", o.verbose, "print debug messages")
+	fs.BoolVar(&o.trace, "vv", o.trace, "also trace Docker API calls and external commands with timings")
+	fs.StringVar(&o.logFile, "log-file", o.logFile, "append messages to `file` with timestamps; debug and trace messages only go there (default $RIZE_LOG_FILE)")
+	fs.BoolVar(&o.noServices, "no-services", o.noServices, "do not start services before running agents, shell or exec")
+	fs.BoolVar(&o.noColor, "no-color", o.noColor, "disable colored output")
+}
+
+// jsonFlag is the --json flag of json commands, a shorthand for
+// --output json
+type jsonFlag struct {
+	opts *options
+}
+
+func (f jsonFlag) String() string   { return "false" }
+func (f jsonFlag) IsBoolFlag() bool { return true }
+
+func (f jsonFlag) Set(value string) error {
+	on, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	if on {
+		f.opts.output = "json"
+	}
+	return nil
+}
+
+// apply applies the global flags
+func (o *options) apply() error {
+	if o.quiet && (o.verbose || o.trace) {
+		return errors.New("--quiet and --verbose cannot be combined")
+	}
+	if err := o.applyLog(); err != nil {
+		return err
+	}
+	switch o.output {
+	case "", "text":
+	case "json":
+		commands.JSON = true
+	default:
+		return fmt.Errorf("unknown output format %q, use text or json", o.output)
+	}
+	if o.noColor {
+		ui.DisableColor()
+	}
+
+	// Resolve the config before changing directory, it is relative to
+	// where rize was started
+	if o.config != "" {
+		path, err := filepath.Abs(o.config)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", o.config, err)
+		}
+		config.SetPath(path)
+		ui.Debug("Using config %s", path)
+	}
+	if o.projectDir != "" {
+		if err := os.Chdir(o.projectDir); err != nil {
+			return fmt.Errorf("failed to change to project dir: %w", err)
+		}
+		ui.Debug("Using project dir %s", o.projectDir)
+	}
+	if o.image != "" {
+		docker.ImageName = o.image
+	}
+	commands.NoServices = o.noServices
+	return nil
+}
+
+// applyLog sets the log level and file from the flags, or from RIZE_LOG and
+// RIZE_LOG_FILE
+func (o *options) applyLog() error {
+	level := ui.LevelInfo
+	if name := os.Getenv("RIZE_LOG"); name != "" {
+		var err error
+		if level, err = ui.ParseLevel(name); err != nil {
+			return fmt.Errorf("invalid RIZE_LOG: %w", err)
+		}
+	}
+	switch {
+	case o.quiet:
+		level = ui.LevelWarn
+	case o.trace:
+		level = ui.LevelTrace
+	case o.verbose:
+		level = max(level, ui.LevelDebug)
+	}
+	ui.SetLevel(level)
+
+	logFile := o.logFile
+	if logFile == "" {
+		logFile = os.Getenv("RIZE_LOG_FILE")
+	}
+	if logFile != "" {
+		if err := ui.SetLogFile(logFile); err != nil {
+			return err
+		}
+	}
+	ui.Debug("Log level %s", level)
+	return nil
+}
+
+// usageError is a command line error, reported with the usage of the
+// command
+type usageError struct {
+	cmd *command
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+// invocation is a parsed command line
+type invocation struct {
+	cmd *command
+	// help is set when the help of cmd was asked for
+	help bool
+	run  func(args []string) error
+	args []string
+}
+
+// parse resolves the command of a command line and parses its flags and
+// arguments
+func parse(root *command, opts *options, args []string) (*invocation, error) {
+	cmd := root
+	for len(cmd.commands) > 0 {
+		fs := newFlagSet(cmd)
+		opts.register(fs)
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return &invocation{cmd: cmd, help: true}, nil
+			}
+			return nil, &usageError{cmd, err.Error()}
+		}
+		args = fs.Args()
+
+		if len(args) == 0 {
+			if cmd.setup != nil {
+				break
+			}
+			if cmd == root {
+				return &invocation{cmd: cmd, help: true}, nil
+			}
+			return nil, &usageError{cmd, fmt.Sprintf("%s requires a command", cmd.path())}
+		}
+
+		sub := cmd.find(args[0])
+		if sub == nil {
+			if cmd.setup != nil {
+				break
+			}
+			return nil, &usageError{cmd, fmt.Sprintf("unknown command %q for %s", args[0], cmd.path())}
+		}
+		cmd, args = sub, args[1:]
+	}
+
+	inv := &invocation{cmd: cmd}
+	fs := newFlagSet(cmd)
+	if cmd.setup != nil {
+		inv.run = cmd.setup(fs)
+	}
+	if cmd.json {
+		fs.Var(jsonFlag{opts}, "json", "print JSON, same as --output json")
+	}
+
+	if cmd.passthrough {
+		if len(args) > 0 && args[0] == "--" {
+			args = args[1:]
+		}
+		inv.args = args
+	} else {
+		opts.register(fs)
+		positional, err := parseInterspersed(fs, args)
+		if err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return &invocation{cmd: cmd, help: true}, nil
+			}
+			return nil, &usageError{cmd, err.Error()}
+		}
+		inv.args = positional
+	}
+
+	if err := checkArgs(cmd, inv.args); err != nil {
+		return nil, err
+	}
+	if opts.output == "json" && !cmd.json {
+		return nil, &usageError{cmd, fmt.Sprintf("%s does not support --output json", cmd.path())}
+	}
+	return inv, nil
+}
+
+// parseInterspersed parses flags anywhere among the positional arguments,
+// until "--" after which everything is positional
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		// The flag package stops at the first positional argument and
+		// consumes a "--" terminator
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+func checkArgs(cmd *command, args []string) error {
+	n := len(args)
+	switch {
+	case n < cmd.minArgs:
+		return &usageError{cmd, fmt.Sprintf("%s requires %s", cmd.path(), cmd.args)}
+	case cmd.maxArgs >= 0 && n > cmd.maxArgs:
+		if cmd.maxArgs == 0 {
+			return &usageError{cmd, fmt.Sprintf("%s takes no arguments, got %q", cmd.path(), strings.Join(args, " "))}
+		}
+		return &usageError{cmd, fmt.Sprintf("too many arguments for %s: %q", cmd.path(), strings.Join(args, " "))}
+	}
+	return nil
+}
+
+func newFlagSet(cmd *command) *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd.path(), flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+// commandFlags returns the flags of a command, without the global flags
+func commandFlags(cmd *command) *flag.FlagSet {
+	fs := newFlagSet(cmd)
+	if cmd.setup != nil {
+		cmd.setup(fs)
+	}
+	if cmd.json {
+		fs.Var(jsonFlag{&options{}}, "json", "print JSON, same as --output json")
+	}
+	return fs
+}
+
+func hasFlags(cmd *command) bool {
+	found := false
+	commandFlags(cmd).VisitAll(func(*flag.Flag) { found = true })
+	return found
+}
+
+// printHelp prints the help of a command
+func printHelp(w io.Writer, cmd *command) {
+	if cmd.parent == nil {
+		fmt.Fprintf(w, "%s - Secure AI Agent Sandbox\n\n", ui.Blue("Rize"))
+		fmt.Fprintln(w, "Usage: rize [global flags] <command> [args...]")
+	} else {
+		fmt.Fprintf(w, "Usage: %s\n", cmd.usage())
+		if cmd.summary != "" {
+			fmt.Fprintf(w, "\n%s\n", cmd.summary)
+		}
+		if len(cmd.aliases) > 0 {
+			fmt.Fprintf(w, "\nAliases: %s\n", strings.Join(append([]string{cmd.name}, cmd.aliases...), ", "))
+		}
+	}
+
+	printCommands(w, cmd)
+
+	if fs := commandFlags(cmd); !cmd.passthrough {
+		printFlags(w, "Flags:", fs)
+	}
+
+	if cmd.parent == nil {
+		globals := flag.NewFlagSet("rize", flag.ContinueOnError)
+		(&options{}).register(globals)
+		printFlags(w, "Global flags:", globals)
+
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Environment variables:")
+		fmt.Fprintln(w, "  RIZE_IMAGE     Docker image to use (default: the alienxp03/rize tag of this version)")
+		fmt.Fprintln(w, "  RIZE_LOG       log level: error, warn, info (default), debug or trace")
+		fmt.Fprintln(w, "  RIZE_LOG_FILE  file to append messages to, like --log-file")
+		fmt.Fprintln(w, "  NO_COLOR       disable colored output, like --no-color")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Config file:")
+		fmt.Fprintln(w, "  ~/.config/rize/config.yml")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Exit status:")
+		fmt.Fprintln(w, "  0  success")
+		fmt.Fprintln(w, "  1  the command failed")
+		fmt.Fprintln(w, "  2  usage error")
+		fmt.Fprintln(w, "  3  a check failed (services ps with an unhealthy service, doctor)")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Run 'rize help <command>' for more about a command.")
+		return
+	}
+
+	fmt.Fprintln(w)
+	if cmd.passthrough {
+		fmt.Fprintf(w, "Arguments are passed through untouched, flags included; use 'rize help %s' for this help.\n", strings.TrimPrefix(cmd.path(), "rize "))
+	}
+	fmt.Fprintln(w, "Global flags are accepted too, see 'rize help'.")
+}
+
+func printCommands(w io.Writer, cmd *command) {
+	if len(cmd.commands) == 0 {
+		return
+	}
+
+	var groups []string
+	byGroup := map[string][]*command{}
+	for _, sub := range cmd.commands {
+		if sub.hidden {
+			continue
+		}
+		if _, ok := byGroup[sub.group]; !ok {
+			groups = append(groups, sub.group)
+		}
+		byGroup[sub.group] = append(byGroup[sub.group], sub)
+	}
+
+	label := func(sub *command) string {
+		args := sub.args
+		if len(sub.commands) > 0 && sub.setup == nil {
+			args = "<command>"
+		}
+		return strings.TrimSpace(sub.name + " " + args)
+	}
+	width := 0
+	for _, sub := range cmd.commands {
+		if !sub.hidden {
+			width = max(width, len(label(sub)))
+		}
+	}
+
+	for _, group := range groups {
+		title := group
+		if title == "" {
+			title = "Commands"
+		}
+		fmt.Fprintf(w, "\n%s:\n", title)
+		for _, sub := range byGroup[group] {
+			fmt.Fprintf(w, "  %-*s  %s\n", width, label(sub), sub.summary)
+		}
+	}
+}
+
+func printFlags(w io.Writer, title string, fs *flag.FlagSet) {
+	var lines [][2]string
+	fs.VisitAll(func(f *flag.Flag) {
+		name, usage := flag.UnquoteUsage(f)
+		left := flagName(f)
+		if name != "" {
+			left += " " + name
+		}
+		switch f.DefValue {
+		case "", "false", "0", "0s", "[]":
+		default:
+			usage += fmt.Sprintf(" (default %s)", f.DefValue)
+		}
+		lines = append(lines, [2]string{left, usage})
+	})
+	if len(lines) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\n%s\n", title)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, line := range lines {
+		fmt.Fprintf(tw, "  %s\t%s\n", line[0], line[1])
+	}
+	tw.Flush()
+}
+
+// flagName returns the name of a flag as it is written: one dash for short
+// flags like -f and -vv, two for the others
+func flagName(f *flag.Flag) string {
+	if len(f.Name) <= 2 {
+		return "-" + f.Name
+	}
+	return "--" + f.Name
+}
+
+// lookup returns the command at a path of names, like ["services", "logs"]
+func lookup(root *command, names []string) (*command, error) {
+	cmd := root
+	for _, name := range names {
+		sub := cmd.find(name)
+		if sub == nil {
+			return nil, &usageError{root, fmt.Sprintf("unknown command %q", strings.Join(names, " "))}
+		}
+		cmd = sub
+	}
+	return cmd, nil
+}
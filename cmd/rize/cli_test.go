@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alienxp03/rize/internal/ui"
+)
+
+// testRoot returns a small command tree recording the flags and arguments
+// its commands run with
+func testRoot(got *[]string) *command {
+	record := func(values ...string) {
+		*got = append(*got, values...)
+	}
+
+	root := &command{name: "rize"}
+	return root.add(
+		&command{
+			name: "claude", args: "[args...]", maxArgs: -1, passthrough: true,
+			setup: withArgs(func(args []string) error { record(args...); return nil }),
+		},
+		(&command{name: "services"}).add(
+			&command{
+				name: "logs", aliases: []string{"log"}, args: "[service...]", maxArgs: -1,
+				setup: func(fs *flag.FlagSet) func([]string) error {
+					follow := fs.Bool("f", false, "follow")
+					tail := fs.String("tail", "all", "lines")
+					return func(args []string) error {
+						record(args...)
+						if *follow {
+							record("-f")
+						}
+						record("tail=" + *tail)
+						return nil
+					}
+				},
+			},
+			&command{
+				name: "restore", args: "<service> <snapshot>", minArgs: 2, maxArgs: 2,
+				setup: withArgs(func(args []string) error { record(args...); return nil }),
+			},
+		),
+		&command{name: "version", json: true, setup: noArgs(func() error { record("version"); return nil })},
+	)
+}
+
+func runTest(t *testing.T, args ...string) ([]string, *options, error) {
+	t.Helper()
+	var got []string
+	opts := &options{}
+	inv, err := parse(testRoot(&got), opts, args)
+	if err != nil {
+		return nil, opts, err
+	}
+	if inv.help {
+		return []string{"help:" + inv.cmd.path()}, opts, nil
+	}
+	return got, opts, inv.run(inv.args)
+}
+
+func TestParseFlagsAndArguments(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"services", "logs", "-f", "postgres"}, []string{"postgres", "-f", "tail=all"}},
+		// Flags may follow the positional arguments
+		{[]string{"services", "logs", "postgres", "--tail", "10", "redis"}, []string{"postgres", "redis", "tail=10"}},
+		{[]string{"services", "log", "--", "-f"}, []string{"-f", "tail=all"}},
+		{[]string{"services", "restore", "postgres", "before"}, []string{"postgres", "before"}},
+		{[]string{"version"}, []string{"version"}},
+	}
+	for _, tt := range tests {
+		got, _, err := runTest(t, tt.args...)
+		if err != nil {
+			t.Errorf("%v: %v", tt.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: got %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestParsePassthrough(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"claude", "--help"}, []string{"--help"}},
+		{[]string{"claude", "-p", "fix the tests", "--verbose"}, []string{"-p", "fix the tests", "--verbose"}},
+		{[]string{"claude", "--", "--version"}, []string{"--version"}},
+		{[]string{"--quiet", "claude", "--quiet"}, []string{"--quiet"}},
+	}
+	for _, tt := range tests {
+		got, opts, err := runTest(t, tt.args...)
+		if err != nil {
+			t.Errorf("%v: %v", tt.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: got %q, want %q", tt.args, got, tt.want)
+		}
+		if opts.quiet != (tt.args[0] == "--quiet") {
+			t.Errorf("%v: quiet = %v", tt.args, opts.quiet)
+		}
+	}
+}
+
+func TestParseGlobalFlags(t *testing.T) {
+	_, opts, err := runTest(t, "--config", "/tmp/rize.yml", "services", "--no-services", "logs", "--project-dir", "/src/api", "--image=rize:dev", "--no-color")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := options{config: "/tmp/rize.yml", projectDir: "/src/api", image: "rize:dev", noServices: true, noColor: true}
+	if *opts != want {
+		t.Errorf("options = %+v, want %+v", *opts, want)
+	}
+
+	if err := (&options{quiet: true, verbose: true}).apply(); err == nil {
+		t.Error("Expected --quiet and --verbose to be rejected together")
+	}
+	if err := (&options{quiet: true, trace: true}).apply(); err == nil {
+		t.Error("Expected --quiet and -vv to be rejected together")
+	}
+	if err := (&options{output: "yaml"}).apply(); err == nil {
+		t.Error("Expected an unknown output format to be rejected")
+	}
+}
+
+func TestApplyLogLevel(t *testing.T) {
+	t.Cleanup(func() { ui.SetLevel(ui.LevelInfo) })
+
+	tests := []struct {
+		env  string
+		opts options
+		want ui.Level
+	}{
+		{"", options{}, ui.LevelInfo},
+		{"debug", options{}, ui.LevelDebug},
+		{"", options{verbose: true}, ui.LevelDebug},
+		{"trace", options{verbose: true}, ui.LevelTrace},
+		{"", options{trace: true}, ui.LevelTrace},
+		{"trace", options{quiet: true}, ui.LevelWarn},
+	}
+	for _, tt := range tests {
+		t.Setenv("RIZE_LOG", tt.env)
+		if err := tt.opts.applyLog(); err != nil {
+			t.Errorf("RIZE_LOG=%s %+v: %v", tt.env, tt.opts, err)
+			continue
+		}
+		if !ui.Enabled(tt.want) || (tt.want < ui.LevelTrace && ui.Enabled(tt.want+1)) {
+			t.Errorf("RIZE_LOG=%s %+v: expected level %s", tt.env, tt.opts, tt.want)
+		}
+	}
+
+	t.Setenv("RIZE_LOG", "loud")
+	if err := (&options{}).applyLog(); err == nil {
+		t.Error("Expected an invalid RIZE_LOG to be rejected")
+	}
+}
+
+func TestParseOutput(t *testing.T) {
+	for _, args := range [][]string{
+		{"--output", "json", "version"},
+		{"version", "--output=json"},
+		{"version", "--json"},
+	} {
+		_, opts, err := runTest(t, args...)
+		if err != nil {
+			t.Errorf("%v: %v", args, err)
+			continue
+		}
+		if opts.output != "json" {
+			t.Errorf("%v: output = %q, want json", args, opts.output)
+		}
+	}
+
+	// Commands without JSON output refuse it rather than print text
+	_, _, err := runTest(t, "--output", "json", "services", "logs")
+	var usage *usageError
+	if !errors.As(err, &usage) || !strings.Contains(usage.msg, "does not support --output json") {
+		t.Errorf("Expected a usage error, got %v", err)
+	}
+	if _, _, err := runTest(t, "services", "logs", "--json"); err == nil {
+		t.Error("Expected --json to be unknown to services logs")
+	}
+}
+
+func TestParseHelp(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, "rize"},
+		{[]string{"--help"}, "rize"},
+		{[]string{"services", "-h"}, "rize services"},
+		{[]string{"services", "logs", "postgres", "--help"}, "rize services logs"},
+	}
+	for _, tt := range tests {
+		got, _, err := runTest(t, tt.args...)
+		if err != nil {
+			t.Errorf("%v: %v", tt.args, err)
+			continue
+		}
+		if len(got) != 1 || got[0] != "help:"+tt.want {
+			t.Errorf("%v: got %q, want help for %s", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestParseUsageErrors(t *testing.T) {
+	tests := []struct {
+		args    []string
+		command string
+		message string
+	}{
+		{[]string{"bogus"}, "rize", `unknown command "bogus"`},
+		{[]string{"services"}, "rize services", "requires a command"},
+		{[]string{"services", "bogus"}, "rize services", `unknown command "bogus"`},
+		{[]string{"services", "restore", "postgres"}, "rize services restore", "requires <service> <snapshot>"},
+		{[]string{"services", "restore", "a", "b", "c"}, "rize services restore", "too many arguments"},
+		{[]string{"services", "logs", "--bogus"}, "rize services logs", "flag provided but not defined"},
+		{[]string{"version", "extra"}, "rize version", "takes no arguments"},
+	}
+	for _, tt := range tests {
+		_, _, err := runTest(t, tt.args...)
+		var usage *usageError
+		if !errors.As(err, &usage) {
+			t.Errorf("%v: expected a usage error, got %v", tt.args, err)
+			continue
+		}
+		if usage.cmd.path() != tt.command || !strings.Contains(usage.msg, tt.message) {
+			t.Errorf("%v: got %q for %s, want %q for %s", tt.args, usage.msg, usage.cmd.path(), tt.message, tt.command)
+		}
+	}
+}
+
+func TestCommandTree(t *testing.T) {
+	root := newRoot()
+
+	// Every command can print its help and the usage line names the command
+	var visit func(cmd *command)
+	visit = func(cmd *command) {
+		var buf bytes.Buffer
+		printHelp(&buf, cmd)
+		if cmd.parent != nil && !strings.Contains(buf.String(), "Usage: "+cmd.path()) {
+			t.Errorf("help of %s has no usage line:\n%s", cmd.path(), buf.String())
+		}
+		if cmd.setup == nil && len(cmd.commands) == 0 {
+			t.Errorf("%s neither runs nor has subcommands", cmd.path())
+		}
+		for _, sub := range cmd.commands {
+			visit(sub)
+		}
+	}
+	visit(root)
+
+	for _, path := range []string{"services ps", "services status", "services snapshots ls", "cache list", "traffic ls"} {
+		if _, err := lookup(root, strings.Fields(path)); err != nil {
+			t.Errorf("lookup(%s): %v", path, err)
+		}
+	}
+
+	inv, err := parse(root, &options{}, []string{"codex", "--help"})
+	if err != nil || inv.help || !reflect.DeepEqual(inv.args, []string{"--help"}) {
+		t.Errorf("Expected agent flags to pass through, got %+v, %v", inv, err)
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/alienxp03/rize/internal/commands"
+)
+
+// completeCommand prints the completion candidates of the last word, one per
+// line. The completion scripts call it with the words after "rize", the last
+// one being the word under the cursor. It also works as a bash completion
+// command (`complete -C 'rize __complete' rize`), reading the command line
+// from COMP_LINE.
+func completeCommand(root *command) func(args []string) error {
+	return func(args []string) error {
+		for _, candidate := range completions(root, completionWords(args)) {
+			fmt.Println(candidate)
+		}
+		return nil
+	}
+}
+
+// completionWords returns the words after "rize", ending with the word under
+// the cursor
+func completionWords(args []string) []string {
+	line, ok := os.LookupEnv("COMP_LINE")
+	if !ok {
+		if len(args) == 0 {
+			return []string{""}
+		}
+		return args
+	}
+
+	if point, err := strconv.Atoi(os.Getenv("COMP_POINT")); err == nil && point <= len(line) {
+		line = line[:point]
+	}
+
+	words := strings.Fields(line)
+	if strings.HasSuffix(line, " ") || len(words) == 0 {
+		words = append(words, "")
+	}
+	return words[1:]
+}
+
+// completions returns the candidates for the last word. The words before it
+// are resolved like parse does: subcommands, then flags and positional
+// arguments; global flags like --project-dir are applied before names are
+// looked up.
+func completions(root *command, words []string) []string {
+	current := words[len(words)-1]
+	words = words[:len(words)-1]
+
+	opts := &options{}
+	cmd := root
+	fs := completionFlags(cmd, opts)
+	var args []string
+	dashes := false
+
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		switch {
+		case cmd.passthrough:
+			if len(args) == 0 && word == "--" && !dashes {
+				dashes = true
+				continue
+			}
+			args = append(args, word)
+		case dashes:
+			args = append(args, word)
+		case word == "--":
+			dashes = true
+		case strings.HasPrefix(word, "-") && word != "-":
+			name, value, hasValue := strings.Cut(strings.TrimLeft(word, "-"), "=")
+			f := fs.Lookup(name)
+			if f == nil || hasValue || isBoolFlag(f) {
+				if f != nil && hasValue {
+					f.Value.Set(value)
+				}
+				continue
+			}
+			// The next word is the value of the flag
+			if i++; i == len(words) {
+				return nil
+			}
+			f.Value.Set(words[i])
+		case len(args) == 0 && cmd.find(word) != nil:
+			cmd = cmd.find(word)
+			fs = completionFlags(cmd, opts)
+		default:
+			args = append(args, word)
+		}
+	}
+
+	var candidates []string
+	switch {
+	case strings.HasPrefix(current, "-") && !cmd.passthrough && !dashes:
+		fs.VisitAll(func(f *flag.Flag) {
+			candidates = append(candidates, flagName(f))
+		})
+	case len(args) == 0 && len(cmd.commands) > 0 && !dashes:
+		for _, sub := range cmd.commands {
+			if !sub.hidden {
+				candidates = append(candidates, sub.name)
+			}
+		}
+	case cmd.complete != nil:
+		// Names depend on the config and the project directory given
+		if opts.apply() != nil {
+			return nil
+		}
+		candidates = cmd.complete(args)
+	}
+
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, current) {
+			matches = append(matches, candidate)
+		}
+	}
+	return matches
+}
+
+// completionFlags returns the flags of a command with the global flags
+// registered in opts
+func completionFlags(cmd *command, opts *options) *flag.FlagSet {
+	fs := commandFlags(cmd)
+	if !cmd.passthrough {
+		opts.register(fs)
+	}
+	return fs
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// The completion functions below complete positional arguments
+
+// eachName completes names not given yet, for commands taking a list
+func eachName(names func() []string) func([]string) []string {
+	return func(args []string) []string {
+		given := map[string]bool{}
+		for _, arg := range args {
+			given[arg] = true
+		}
+
+		var rest []string
+		for _, name := range names() {
+			if !given[name] {
+				rest = append(rest, name)
+			}
+		}
+		return rest
+	}
+}
+
+// firstName completes the first positional argument only
+func firstName(names func() []string) func([]string) []string {
+	return func(args []string) []string {
+		if len(args) > 0 {
+			return nil
+		}
+		return names()
+	}
+}
+
+// serviceSnapshot completes a service, then one of its snapshots
+func serviceSnapshot(args []string) []string {
+	switch len(args) {
+	case 0:
+		return commands.ServiceNames()
+	case 1:
+		return commands.SnapshotNames(args[0])
+	}
+	return nil
+}
+
+// staticNames returns a function returning fixed names
+func staticNames(names ...string) func() []string {
+	return func() []string { return names }
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func completionRoot() *command {
+	services := staticNames("postgres", "redis", "mitmproxy")
+	logs := func(fs *flag.FlagSet) func([]string) error {
+		fs.Bool("f", false, "follow")
+		fs.String("tail", "all", "lines")
+		return nil
+	}
+
+	root := &command{name: "rize"}
+	return root.add(
+		&command{name: "claude", maxArgs: -1, passthrough: true, complete: firstName(services)},
+		(&command{name: "services"}).add(
+			&command{name: "logs", maxArgs: -1, setup: logs, complete: eachName(services)},
+			&command{name: "exec", minArgs: 2, maxArgs: -1, passthrough: true, complete: firstName(services)},
+			&command{name: "restore", minArgs: 2, maxArgs: 2, complete: func(args []string) []string {
+				if len(args) == 1 {
+					return []string{"before-" + args[0], "nightly"}
+				}
+				return nil
+			}},
+		),
+		&command{name: "__complete", hidden: true},
+	)
+}
+
+func TestCompletions(t *testing.T) {
+	tests := []struct {
+		words []string
+		want  []string
+	}{
+		{[]string{""}, []string{"claude", "services"}},
+		{[]string{"s"}, []string{"services"}},
+		{[]string{"services", ""}, []string{"logs", "exec", "restore"}},
+		{[]string{"services", "logs", "-f", "p"}, []string{"postgres"}},
+		// Names given already are not offered again
+		{[]string{"services", "logs", "postgres", "--tail", "10", ""}, []string{"redis", "mitmproxy"}},
+		{[]string{"services", "logs", "--tail=10", "r"}, []string{"redis"}},
+		// The value of a flag is not completed
+		{[]string{"services", "logs", "--tail", ""}, nil},
+		{[]string{"services", "logs", "--t"}, []string{"--tail"}},
+		{[]string{"services", "logs", "--no-"}, []string{"--no-color", "--no-services"}},
+		{[]string{"--project-dir", "/src", "services", "l"}, []string{"logs"}},
+		{[]string{"services", "restore", "redis", ""}, []string{"before-redis", "nightly"}},
+		{[]string{"services", "exec", ""}, []string{"postgres", "redis", "mitmproxy"}},
+		{[]string{"services", "exec", "postgres", ""}, nil},
+		// Flags of passthrough commands belong to the program they run
+		{[]string{"claude", "--"}, nil},
+		{[]string{"services", "exec", "--", "r"}, []string{"redis"}},
+	}
+	root := completionRoot()
+	for _, tt := range tests {
+		got := completions(root, tt.words)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("completions(%q) = %q, want %q", tt.words, got, tt.want)
+		}
+	}
+}
+
+func TestCompletionWords(t *testing.T) {
+	if got := completionWords([]string{"services", "lo"}); !reflect.DeepEqual(got, []string{"services", "lo"}) {
+		t.Errorf("completionWords(args) = %q", got)
+	}
+	if got := completionWords(nil); !reflect.DeepEqual(got, []string{""}) {
+		t.Errorf("completionWords(nil) = %q", got)
+	}
+
+	// bash `complete -C` passes the line and the cursor position
+	t.Setenv("COMP_LINE", "rize services logs post")
+	t.Setenv("COMP_POINT", "19")
+	if got := completionWords(nil); !reflect.DeepEqual(got, []string{"services", "logs", ""}) {
+		t.Errorf("completionWords(COMP_LINE) = %q", got)
+	}
+}
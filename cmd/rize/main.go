@@ -1,109 +1,414 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/alienxp03/rize/internal/audit"
 	"github.com/alienxp03/rize/internal/commands"
+	"github.com/alienxp03/rize/internal/docker"
 	"github.com/alienxp03/rize/internal/ui"
 )
 
 func main() {
-	if err := run(); err != nil {
-		ui.Error("%v", err)
-		os.Exit(1)
-	}
+	os.Exit(run(os.Args[1:]))
 }
 
-func run() error {
-	args := os.Args[1:]
+// run runs a command line and returns the exit code: 1 when the command
+// fails, 2 for usage errors and 3 when a check failed
+func run(args []string) int {
+	// Kept for compatibility with the version flag of other tools
+	if len(args) > 0 && args[0] == "--version" {
+		args[0] = "version"
+	}
+
+	opts := &options{}
+	root := newRoot()
 
-	// Default to help if no args
-	if len(args) == 0 {
-		commands.Help()
-		return nil
+	inv, err := parse(root, opts, args)
+	if err == nil {
+		if inv.help {
+			printHelp(os.Stdout, inv.cmd)
+			return 0
+		}
+		if err = opts.apply(); err != nil {
+			err = &usageError{inv.cmd, err.Error()}
+		} else {
+			err = inv.run(inv.args)
+		}
+	}
+	if err == nil {
+		return 0
 	}
 
-	command := args[0]
-	commandArgs := args[1:]
+	code := 1
+	var usage *usageError
+	var check *commands.CheckError
+	switch {
+	case errors.As(err, &usage):
+		code = 2
+	case errors.As(err, &check):
+		code = 3
+	}
 
-	switch command {
-	case "shell":
-		return commands.Shell()
+	if opts.output == "json" {
+		printJSONError(err, code)
+		return code
+	}
+	ui.Error("%v", err)
+	if usage != nil {
+		fmt.Fprintf(os.Stderr, "Usage: %s\nRun '%s --help' for more information.\n", usage.cmd.usage(), usage.cmd.path())
+	}
+	return code
+}
 
-	case "claude":
-		return commands.Agent("claude", commandArgs)
+// printJSONError prints the error of a command run with --output json on
+// stderr, as {"error": "...", "exit_code": 1}
+func printJSONError(err error, code int) {
+	data, _ := json.Marshal(struct {
+		Error    string `json:"error"`
+		ExitCode int    `json:"exit_code"`
+	}{err.Error(), code})
+	fmt.Fprintln(os.Stderr, string(data))
+}
 
-	case "codex":
-		return commands.Agent("codex", commandArgs)
+// newRoot returns the command tree
+func newRoot() *command {
+	root := &command{name: "rize"}
 
-	case "opencode":
-		return commands.Agent("opencode", commandArgs)
+	root.add(
+		agentCommand("claude", "Run Claude Code"),
+		agentCommand("codex", "Run OpenAI Codex"),
+		agentCommand("opencode", "Run OpenCode"),
+		agentCommand("gemini", "Run Gemini"),
+		&command{
+			name: "shell", group: "Agents", summary: "Start an interactive shell (zsh)",
+			setup: noArgs(commands.Shell),
+		},
+		&command{
+			name: "exec", group: "Agents", args: "<command> [args...]", summary: "Run a command in the project container",
+			minArgs: 1, maxArgs: -1, passthrough: true,
+			setup: withArgs(commands.Exec),
+		},
+		&command{
+			name: "ps", group: "Agents", summary: "List the project containers of every workspace", json: true,
+			setup: noArgs(commands.Ps),
+		},
 
-	case "gemini":
-		return commands.Agent("gemini", commandArgs)
+		servicesCommand(),
 
-	case "exec":
-		if len(commandArgs) == 0 {
-			return fmt.Errorf("exec requires a command")
-		}
-		return commands.Exec(commandArgs)
+		(&command{name: "recordings", group: "Recordings", summary: "Manage recorded sessions"}).add(&command{
+			name: "list", aliases: []string{"ls"}, summary: "List recorded sessions",
+			setup: noArgs(commands.RecordingsList),
+		}),
+		&command{
+			name: "replay", group: "Recordings", args: "<id>", summary: "Replay a recorded session",
+			minArgs: 1, maxArgs: 1, complete: firstName(commands.RecordingIDs),
+			setup: func(fs *flag.FlagSet) func([]string) error {
+				speed := fs.Float64("speed", 1, "playback speed multiplier")
+				maxIdle := fs.Duration("max-idle", 2*time.Second, "maximum pause between events (0 to disable)")
+				return func(args []string) error {
+					return commands.Replay(args[0], *speed, *maxIdle)
+				}
+			},
+		},
 
-	case "services":
-		if len(commandArgs) == 0 {
-			return fmt.Errorf("services requires a subcommand (up, down, ps, logs, restart)")
-		}
-		return handleServicesCommand(commandArgs)
+		trafficCommand(),
+		&command{
+			name: "usage", group: "Traffic", summary: "Show LLM token usage and cost", json: true,
+			setup: func(fs *flag.FlagSet) func([]string) error {
+				since := fs.String("since", "30d", "only usage since (e.g. 24h, 7d, 2026-01-15)")
+				by := fs.String("by", "model", "group by project, agent, model, provider or session")
+				return func([]string) error {
+					return commands.Usage(*since, *by)
+				}
+			},
+		},
 
-	case "init":
-		return commands.Init()
+		auditCommand(),
+		cacheCommand(),
 
-	case "install":
-		return commands.Install()
+		&command{
+			name: "init", group: "Configuration", summary: "Create the default config file",
+			setup: noArgs(commands.Init),
+		},
+		(&command{name: "config", group: "Configuration", summary: "Inspect the configuration"}).add(&command{
+			name: "show", summary: "Show the config rize applies here, secrets redacted", json: true,
+			setup: noArgs(commands.ConfigShow),
+		}),
+		&command{
+			name: "doctor", group: "Configuration", summary: "Check Docker, ports, disk and the project container; show the config rize applies",
+			json:  true,
+			setup: noArgs(commands.Doctor),
+		},
 
-	case "update":
-		return commands.Update()
+		&command{
+			name: "install", group: "Installation", summary: "Install rize (default /usr/local/bin when writable, else ~/.local/bin)",
+			setup: func(fs *flag.FlagSet) func([]string) error {
+				prefix := fs.String("prefix", "", "install `dir`ectory (default: /usr/local/bin if writable, else ~/.local/bin)")
+				completions := fs.Bool("completions", false, "also install the shell completion scripts")
+				return func([]string) error { return commands.Install(*prefix, *completions) }
+			},
+		},
+		&command{
+			name: "update", group: "Installation", summary: "Update the binary and image",
+			setup: func(fs *flag.FlagSet) func([]string) error {
+				rollback := fs.Bool("rollback", false, "restore the binary the last update replaced")
+				force := fs.Bool("force", false, "install the release even if it is older or the same version")
+				channel := fs.String("channel", "", "stable, nightly or a version like v1.2.3 (default: the channel of this binary)")
+				return func([]string) error {
+					if *rollback {
+						return commands.UpdateRollback()
+					}
+					return commands.Update(*channel, *force)
+				}
+			},
+		},
+		&command{
+			name: "uninstall", group: "Installation", summary: "Remove rize, its containers, volumes, images and config",
+			setup: func(fs *flag.FlagSet) func([]string) error {
+				keepData := fs.Bool("keep-data", false, "keep volumes, the configuration and ~/.rize")
+				dryRun := fs.Bool("dry-run", false, "list what would be removed without removing it")
+				yes := fs.Bool("yes", false, "do not ask for confirmation")
+				fs.BoolVar(yes, "y", false, "do not ask for confirmation")
+				return func([]string) error { return commands.Uninstall(*keepData, *dryRun, *yes) }
+			},
+		},
 
-	case "uninstall":
-		return commands.Uninstall()
+		&command{
+			name: "version", group: "Other", summary: "Show the binary and image versions", json: true,
+			setup: noArgs(commands.Version),
+		},
+		&command{
+			name: "completion", group: "Other", args: "<shell>", summary: "Print the completion script of bash, zsh or fish",
+			minArgs: 1, maxArgs: 1, complete: firstName(staticNames(commands.CompletionShells...)),
+			setup: withArgs(func(args []string) error { return commands.Completion(args[0]) }),
+		},
+		&command{
+			name: "help", group: "Other", args: "[command...]", summary: "Show help for rize or a command",
+			maxArgs: -1, complete: func(args []string) []string {
+				cmd, err := lookup(root, args)
+				if err != nil {
+					return nil
+				}
+				var names []string
+				for _, sub := range cmd.commands {
+					if !sub.hidden {
+						names = append(names, sub.name)
+					}
+				}
+				return names
+			},
+			setup: withArgs(func(args []string) error {
+				cmd, err := lookup(root, args)
+				if err != nil {
+					return err
+				}
+				printHelp(os.Stdout, cmd)
+				return nil
+			}),
+		},
+		&command{
+			name: "__complete", hidden: true, maxArgs: -1, passthrough: true,
+			setup: withArgs(completeCommand(root)),
+		},
+	)
 
-	case "help":
-		commands.Help()
-		return nil
+	return root
+}
 
-	default:
-		ui.Error("Unknown command: %s", command)
-		fmt.Println()
-		commands.Help()
-		return fmt.Errorf("unknown command: %s", command)
+// agentCommand runs an agent with its arguments passed through untouched
+func agentCommand(name, summary string) *command {
+	return &command{
+		name: name, group: "Agents", args: "[args...]", summary: summary,
+		maxArgs: -1, passthrough: true,
+		setup: withArgs(func(args []string) error {
+			return commands.Agent(name, args)
+		}),
 	}
 }
 
-func handleServicesCommand(args []string) error {
-	subcommand := args[0]
-	subcommandArgs := args[1:]
+func servicesCommand() *command {
+	services := &command{name: "services", group: "Services", summary: "Manage the services (postgres, redis, mitmproxy, ...)"}
 
-	switch subcommand {
-	case "up":
-		return commands.ServicesUp()
-
-	case "down":
-		return commands.ServicesDown()
+	names := func(name, summary string, run func([]string) error) *command {
+		return &command{
+			name: name, args: "[service...]", summary: summary, maxArgs: -1,
+			complete: eachName(commands.ServiceNames),
+			setup:    withArgs(run),
+		}
+	}
 
-	case "ps":
-		return commands.ServicesPs()
+	return services.add(
+		names("up", "Start services (all enabled by default)", commands.ServicesUp),
+		&command{
+			name: "down", summary: "Stop and remove all services",
+			setup: noArgs(commands.ServicesDown),
+		},
+		names("start", "Start stopped services", commands.ServicesStart),
+		names("stop", "Stop services, keeping their containers", commands.ServicesStop),
+		names("restart", "Restart services", commands.ServicesRestart),
+		names("pull", "Pull the latest service images", commands.ServicesPull),
+		&command{
+			name: "ps", aliases: []string{"status"}, summary: "Show service status, ports and URLs", json: true,
+			setup: noArgs(commands.ServicesPs),
+		},
+		&command{
+			name: "logs", args: "[service...]", summary: "View service logs", maxArgs: -1,
+			complete: eachName(commands.ServiceNames),
+			setup: func(fs *flag.FlagSet) func([]string) error {
+				follow := fs.Bool("f", false, "follow log output")
+				tail := fs.String("tail", "all", "number of lines to show from the end of the logs")
+				since := fs.String("since", "", "show logs since a timestamp or relative duration (e.g. 10m)")
+				return func(args []string) error {
+					return commands.ServicesLogs(args, docker.LogOptions{Follow: *follow, Tail: *tail, Since: *since})
+				}
+			},
+		},
+		&command{
+			name: "exec", args: "<service> <command> [args...]", summary: "Run a command in a service container",
+			minArgs: 2, maxArgs: -1, passthrough: true, complete: firstName(commands.ServiceNames),
+			setup: withArgs(func(args []string) error {
+				return commands.ServicesExec(args[0], args[1:])
+			}),
+		},
+		&command{
+			name: "seed", args: "<service> [file]", summary: "Pipe a SQL dump into a running service",
+			minArgs: 1, maxArgs: 2, complete: firstName(commands.ServiceNames),
+			setup: withArgs(func(args []string) error {
+				file := ""
+				if len(args) == 2 {
+					file = args[1]
+				}
+				return commands.ServicesSeed(args[0], file)
+			}),
+		},
+		&command{
+			name: "snapshot", args: "<service>", summary: "Archive service volumes",
+			minArgs: 1, maxArgs: 1, complete: firstName(commands.ServiceNames),
+			setup: func(fs *flag.FlagSet) func([]string) error {
+				name := fs.String("name", "", "snapshot name (default: current time)")
+				return func(args []string) error { return commands.ServicesSnapshot(args[0], *name) }
+			},
+		},
+		&command{
+			name: "restore", args: "<service> <snapshot>", summary: "Restore service volumes from a snapshot",
+			minArgs: 2, maxArgs: 2, complete: serviceSnapshot,
+			setup: func(fs *flag.FlagSet) func([]string) error {
+				yes := fs.Bool("y", false, "do not ask for confirmation")
+				return func(args []string) error { return commands.ServicesRestore(args[0], args[1], *yes) }
+			},
+		},
+		&command{
+			name: "reset", args: "<service>", summary: "Delete service volumes and start with fresh data",
+			minArgs: 1, maxArgs: 1, complete: firstName(commands.ServiceNames),
+			setup: func(fs *flag.FlagSet) func([]string) error {
+				yes := fs.Bool("y", false, "do not ask for confirmation")
+				return func(args []string) error { return commands.ServicesReset(args[0], *yes) }
+			},
+		},
+		(&command{name: "snapshots", summary: "Manage snapshots"}).add(&command{
+			name: "list", aliases: []string{"ls"}, args: "[service]", summary: "List snapshots",
+			maxArgs: 1, complete: firstName(commands.ServiceNames),
+			setup: withArgs(func(args []string) error {
+				service := ""
+				if len(args) == 1 {
+					service = args[0]
+				}
+				return commands.ServicesSnapshotsList(service)
+			}),
+		}),
+		&command{
+			name: "export", summary: "Write an equivalent docker-compose.yml",
+			setup: func(fs *flag.FlagSet) func([]string) error {
+				output := fs.String("o", "-", "output `file` (- for stdout)")
+				return func([]string) error { return commands.ServicesExport(*output) }
+			},
+		},
+	)
+}
 
-	case "logs":
-		follow := false
-		if len(subcommandArgs) > 0 && subcommandArgs[0] == "-f" {
-			follow = true
-		}
-		return commands.ServicesLogs(follow)
+func trafficCommand() *command {
+	return (&command{name: "traffic", group: "Traffic", summary: "Inspect captured proxy traffic"}).add(
+		&command{
+			name: "list", aliases: []string{"ls"}, summary: "List sessions with captured proxy traffic",
+			setup: noArgs(commands.TrafficList),
+		},
+		&command{
+			name: "show", args: "<session>", summary: "Show the flows of a session",
+			minArgs: 1, maxArgs: 1, complete: firstName(commands.TrafficSessionIDs),
+			setup: func(fs *flag.FlagSet) func([]string) error {
+				full := fs.Bool("full", false, "print headers and bodies")
+				return func(args []string) error { return commands.TrafficShow(args[0], *full) }
+			},
+		},
+		&command{
+			name: "export", args: "<session>", summary: "Export a session as HAR",
+			minArgs: 1, maxArgs: 1, complete: firstName(commands.TrafficSessionIDs),
+			setup: func(fs *flag.FlagSet) func([]string) error {
+				fs.Bool("har", true, "export as HAR (the only supported format)")
+				output := fs.String("o", "", "output `file` (default <session>.har, - for stdout)")
+				return func(args []string) error { return commands.TrafficExport(args[0], *output) }
+			},
+		},
+	)
+}
 
-	case "restart":
-		return commands.ServicesRestart()
+func auditCommand() *command {
+	return (&command{name: "audit", group: "Audit", summary: "Inspect the audit log"}).add(
+		&command{
+			name: "tail", summary: "Show recent audit events", json: true,
+			setup: func(fs *flag.FlagSet) func([]string) error {
+				lines := fs.Int("n", 20, "number of events to show")
+				follow := fs.Bool("f", false, "follow new events")
+				return func([]string) error { return commands.AuditTail(*lines, *follow) }
+			},
+		},
+		&command{
+			name: "query", summary: "Search audit events", json: true,
+			setup: func(fs *flag.FlagSet) func([]string) error {
+				since := fs.String("since", "", "only events since (e.g. 24h, 7d, 2026-01-15)")
+				project := fs.String("project", "", "only events for this project")
+				agent := fs.String("agent", "", "only events for this agent")
+				eventType := fs.String("type", "", "only events of this type (e.g. exec, exec.end, service)")
+				return func([]string) error {
+					sinceTime, err := commands.ParseSince(*since)
+					if err != nil {
+						return err
+					}
+					return commands.AuditQuery(audit.Filter{
+						Since:   sinceTime,
+						Project: *project,
+						Agent:   *agent,
+						Type:    *eventType,
+					})
+				}
+			},
+		},
+	)
+}
 
-	default:
-		return fmt.Errorf("unknown services subcommand: %s", subcommand)
-	}
+func cacheCommand() *command {
+	return (&command{name: "cache", group: "Caches", summary: "Manage the package-manager caches"}).add(
+		&command{
+			name: "ls", aliases: []string{"list"}, summary: "List the package-manager cache volumes",
+			setup: noArgs(commands.CacheList),
+		},
+		&command{
+			name: "size", summary: "Show the size of each cache",
+			setup: noArgs(commands.CacheSize),
+		},
+		&command{
+			name: "clear", args: "[cache...]", summary: "Empty caches (all by default)", maxArgs: -1,
+			complete: eachName(commands.CacheNames),
+			setup: func(fs *flag.FlagSet) func([]string) error {
+				yes := fs.Bool("y", false, "skip the confirmation")
+				return func(args []string) error { return commands.CacheClear(args, *yes) }
+			},
+		},
+	)
 }
@@ -49,6 +49,24 @@ if [ "$(id -u $USERNAME)" != "$HOST_UID" ] || [ "$(id -g $USERNAME)" != "$HOST_G
     done
 fi
 
+# Hand the package-manager cache volumes to the user. Docker creates new
+# volumes, and the missing parents of their mount points, owned by root.
+if [ -n "${RIZE_CACHE_DIRS:-}" ]; then
+    IFS=: read -ra cache_dirs <<< "$RIZE_CACHE_DIRS"
+    for dir in "${cache_dirs[@]}"; do
+        if [ -d "$dir" ] && [ "$(stat -c %u "$dir")" != "$HOST_UID" ]; then
+            $SUDO chown -R "$HOST_UID:$HOST_GID" "$dir" 2>/dev/null || true
+        fi
+        parent=$(dirname "$dir")
+        while [ "$parent" != "/home/$USERNAME" ] && [ "$parent" != "/" ]; do
+            if [ "$(stat -c %u "$parent")" != "$HOST_UID" ]; then
+                $SUDO chown "$HOST_UID:$HOST_GID" "$parent" 2>/dev/null || true
+            fi
+            parent=$(dirname "$parent")
+        done
+    done
+fi
+
 # Ensure workspace (cwd) is writable if it's not mounted
 if [ ! -d "$WORKSPACE_DIR" ]; then
     $SUDO mkdir -p "$WORKSPACE_DIR"
@@ -81,6 +99,11 @@ if [ -n "${HTTP_PROXY:-}" ] && [[ "${HTTP_PROXY}" == *"mitmproxy"* ]]; then
     done
 fi
 
+# Record the command in the audit log (merged into ~/.rize/audit.log by the host)
+if command -v rize-audit >/dev/null 2>&1; then
+    rize-audit container.command "$@" || true
+fi
+
 # Switch to the user for the remaining commands
 if [ "$1" = "exec_as_root" ]; then
     shift
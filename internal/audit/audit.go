@@ -0,0 +1,244 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/alienxp03/rize/internal/config"
+	"github.com/alienxp03/rize/internal/redact"
+)
+
+// Event types written to the audit log
+const (
+	ContainerCreate = "container.create"
+	ContainerRemove = "container.remove"
+	ExecStart       = "exec.start"
+	ExecEnd         = "exec.end"
+	ServiceUp       = "service.up"
+	ServiceDown     = "service.down"
+	ServiceStart    = "service.start"
+	ServiceStop     = "service.stop"
+	ServiceRestart  = "service.restart"
+	ServiceSnapshot = "service.snapshot"
+	ServiceRestore  = "service.restore"
+	ServiceReset    = "service.reset"
+	ServiceSeed     = "service.seed"
+	ImagePull       = "image.pull"
+	ImageBuild      = "image.build"
+	CacheClear      = "cache.clear"
+	ConfigChange    = "config.change"
+)
+
+// Event is a single audit log entry. Events written from inside the
+// container by rize-audit use the same schema with source "container"; they
+// are spooled to ContainerPath and merged into the log by the host, since
+// the container only gets a read-only view of the log itself.
+type Event struct {
+	Time       time.Time         `json:"time"`
+	Type       string            `json:"type"`
+	Source     string            `json:"source,omitempty"`
+	HostUser   string            `json:"host_user,omitempty"`
+	Project    string            `json:"project,omitempty"`
+	Session    string            `json:"session,omitempty"`
+	Agent      string            `json:"agent,omitempty"`
+	Container  string            `json:"container,omitempty"`
+	Image      string            `json:"image,omitempty"`
+	Command    []string          `json:"command,omitempty"`
+	User       string            `json:"user,omitempty"`
+	Cwd        string            `json:"cwd,omitempty"`
+	ExitCode   *int              `json:"exit_code,omitempty"`
+	DurationMs int64             `json:"duration_ms,omitempty"`
+	Services   []string          `json:"services,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	Details    map[string]string `json:"details,omitempty"`
+}
+
+var mu sync.Mutex
+
+// Path returns the path to the audit log (~/.rize/audit.log)
+func Path() (string, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dataDir, "audit.log"), nil
+}
+
+// ContainerPath returns the file rize-audit appends in-container events to
+// (~/.rize/container/audit-container.log)
+func ContainerPath() (string, error) {
+	dataDir, err := config.ContainerDataDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dataDir, "audit-container.log"), nil
+}
+
+// Prepare creates the audit log and the container spool as the host user,
+// so they exist before they are mounted into a container and processes
+// running as root there never create them
+func Prepare() error {
+	for _, pathFunc := range []func() (string, error){Path, ContainerPath} {
+		path, err := pathFunc()
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return fmt.Errorf("failed to create audit directory: %w", err)
+		}
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", path, err)
+		}
+		file.Close()
+	}
+	return nil
+}
+
+// Log appends an event to the audit log. Auditing is best effort and never
+// interrupts the command being audited.
+func Log(ev Event) {
+	_ = Append(ev)
+}
+
+// Append appends an event to the audit log and reports any failure
+func Append(ev Event) error {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now().UTC()
+	}
+	if ev.Source == "" {
+		ev.Source = "cli"
+	}
+	if ev.HostUser == "" {
+		ev.HostUser = currentUser()
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	return write([]Event{ev})
+}
+
+// MergeContainerEvents moves the events the container spooled into the
+// audit log. Whatever the container wrote, merged events keep source
+// "container" and no host user.
+func MergeContainerEvents() error {
+	spool, err := ContainerPath()
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	info, err := os.Stat(spool)
+	if err != nil || info.Size() == 0 {
+		return nil
+	}
+
+	// Take the spool away before reading it, and put an empty one back for
+	// the container to keep appending to
+	merging := spool + ".merging"
+	if err := os.Rename(spool, merging); err != nil {
+		return fmt.Errorf("failed to merge container audit events: %w", err)
+	}
+	defer os.Remove(merging)
+	if file, err := os.OpenFile(spool, os.O_CREATE|os.O_WRONLY, 0600); err == nil {
+		file.Close()
+	}
+
+	file, err := os.Open(merging)
+	if err != nil {
+		return fmt.Errorf("failed to merge container audit events: %w", err)
+	}
+	defer file.Close()
+
+	var events []Event
+	if err := scan(file, func(ev Event) {
+		ev.Source = "container"
+		ev.HostUser = ""
+		if ev.Time.IsZero() {
+			ev.Time = time.Now().UTC()
+		}
+		events = append(events, ev)
+	}); err != nil {
+		return fmt.Errorf("failed to merge container audit events: %w", err)
+	}
+	return write(events)
+}
+
+// write appends events to the audit log; mu must be held
+func write(events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	var lines []byte
+	for _, ev := range events {
+		redactEvent(&ev)
+		line, err := json.Marshal(ev)
+		if err != nil {
+			return fmt.Errorf("failed to marshal audit event: %w", err)
+		}
+		lines = append(append(lines, line...), '\n')
+	}
+
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create audit directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(lines); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	return nil
+}
+
+// ExitCode returns a pointer to code for use in Event.ExitCode
+func ExitCode(code int) *int {
+	return &code
+}
+
+// ErrorString returns the message of err, or an empty string for nil
+func ErrorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// redactEvent removes secrets from the free-form fields of an event
+func redactEvent(ev *Event) {
+	r := redact.Default()
+	if len(ev.Command) > 0 {
+		ev.Command = r.Strings(append([]string(nil), ev.Command...))
+	}
+	ev.Error = r.String(ev.Error)
+	for key, value := range ev.Details {
+		ev.Details[key] = r.String(value)
+	}
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
@@ -0,0 +1,115 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAppendAndQuery(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	events := []Event{
+		{Type: ExecStart, Project: "api", Agent: "claude", Time: time.Now().Add(-48 * time.Hour)},
+		{Type: ExecEnd, Project: "api", Agent: "claude", ExitCode: ExitCode(0)},
+		{Type: ExecEnd, Project: "web", Agent: "codex", ExitCode: ExitCode(1)},
+		{Type: ServiceUp, Services: []string{"postgres"}},
+	}
+	for _, ev := range events {
+		if err := Append(ev); err != nil {
+			t.Fatalf("Failed to append event: %v", err)
+		}
+	}
+
+	all, err := Query(Filter{})
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	if len(all) != 4 {
+		t.Fatalf("Expected 4 events, got %d", len(all))
+	}
+	if all[0].Source != "cli" {
+		t.Errorf("Expected default source 'cli', got %q", all[0].Source)
+	}
+
+	recent, _ := Query(Filter{Since: time.Now().Add(-time.Hour), Type: "exec"})
+	if len(recent) != 2 {
+		t.Errorf("Expected 2 recent exec events, got %d", len(recent))
+	}
+
+	byAgent, _ := Query(Filter{Project: "web", Agent: "codex"})
+	if len(byAgent) != 1 || byAgent[0].ExitCode == nil || *byAgent[0].ExitCode != 1 {
+		t.Errorf("Unexpected events for web/codex: %+v", byAgent)
+	}
+}
+
+func TestTrackConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	configPath := filepath.Join(home, "config.yml")
+	if err := os.WriteFile(configPath, []byte("network:\n  name: rize\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	TrackConfig(configPath)
+	TrackConfig(configPath)
+
+	if err := os.WriteFile(configPath, []byte("network:\n  name: other\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	TrackConfig(configPath)
+
+	changes, err := Query(Filter{Type: ConfigChange})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 config changes, got %d", len(changes))
+	}
+	if changes[1].Details["previous_hash"] != changes[0].Details["hash"] {
+		t.Error("Expected second change to reference the previous hash")
+	}
+}
+
+func TestMergeContainerEvents(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if err := Prepare(); err != nil {
+		t.Fatalf("Failed to prepare audit log: %v", err)
+	}
+	spool, _ := ContainerPath()
+	info, err := os.Stat(spool)
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("Expected the spool to be created with mode 0600, got %v, %v", info, err)
+	}
+
+	if err := Append(Event{Type: ExecStart, Project: "api"}); err != nil {
+		t.Fatal(err)
+	}
+	// A container event claiming to come from the host CLI
+	line := `{"time":"2026-01-02T03:04:05Z","type":"shell.command","source":"cli","host_user":"root","command":["ls"]}` + "\n"
+	if err := os.WriteFile(spool, []byte(line+"not json\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := Query(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events after merging, got %d", len(events))
+	}
+	merged := events[1]
+	if merged.Type != "shell.command" || merged.Source != "container" || merged.HostUser != "" {
+		t.Errorf("Unexpected merged event: %+v", merged)
+	}
+
+	if info, err := os.Stat(spool); err != nil || info.Size() != 0 {
+		t.Errorf("Expected an empty spool after merging, got %v, %v", info, err)
+	}
+	if events, _ := Query(Filter{}); len(events) != 2 {
+		t.Errorf("Expected events to be merged once, got %d events", len(events))
+	}
+}
@@ -0,0 +1,64 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+type state struct {
+	ConfigHash string `json:"config_hash"`
+}
+
+// TrackConfig records a config.change event when the config file at
+// configPath differs from the last version seen by rize. This catches edits
+// made by hand as well as changes written by rize itself.
+func TrackConfig(configPath string) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	statePath, err := statePath()
+	if err != nil {
+		return
+	}
+
+	var previous state
+	if raw, err := os.ReadFile(statePath); err == nil {
+		_ = json.Unmarshal(raw, &previous)
+	}
+
+	if previous.ConfigHash == hash {
+		return
+	}
+
+	details := map[string]string{
+		"path": configPath,
+		"hash": hash,
+	}
+	if previous.ConfigHash != "" {
+		details["previous_hash"] = previous.ConfigHash
+	}
+	Log(Event{Type: ConfigChange, Details: details})
+
+	raw, err := json.Marshal(state{ConfigHash: hash})
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(statePath, raw, 0600)
+}
+
+func statePath() (string, error) {
+	path, err := Path()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(filepath.Dir(path), "audit.state"), nil
+}
@@ -0,0 +1,135 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Filter selects audit events
+type Filter struct {
+	Since   time.Time
+	Project string
+	Agent   string
+	Type    string
+}
+
+// Match reports whether ev passes the filter. Type accepts a prefix such as
+// "exec" to match both exec.start and exec.end.
+func (f Filter) Match(ev Event) bool {
+	if !f.Since.IsZero() && ev.Time.Before(f.Since) {
+		return false
+	}
+	if f.Project != "" && ev.Project != f.Project {
+		return false
+	}
+	if f.Agent != "" && ev.Agent != f.Agent {
+		return false
+	}
+	if f.Type != "" && ev.Type != f.Type && !strings.HasPrefix(ev.Type, f.Type+".") {
+		return false
+	}
+	return true
+}
+
+// Query returns all events in the audit log matching the filter, oldest first
+func Query(filter Filter) ([]Event, error) {
+	_ = MergeContainerEvents()
+
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var events []Event
+	err = scan(file, func(ev Event) {
+		if filter.Match(ev) {
+			events = append(events, ev)
+		}
+	})
+
+	return events, err
+}
+
+// Follow streams events appended to the audit log after the current end of
+// file, until stop is closed.
+func Follow(filter Filter, stop <-chan struct{}, fn func(Event)) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+
+	var offset int64
+	if info, err := os.Stat(path); err == nil {
+		offset = info.Size()
+	}
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+		_ = MergeContainerEvents()
+
+		info, err := os.Stat(path)
+		if err != nil || info.Size() <= offset {
+			if err == nil && info.Size() < offset {
+				// Log was truncated or rotated
+				offset = 0
+			}
+			continue
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			file.Close()
+			continue
+		}
+
+		err = scan(file, func(ev Event) {
+			if filter.Match(ev) {
+				fn(ev)
+			}
+		})
+		offset = info.Size()
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func scan(r io.Reader, fn func(Event)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	for scanner.Scan() {
+		var ev Event
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			// Skip partial or corrupt lines rather than failing the query
+			continue
+		}
+		fn(ev)
+	}
+
+	return scanner.Err()
+}
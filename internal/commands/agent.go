@@ -1,14 +1,13 @@
 package commands
 
 import (
-	"github.com/alienxp03/rize/internal/config"
 	"github.com/alienxp03/rize/internal/docker"
 	"github.com/alienxp03/rize/internal/ui"
 )
 
 // Agent runs a specific AI agent
 func Agent(name string, args []string) error {
-	cfg, err := config.Load()
+	cfg, err := loadConfig()
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,144 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alienxp03/rize/internal/audit"
+	"github.com/alienxp03/rize/internal/ui"
+)
+
+// AuditTail prints the last n audit events, optionally following new ones
+func AuditTail(n int, follow bool) error {
+	events, err := audit.Query(audit.Filter{})
+	if err != nil {
+		return err
+	}
+
+	if n > 0 && len(events) > n {
+		events = events[len(events)-n:]
+	}
+
+	for _, ev := range events {
+		printAuditEvent(ev)
+	}
+
+	if !follow {
+		return nil
+	}
+
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	defer signal.Stop(signals)
+	go func() {
+		<-signals
+		close(stop)
+	}()
+
+	return audit.Follow(audit.Filter{}, stop, func(ev audit.Event) {
+		printAuditEvent(ev)
+	})
+}
+
+// AuditQuery prints audit events matching the filter
+func AuditQuery(filter audit.Filter) error {
+	events, err := audit.Query(filter)
+	if err != nil {
+		return err
+	}
+
+	if len(events) == 0 && !JSON {
+		ui.Info("No matching audit events")
+		return nil
+	}
+
+	for _, ev := range events {
+		printAuditEvent(ev)
+	}
+
+	return nil
+}
+
+// printAuditEvent prints an event, as a JSON line with --output json
+func printAuditEvent(ev audit.Event) {
+	if JSON {
+		line, err := json.Marshal(ev)
+		if err == nil {
+			fmt.Println(string(line))
+		}
+		return
+	}
+
+	parts := []string{
+		ev.Time.Local().Format("2006-01-02 15:04:05"),
+		fmt.Sprintf("%-17s", ev.Type),
+	}
+	if ev.Source != "" && ev.Source != "cli" {
+		parts = append(parts, "["+ev.Source+"]")
+	}
+	if ev.Project != "" {
+		parts = append(parts, "project="+ev.Project)
+	}
+	if ev.Agent != "" {
+		parts = append(parts, "agent="+ev.Agent)
+	}
+	if ev.Container != "" {
+		parts = append(parts, "container="+ev.Container)
+	}
+	if ev.Image != "" && ev.Type == audit.ImagePull {
+		parts = append(parts, "image="+ev.Image)
+	}
+	if len(ev.Services) > 0 {
+		parts = append(parts, "services="+strings.Join(ev.Services, ","))
+	}
+	if len(ev.Command) > 0 {
+		parts = append(parts, strconv.Quote(strings.Join(ev.Command, " ")))
+	}
+	if ev.ExitCode != nil {
+		parts = append(parts, fmt.Sprintf("exit=%d", *ev.ExitCode))
+	}
+	if ev.DurationMs > 0 {
+		parts = append(parts, (time.Duration(ev.DurationMs) * time.Millisecond).String())
+	}
+
+	line := strings.Join(parts, "  ")
+	if ev.Error != "" {
+		line += "  " + ui.Red("error: "+ev.Error)
+	}
+
+	fmt.Println(line)
+}
+
+// ParseSince parses a --since value. It accepts Go durations ("90m"),
+// day counts ("7d"), dates ("2026-01-15") and RFC 3339 timestamps.
+func ParseSince(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if strings.HasSuffix(value, "d") {
+		if days, err := strconv.Atoi(strings.TrimSuffix(value, "d")); err == nil {
+			return time.Now().AddDate(0, 0, -days), nil
+		}
+	}
+
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid --since value %q (use e.g. 24h, 7d, 2026-01-15)", value)
+}
@@ -0,0 +1,134 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/alienxp03/rize/internal/docker"
+	"github.com/alienxp03/rize/internal/ui"
+)
+
+// CacheList lists the package-manager cache volumes
+func CacheList() error {
+	return listCaches(false)
+}
+
+// CacheSize lists the package-manager cache volumes with their sizes
+func CacheSize() error {
+	return listCaches(true)
+}
+
+func listCaches(withSize bool) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	client, err := docker.NewClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	caches, err := client.Caches(cfg, withSize)
+	if err != nil {
+		return err
+	}
+
+	if len(caches) == 0 {
+		ui.Info("No caches configured")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if withSize {
+		fmt.Fprintln(w, "CACHE\tSIZE\tIN USE")
+	} else {
+		fmt.Fprintln(w, "CACHE\tPATH\tVOLUME\tCREATED")
+	}
+
+	var total int64
+	for _, cache := range caches {
+		if withSize {
+			size, inUse := "-", "-"
+			if cache.Size >= 0 {
+				size = formatBytes(cache.Size)
+				total += cache.Size
+			}
+			if cache.InUse >= 0 {
+				inUse = fmt.Sprintf("%d", cache.InUse)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", cache.Name, size, inUse)
+			continue
+		}
+
+		path := cache.Path
+		if path == "" {
+			path = "(not configured)"
+		}
+		created := "-"
+		if cache.Exists && !cache.Created.IsZero() {
+			created = cache.Created.Local().Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", cache.Name, path, cache.Volume, created)
+	}
+	if withSize {
+		fmt.Fprintf(w, "TOTAL\t%s\t\n", formatBytes(total))
+	}
+	return w.Flush()
+}
+
+// CacheClear empties the given caches, or all caches when none are given
+func CacheClear(names []string, yes bool) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	client, err := docker.NewClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	caches, err := client.Caches(cfg, false)
+	if err != nil {
+		return err
+	}
+
+	known := map[string]bool{}
+	for _, cache := range caches {
+		known[cache.Name] = true
+	}
+	for _, name := range names {
+		if !known[name] {
+			return fmt.Errorf("unknown cache: %s (see: rize cache ls)", name)
+		}
+	}
+
+	if len(names) == 0 {
+		for _, cache := range caches {
+			if cache.Exists {
+				names = append(names, cache.Name)
+			}
+		}
+		if len(names) == 0 {
+			ui.Info("No caches to clear")
+			return nil
+		}
+		if !yes && !confirm("This will delete all package-manager caches") {
+			ui.Info("Clear cancelled")
+			return nil
+		}
+	}
+
+	for _, name := range names {
+		if err := client.ClearCache(name); err != nil {
+			return fmt.Errorf("failed to clear cache %s: %w", name, err)
+		}
+		ui.Success("Cleared %s", name)
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package commands
+
+import (
+	"os"
+	"sort"
+
+	"github.com/alienxp03/rize/internal/config"
+	"github.com/alienxp03/rize/internal/docker"
+	"github.com/alienxp03/rize/internal/recording"
+	"github.com/alienxp03/rize/internal/traffic"
+)
+
+// The functions below return names for shell completion. They never fail:
+// completion stays silent when a name cannot be read.
+
+// completionConfig returns the config with the project config applied
+func completionConfig() *config.Config {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		if project, err := config.LoadProject(cwd); err == nil {
+			cfg.ApplyProject(project)
+		}
+	}
+	return cfg
+}
+
+// ServiceNames returns the configured services
+func ServiceNames() []string {
+	cfg := completionConfig()
+	if cfg == nil {
+		return nil
+	}
+	return cfg.ServiceNames()
+}
+
+// CacheNames returns the configured caches
+func CacheNames() []string {
+	cfg := completionConfig()
+	if cfg == nil {
+		return nil
+	}
+
+	var names []string
+	for name, path := range cfg.Caches {
+		if path != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SnapshotNames returns the snapshots of a service of the current project
+func SnapshotNames(service string) []string {
+	cfg := completionConfig()
+	if cfg == nil {
+		return nil
+	}
+	project, err := docker.CurrentServiceProject(cfg)
+	if err != nil {
+		return nil
+	}
+	snapshots, err := docker.ListSnapshots(project, service)
+	if err != nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		names = append(names, snapshot.Name)
+	}
+	return names
+}
+
+// RecordingIDs returns the IDs of the recorded sessions, newest first
+func RecordingIDs() []string {
+	recordings, err := recording.List()
+	if err != nil {
+		return nil
+	}
+
+	ids := make([]string, 0, len(recordings))
+	for _, rec := range recordings {
+		ids = append(ids, rec.ID)
+	}
+	return ids
+}
+
+// TrafficSessionIDs returns the IDs of the sessions with captured traffic,
+// newest first
+func TrafficSessionIDs() []string {
+	sessions, err := traffic.List()
+	if err != nil {
+		return nil
+	}
+
+	ids := make([]string, 0, len(sessions))
+	for _, session := range sessions {
+		ids = append(ids, session.ID)
+	}
+	return ids
+}
@@ -0,0 +1,139 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/alienxp03/rize/internal/ui"
+)
+
+// CompletionShells are the shells rize generates completion scripts for
+var CompletionShells = []string{"bash", "zsh", "fish"}
+
+// The scripts ask `rize __complete <words...>` for the candidates of the
+// last word, so they follow new commands and flags without being
+// regenerated. Without candidates the shells complete file names.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for rize
+_rize() {
+    local IFS=$'\n'
+    COMPREPLY=($("${COMP_WORDS[0]}" __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _rize rize
+`,
+	"zsh": `#compdef rize
+# zsh completion for rize
+_rize() {
+    local -a candidates
+    candidates=("${(@f)$(${words[1]} __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    candidates=(${candidates:#})
+    if (( ${#candidates} )); then
+        compadd -a candidates
+    else
+        _files
+    fi
+}
+if [ "$funcstack[1]" = "_rize" ]; then
+    _rize "$@"
+else
+    compdef _rize rize
+fi
+`,
+	"fish": `# fish completion for rize
+function __rize_complete
+    set -l words (commandline -opc)
+    set -l candidates ($words[1] __complete $words[2..-1] (commandline -ct) 2>/dev/null)
+    if test (count $candidates) -gt 0
+        printf '%s\n' $candidates
+    else
+        __fish_complete_path (commandline -ct)
+    end
+end
+complete -c rize -f -a '(__rize_complete)'
+`,
+}
+
+// Completion prints the completion script of a shell
+func Completion(shell string) error {
+	script, ok := completionScripts[shell]
+	if !ok {
+		return fmt.Errorf("unsupported shell %q (supported: %s)", shell, strings.Join(CompletionShells, ", "))
+	}
+	fmt.Print(script)
+	return nil
+}
+
+// completionPath returns where a shell loads the completion script of rize
+// from, in the user's home
+func completionPath(shell string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	dataDir := os.Getenv("XDG_DATA_HOME")
+	if dataDir == "" {
+		dataDir = filepath.Join(home, ".local", "share")
+	}
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		configDir = filepath.Join(home, ".config")
+	}
+
+	switch shell {
+	case "bash":
+		return filepath.Join(dataDir, "bash-completion", "completions", "rize"), nil
+	case "zsh":
+		return filepath.Join(dataDir, "zsh", "site-functions", "_rize"), nil
+	case "fish":
+		return filepath.Join(configDir, "fish", "completions", "rize.fish"), nil
+	}
+	return "", fmt.Errorf("unsupported shell %q", shell)
+}
+
+// installCompletions writes the completion scripts of the installed shells
+func installCompletions() error {
+	installed := 0
+	for _, shell := range CompletionShells {
+		if _, err := exec.LookPath(shell); err != nil {
+			continue
+		}
+		path, err := completionPath(shell)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create completion directory: %w", err)
+		}
+		if err := os.WriteFile(path, []byte(completionScripts[shell]), 0644); err != nil {
+			return fmt.Errorf("failed to write %s completion: %w", shell, err)
+		}
+		ui.Success("Installed %s completion to %s", shell, path)
+		installed++
+
+		if shell == "zsh" {
+			ui.Info("If zsh does not pick it up, add this line to ~/.zshrc before compinit:")
+			fmt.Fprintf(os.Stderr, "  fpath=(%s $fpath)\n", filepath.Dir(path))
+		}
+	}
+
+	if installed == 0 {
+		ui.Warning("No supported shell found (%s); run rize completion <shell> to print a script", strings.Join(CompletionShells, ", "))
+	}
+	return nil
+}
+
+// completionFiles returns the installed completion scripts
+func completionFiles() []string {
+	var files []string
+	for _, shell := range CompletionShells {
+		if path, err := completionPath(shell); err == nil {
+			if _, err := os.Stat(path); err == nil {
+				files = append(files, path)
+			}
+		}
+	}
+	return files
+}
@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/alienxp03/rize/internal/audit"
+	"github.com/alienxp03/rize/internal/config"
+	"github.com/alienxp03/rize/internal/docker"
+	"github.com/alienxp03/rize/internal/doctor"
+	"github.com/alienxp03/rize/internal/redact"
+	"github.com/alienxp03/rize/internal/ui"
+	"gopkg.in/yaml.v3"
+)
+
+// loadConfig loads the configuration with the project's .rize.yml and
+// devcontainer.json, applies the redaction rules and records config changes
+// in the audit log
+func loadConfig() (*config.Config, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	if cwd, err := os.Getwd(); err == nil {
+		project, err := config.LoadProject(cwd)
+		if err != nil {
+			return nil, err
+		}
+		cfg.ApplyProject(project)
+
+		cfg.DevContainer, err = config.LoadDevContainer(cwd, docker.WorkspaceDir(cwd))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := redact.Configure(cfg.Redaction); err != nil {
+		return nil, err
+	}
+
+	if configPath, err := config.ConfigPath(); err == nil {
+		audit.TrackConfig(configPath)
+	}
+
+	return cfg, nil
+}
+
+// warnProject prints what rize could not import from the project's compose
+// file
+func warnProject(cfg *config.Config) {
+	if cfg.Project == nil {
+		return
+	}
+	for _, warning := range cfg.Project.Warnings {
+		ui.Warning("%s: %s", cfg.Project.Compose, warning)
+	}
+}
+
+// ConfigShow prints the configuration rize applies in the current directory,
+// config.yml merged with .rize.yml, with secrets redacted
+func ConfigShow() error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	effective, err := doctor.EffectiveConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	output := struct {
+		Path          string                 `json:"path"`
+		ProjectConfig string                 `json:"project_config,omitempty"`
+		DevContainer  string                 `json:"devcontainer,omitempty"`
+		Config        map[string]interface{} `json:"config"`
+	}{Config: effective}
+	output.Path, _ = config.ConfigPath()
+	if cfg.Project != nil {
+		output.ProjectConfig = cfg.Project.Path
+	}
+	if cfg.DevContainer != nil {
+		output.DevContainer = cfg.DevContainer.Path
+	}
+
+	if JSON {
+		return printJSON(output)
+	}
+
+	fmt.Printf("# %s\n", output.Path)
+	if output.ProjectConfig != "" {
+		fmt.Printf("# %s\n", output.ProjectConfig)
+	}
+	if output.DevContainer != "" {
+		fmt.Printf("# %s\n", output.DevContainer)
+	}
+	data, err := yaml.Marshal(effective)
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	fmt.Print(string(data))
+	return nil
+}
@@ -0,0 +1,161 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alienxp03/rize/internal/config"
+	"github.com/alienxp03/rize/internal/docker"
+	"github.com/alienxp03/rize/internal/doctor"
+	"github.com/alienxp03/rize/internal/ui"
+	"gopkg.in/yaml.v3"
+)
+
+// Doctor checks the environment rize runs in and reports how rize sees the
+// current project, in a form that can be pasted into bug reports. It fails
+// with a CheckError when a check fails.
+func Doctor() error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	cwd, _ := os.Getwd()
+	client, err := docker.NewClient()
+	if err == nil {
+		defer client.Close()
+	} else {
+		client = nil
+	}
+
+	report := doctor.Run(cfg, client, cwd)
+
+	if JSON {
+		if err := printJSON(report); err != nil {
+			return err
+		}
+	} else if err := printDoctorReport(report, cfg, cwd); err != nil {
+		return err
+	}
+
+	if failed := report.Failed(); failed > 0 {
+		return &CheckError{fmt.Sprintf("%d of %d checks failed", failed, len(report.Checks))}
+	}
+	return nil
+}
+
+func printDoctorReport(report *doctor.Report, cfg *config.Config, cwd string) error {
+	relative := func(path string) string {
+		if rel, err := filepath.Rel(cwd, path); err == nil {
+			return rel
+		}
+		return path
+	}
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Printf("  %-10s%s\n", name+":", value)
+		}
+	}
+
+	fmt.Printf("rize %s (%s)\n", report.Version.Version, report.Version.Platform)
+	fmt.Println()
+
+	fmt.Println("Checks:")
+	for _, check := range report.Checks {
+		switch check.Status {
+		case doctor.Pass:
+			printMark(ui.Green("✓"), "%s: %s", check.Name, check.Message)
+		case doctor.Warn:
+			printMark(ui.Yellow("!"), "%s: %s", check.Name, check.Message)
+		case doctor.Fail:
+			printMark(ui.Red("✗"), "%s: %s", check.Name, check.Message)
+		default:
+			printMark(ui.Blue("→"), "%s: %s", check.Name, check.Message)
+		}
+		if check.Hint != "" {
+			fmt.Printf("    %s\n", check.Hint)
+		}
+	}
+	fmt.Println()
+
+	fmt.Println("Image:")
+	field("Ref", report.ImageRef)
+	if image := report.Image; image != nil {
+		field("Version", image.Version)
+		field("ID", image.ID)
+		field("Digest", image.Digest)
+		field("Created", image.Created)
+	}
+	fmt.Println()
+
+	if container := report.Container; container != nil {
+		fmt.Println("Project container:")
+		field("Name", container.Name)
+		field("State", container.State)
+		field("Image", container.Image)
+		field("Created", container.Created)
+		if container.ID != "" {
+			field("Sessions", fmt.Sprintf("%d", container.Sessions))
+		}
+		fmt.Println()
+	}
+
+	fmt.Println("Project config:")
+	if cfg.Project == nil {
+		printMark(ui.Blue("→"), "No .rize.yml")
+	} else {
+		printMark(ui.Green("✓"), "%s", relative(cfg.Project.Path))
+		if build := cfg.Project.Build; build != nil {
+			printMark(ui.Blue("→"), "Image built from %s", projectBuildSources(build, relative))
+		}
+		warnProject(cfg)
+	}
+	fmt.Println()
+
+	fmt.Println("Devcontainer:")
+	if dc := cfg.DevContainer; dc == nil {
+		printMark(ui.Blue("→"), "No devcontainer.json")
+	} else {
+		printMark(ui.Blue("→"), "%s", relative(dc.Path))
+		for _, property := range dc.Applied {
+			printMark(ui.Green("✓"), "%s", property)
+		}
+		for _, property := range dc.Ignored {
+			printMark(ui.Yellow("!"), "%s (ignored)", property)
+		}
+	}
+	fmt.Println()
+
+	fmt.Println("Effective config (secrets redacted):")
+	data, err := yaml.Marshal(report.Config)
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	fmt.Print(string(data))
+	return nil
+}
+
+// printMark prints a line of the report on stdout, marked like the ui
+// messages
+func printMark(mark, format string, a ...interface{}) {
+	fmt.Printf("%s %s\n", mark, fmt.Sprintf(format, a...))
+}
+
+// projectBuildSources describes what the .rize.yml build installs
+func projectBuildSources(build *config.ProjectBuild, relative func(string) string) string {
+	var sources []string
+	if build.Dockerfile != "" {
+		sources = append(sources, relative(build.Dockerfile))
+	}
+	for _, list := range []struct {
+		name     string
+		packages []string
+	}{{"apt", build.Apt}, {"mise", build.Mise}, {"npm", build.Npm}} {
+		if len(list.packages) > 0 {
+			sources = append(sources, fmt.Sprintf("%s: %s", list.name, strings.Join(list.packages, " ")))
+		}
+	}
+	return strings.Join(sources, ", ")
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/alienxp03/rize/internal/audit"
 	"github.com/alienxp03/rize/internal/config"
 	"github.com/alienxp03/rize/internal/ui"
 )
@@ -27,6 +28,7 @@ func Init() error {
 	if err := config.Save(cfg); err != nil {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
+	audit.TrackConfig(configPath)
 
 	ui.Success("Config file created successfully")
 	ui.Info("Edit the config file to customize your environment")
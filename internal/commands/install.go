@@ -2,57 +2,89 @@ package commands
 
 import (
 	"fmt"
-	"io"
-	"net/http"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"runtime"
+	"strings"
+	"text/tabwriter"
 
+	"github.com/alienxp03/rize/internal/config"
 	"github.com/alienxp03/rize/internal/docker"
 	"github.com/alienxp03/rize/internal/ui"
+	"github.com/alienxp03/rize/internal/update"
+	"github.com/moby/term"
 )
 
-const (
-	installPath     = "/usr/local/bin/rize"
-	githubReleaseURL = "https://github.com/alienxp03/rize/releases/latest/download/rize-%s-%s"
-)
-
-// Install installs rize to /usr/local/bin
-func Install() error {
-	ui.Info("Installing rize to %s", installPath)
-
+// Install copies the running binary to prefix, or to /usr/local/bin when it
+// is writable and ~/.local/bin otherwise, and records where it went. With
+// completions it also installs the shell completion scripts.
+func Install(prefix string, completions bool) error {
 	// Get current executable path
 	exePath, err := os.Executable()
 	if err != nil {
 		return fmt.Errorf("failed to get executable path: %w", err)
 	}
 
-	// Check if we can write to install directory
-	installDir := filepath.Dir(installPath)
-	if !isWritable(installDir) {
-		// Need sudo
+	installDir := prefix
+	if installDir == "" {
+		if installDir, err = update.DefaultInstallDir(); err != nil {
+			return err
+		}
+	} else if installDir, err = expandHome(installDir); err != nil {
+		return err
+	}
+	if installDir, err = filepath.Abs(installDir); err != nil {
+		return err
+	}
+	installPath := filepath.Join(installDir, update.BinaryName)
+	previous := update.InstalledPath()
+
+	ui.Info("Installing rize to %s", installPath)
+
+	os.MkdirAll(installDir, 0755)
+	if update.Writable(installDir) {
+		if err := update.InstallBinary(exePath, installPath); err != nil {
+			return err
+		}
+	} else {
+		// Only a prefix the user picked can need sudo
 		ui.Warning("Installing to %s requires elevated permissions", installPath)
-		return installWithSudo(exePath)
+		if err := installWithSudo(exePath, installPath); err != nil {
+			return err
+		}
 	}
 
-	// Copy file
-	if err := copyFile(exePath, installPath); err != nil {
-		return fmt.Errorf("failed to copy file: %w", err)
+	if err := update.RecordInstall(installPath); err != nil {
+		ui.Warning("Failed to record the install location: %v", err)
 	}
+	ui.Success("Installed rize to %s", installPath)
 
-	// Make executable
-	if err := os.Chmod(installPath, 0755); err != nil {
-		return fmt.Errorf("failed to make executable: %w", err)
+	if previous != "" && previous != installPath {
+		ui.Warning("Another rize binary remains at %s", previous)
 	}
 
-	ui.Success("Installed rize to %s", installPath)
+	if !update.OnPath(installDir) {
+		line, file := update.PathInstructions(installDir)
+		ui.Warning("%s is not on your PATH", installDir)
+		ui.Info("Add this line to %s and open a new shell:", file)
+		fmt.Fprintf(os.Stderr, "  %s\n", line)
+	}
+
+	if completions {
+		return installCompletions()
+	}
 	return nil
 }
 
-// Update updates rize and pulls the latest image
-func Update() error {
-	ui.Info("Updating rize image...")
+// Update installs the release of a channel (stable, nightly or a pinned
+// version) and pulls its image, so the binary and the image never drift
+// apart. The binary is only replaced by a release whose checksum and
+// signature verify; older releases are refused unless force is set.
+func Update(channel string, force bool) error {
+	if channel == "" {
+		channel = update.DefaultChannel()
+	}
 
 	client, err := docker.NewClient()
 	if err != nil {
@@ -60,156 +92,289 @@ func Update() error {
 	}
 	defer client.Close()
 
-	if err := client.PullImage(); err != nil {
-		return fmt.Errorf("failed to pull image: %w", err)
-	}
-
-	ui.Success("Image updated")
+	installPath := update.InstalledPath()
+	installed := installPath != ""
 
-	// Try to update binary if installed
-	if _, err := os.Stat(installPath); err == nil {
-		ui.Info("Updating rize binary...")
-		if err := downloadLatestBinary(); err != nil {
-			ui.Warning("Failed to update binary: %v", err)
-			ui.Info("To update manually, run: rize install")
-		} else {
-			ui.Success("Binary updated")
+	updater, err := update.New(installPath, channel)
+	if err != nil {
+		if channel != update.DefaultChannel() {
+			return err
 		}
+		// Without a verified release only the image of this binary can be
+		// updated
+		ui.Warning("Cannot update the binary: %v", err)
+		return pullImage(client, docker.ImageName)
 	}
 
-	return nil
-}
+	ui.Info("Checking the %s release...", channel)
+	release, err := updater.Release()
+	if err != nil {
+		return fmt.Errorf("failed to get the %s release: %w", channel, err)
+	}
 
-// Uninstall removes rize
-func Uninstall() error {
-	ui.Warning("This will remove the rize image and binary")
-	ui.Info("Continue? [y/N]")
+	result := &update.Result{To: release.Version}
+	if installed {
+		if result, err = updater.Check(release, force); err != nil {
+			return err
+		}
+	}
 
-	var confirm string
-	fmt.Scanln(&confirm)
+	if err := pullImage(client, docker.ImageRef(release.Version)); err != nil {
+		return err
+	}
 
-	if confirm != "y" && confirm != "Y" {
-		ui.Info("Uninstall cancelled")
+	if !installed {
+		ui.Info("rize is not installed; run rize install to install this binary")
+		return nil
+	}
+	if result.UpToDate {
+		ui.Success("Binary is up to date (%s)", result.To)
 		return nil
 	}
 
-	// Remove image
-	ui.Info("Removing Docker image...")
-	client, err := docker.NewClient()
-	if err == nil {
-		if err := client.RemoveImage(); err != nil {
-			ui.Warning("Failed to remove image: %v", err)
-		} else {
-			ui.Success("Image removed")
-		}
-		client.Close()
-	}
-
-	// Remove binary
-	if _, err := os.Stat(installPath); err == nil {
-		ui.Info("Removing binary...")
-		if !isWritable(filepath.Dir(installPath)) {
-			cmd := exec.Command("sudo", "rm", "-f", installPath)
-			cmd.Stdout = os.Stdout
-			cmd.Stderr = os.Stderr
-			if err := cmd.Run(); err != nil {
-				ui.Warning("Failed to remove binary: %v", err)
-			} else {
-				ui.Success("Binary removed")
-			}
-		} else {
-			if err := os.Remove(installPath); err != nil {
-				ui.Warning("Failed to remove binary: %v", err)
-			} else {
-				ui.Success("Binary removed")
-			}
-		}
+	ui.Info("Updating rize binary to %s...", release.Version)
+	if err := updater.Install(release); err != nil {
+		return fmt.Errorf("failed to update binary: %w", err)
 	}
+	ui.Success("Binary updated from %s to %s", result.From, result.To)
+	return nil
+}
 
-	ui.Success("Uninstall complete")
+func pullImage(client *docker.Client, ref string) error {
+	ui.Info("Pulling %s...", ref)
+	if err := client.PullImage(ref); err != nil {
+		return err
+	}
+	ui.Success("Image updated")
 	return nil
 }
 
-// Helper functions
+// UpdateRollback restores the binary the last update replaced
+func UpdateRollback() error {
+	installPath := update.InstalledPath()
+	if installPath == "" {
+		return fmt.Errorf("rize is not installed")
+	}
 
-func isWritable(path string) bool {
-	file, err := os.OpenFile(filepath.Join(path, ".rize-test"), os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return false
+	if err := update.Rollback(installPath); err != nil {
+		return fmt.Errorf("failed to roll back: %w", err)
 	}
-	file.Close()
-	os.Remove(filepath.Join(path, ".rize-test"))
-	return true
+
+	ui.Success("Restored the previous rize binary (run rize update --rollback again to undo)")
+	return nil
 }
 
-func copyFile(src, dst string) error {
-	source, err := os.Open(src)
-	if err != nil {
-		return err
+// uninstallItem is something uninstall removes: a docker resource, the
+// binary or a directory
+type uninstallItem struct {
+	kind string
+	name string
+	// size is the size in bytes, -1 when unknown
+	size     int64
+	resource *docker.Resource
+}
+
+// Uninstall removes the binary, its shell completions and every docker
+// resource, the configuration and the data rize created. keepData keeps the
+// volumes, the configuration and ~/.rize; dryRun only lists what would be
+// removed. Without yes it asks for confirmation, and refuses to run when it
+// cannot ask.
+func Uninstall(keepData, dryRun, yes bool) error {
+	cfg := config.DefaultConfig()
+	if configPath, err := config.ConfigPath(); err == nil {
+		if _, err := os.Stat(configPath); err == nil {
+			if cfg, err = config.Load(); err != nil {
+				return err
+			}
+		}
 	}
-	defer source.Close()
 
-	destination, err := os.Create(dst)
+	var items []uninstallItem
+	kept := false
+
+	client, err := docker.NewClient()
 	if err != nil {
-		return err
+		ui.Warning("Docker is not available, skipping containers, volumes and images: %v", err)
+	} else {
+		defer client.Close()
+		resources, err := client.Resources(cfg)
+		if err != nil {
+			ui.Warning("Skipping docker resources: %v", err)
+		}
+		for i := range resources {
+			if keepData && resources[i].Data {
+				kept = true
+				continue
+			}
+			items = append(items, uninstallItem{
+				kind:     resources[i].Kind,
+				name:     resources[i].Name,
+				size:     resources[i].Size,
+				resource: &resources[i],
+			})
+		}
 	}
-	defer destination.Close()
 
-	_, err = io.Copy(destination, source)
-	return err
-}
+	installPath := update.InstalledPath()
+	if installPath != "" {
+		items = append(items, uninstallItem{kind: "binary", name: installPath, size: pathSize(installPath)})
+	}
 
-func installWithSudo(exePath string) error {
-	cmd := exec.Command("sudo", "install", "-m", "755", exePath, installPath)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	for _, path := range completionFiles() {
+		items = append(items, uninstallItem{kind: "completion", name: path, size: pathSize(path)})
+	}
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to install with sudo: %w", err)
+	var dirs []string
+	if configPath, err := config.ConfigPath(); err == nil {
+		dirs = append(dirs, filepath.Dir(configPath))
+	}
+	if dataDir, err := config.DataDir(); err == nil {
+		dirs = append(dirs, dataDir)
+	}
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); err != nil {
+			continue
+		}
+		if keepData {
+			kept = true
+			continue
+		}
+		items = append(items, uninstallItem{kind: "directory", name: dir, size: pathSize(dir)})
 	}
 
-	ui.Success("Installed rize to %s", installPath)
-	return nil
-}
+	if kept {
+		ui.Info("Keeping volumes, ~/.config/rize and ~/.rize (--keep-data)")
+	}
+	if len(items) == 0 {
+		ui.Info("Nothing to uninstall")
+		return nil
+	}
 
-func downloadLatestBinary() error {
-	url := fmt.Sprintf(githubReleaseURL, runtime.GOOS, runtime.GOARCH)
+	printUninstallItems(items)
 
-	resp, err := http.Get(url)
-	if err != nil {
-		return err
+	if dryRun {
+		ui.Info("Dry run, nothing was removed")
+		return nil
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to download: %s", resp.Status)
+	if !yes {
+		if !term.IsTerminal(os.Stdin.Fd()) {
+			return fmt.Errorf("uninstall needs confirmation; run rize uninstall --yes to uninstall non-interactively")
+		}
+		if !confirm("This will remove everything listed above") {
+			ui.Info("Uninstall cancelled")
+			return nil
+		}
 	}
 
-	tmpFile, err := os.CreateTemp("", "rize-*")
-	if err != nil {
-		return err
+	failed := 0
+	for _, item := range items {
+		var err error
+		switch {
+		case item.resource != nil:
+			err = client.RemoveResource(*item.resource)
+		case item.kind == "binary":
+			if err = removeBinary(item.name); err == nil {
+				update.ForgetInstall()
+			}
+		default:
+			err = os.RemoveAll(item.name)
+		}
+		if err != nil {
+			ui.Warning("Failed to remove %s %s: %v", item.kind, item.name, err)
+			failed++
+			continue
+		}
+		ui.Success("Removed %s %s", item.kind, item.name)
 	}
-	defer os.Remove(tmpFile.Name())
-	defer tmpFile.Close()
 
-	if _, err := io.Copy(tmpFile, resp.Body); err != nil {
-		return err
+	if failed > 0 {
+		return fmt.Errorf("uninstall incomplete: %d of %d items could not be removed", failed, len(items))
 	}
+	ui.Success("Uninstall complete")
+	return nil
+}
 
-	tmpFile.Close()
+func printUninstallItems(items []uninstallItem) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tNAME\tSIZE")
 
-	// Make executable
-	if err := os.Chmod(tmpFile.Name(), 0755); err != nil {
-		return err
+	var total int64
+	for _, item := range items {
+		size := "-"
+		if item.size >= 0 {
+			size = formatBytes(item.size)
+			total += item.size
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", item.kind, item.name, size)
 	}
+	fmt.Fprintf(w, "TOTAL\t\t%s\n", formatBytes(total))
+	w.Flush()
+}
 
-	// Install
-	if !isWritable(filepath.Dir(installPath)) {
-		cmd := exec.Command("sudo", "install", "-m", "755", tmpFile.Name(), installPath)
+// removeBinary removes the installed binary and the copy kept for rollback,
+// with sudo when its directory is not writable
+func removeBinary(installPath string) error {
+	paths := []string{installPath, installPath + ".previous"}
+	if !update.Writable(filepath.Dir(installPath)) {
+		cmd := exec.Command("sudo", append([]string{"rm", "-f"}, paths...)...)
+		cmd.Stdin = os.Stdin
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
 		return cmd.Run()
 	}
 
-	return copyFile(tmpFile.Name(), installPath)
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// pathSize returns the size of a file or of the files in a directory, -1
+// when it cannot be read
+func pathSize(path string) int64 {
+	var size int64
+	err := filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.Type().IsRegular() {
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return -1
+	}
+	return size
+}
+
+// Helper functions
+
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
+
+func installWithSudo(exePath, installPath string) error {
+	cmd := exec.Command("sudo", "install", "-m", "755", exePath, installPath)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to install with sudo: %w", err)
+	}
+	return nil
 }
@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// JSON is set by --output json. Commands supporting it print one JSON
+// document on stdout instead of text; messages always go to stderr.
+var JSON bool
+
+// CheckError is returned by commands that ran but found a problem, like an
+// unhealthy service, so scripts can tell it from a command that failed to
+// run. rize exits with status 3 for it.
+type CheckError struct {
+	Message string
+}
+
+func (e *CheckError) Error() string {
+	return e.Message
+}
+
+// printJSON prints v as indented JSON on stdout
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,46 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/alienxp03/rize/internal/docker"
+	"github.com/alienxp03/rize/internal/ui"
+)
+
+// Ps lists the project containers of every workspace
+func Ps() error {
+	client, err := docker.NewClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	containers, err := client.ProjectContainers()
+	if err != nil {
+		return err
+	}
+
+	if JSON {
+		return printJSON(containers)
+	}
+
+	if len(containers) == 0 {
+		ui.Info("No project containers")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATE\tSTATUS\tIMAGE\tPATH")
+	for _, ctr := range containers {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			ctr.Name,
+			ctr.State,
+			ctr.Status,
+			ctr.Image,
+			valueOrDash(ctr.Path),
+		)
+	}
+	return w.Flush()
+}
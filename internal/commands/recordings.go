@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alienxp03/rize/internal/recording"
+	"github.com/alienxp03/rize/internal/ui"
+)
+
+// RecordingsList lists recorded sessions
+func RecordingsList() error {
+	recordings, err := recording.List()
+	if err != nil {
+		return err
+	}
+
+	if len(recordings) == 0 {
+		ui.Info("No recordings found")
+		ui.Info("Enable recording with 'recording.enabled: true' in the config file")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tPROJECT\tAGENT\tSTARTED\tDURATION\tEXIT\tSIZE")
+	for _, rec := range recordings {
+		agent, exitCode := "-", "-"
+		if meta := rec.Header.Rize; meta != nil {
+			agent = strings.TrimSpace(meta.Agent + " " + strings.Join(meta.Args, " "))
+			exitCode = fmt.Sprintf("%d", meta.ExitCode)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			rec.ID,
+			rec.Project,
+			truncate(agent, 40),
+			rec.StartedAt().Format("2006-01-02 15:04:05"),
+			formatDuration(time.Duration(rec.Header.Duration*float64(time.Second))),
+			exitCode,
+			formatBytes(rec.Size),
+		)
+	}
+
+	return w.Flush()
+}
+
+// Replay plays back a recorded session
+func Replay(id string, speed float64, maxIdle time.Duration) error {
+	// Loading the config sets up the user's redaction rules
+	if _, err := loadConfig(); err != nil {
+		return err
+	}
+
+	rec, err := recording.Find(id)
+	if err != nil {
+		return err
+	}
+
+	if meta := rec.Header.Rize; meta != nil {
+		ui.Info("Replaying %s in %s (%s, exit code %d)", meta.Agent, rec.Project, formatDuration(meta.Duration()), meta.ExitCode)
+	}
+
+	if err := recording.Replay(os.Stdout, rec.Path, recording.ReplayOptions{
+		Speed:   speed,
+		MaxIdle: maxIdle,
+	}); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	ui.Success("Replay finished")
+	return nil
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max-3] + "..."
+}
+
+func formatDuration(d time.Duration) string {
+	return d.Round(time.Second).String()
+}
+
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
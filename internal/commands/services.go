@@ -1,29 +1,50 @@
 package commands
 
 import (
+	"compress/gzip"
 	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/alienxp03/rize/internal/config"
 	"github.com/alienxp03/rize/internal/docker"
 	"github.com/alienxp03/rize/internal/ui"
+	"github.com/moby/term"
 )
 
-// ServicesUp starts all enabled services
-func ServicesUp() error {
-	cfg, err := config.Load()
+// ServicesUp starts the named services, or all enabled services
+func ServicesUp(names []string) error {
+	cfg, err := loadConfig()
 	if err != nil {
 		return err
 	}
 
-	enabledServices := cfg.GetEnabledServices()
+	warnProject(cfg)
+
+	enabledServices := names
+	if len(enabledServices) == 0 {
+		enabledServices = cfg.GetEnabledServices()
+	}
 	if len(enabledServices) == 0 {
 		ui.Info("No services enabled")
 		return nil
 	}
 
-	ui.Info("Starting services: %v", enabledServices)
+	client, orchestrator, err := newOrchestrator(cfg)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if project := orchestrator.Project(); project.Scoped {
+		ui.Info("Starting services for %s: %v", project.Name, enabledServices)
+	} else {
+		ui.Info("Starting services: %v", enabledServices)
+	}
 
-	if err := docker.ComposeUp(cfg); err != nil {
+	if err := orchestrator.Up(names...); err != nil {
 		return fmt.Errorf("failed to start services: %w", err)
 	}
 
@@ -33,18 +54,20 @@ func ServicesUp() error {
 
 // ServicesDown stops all services
 func ServicesDown() error {
-	cfg, err := config.Load()
+	cfg, err := loadConfig()
 	if err != nil {
 		return err
 	}
 
-	if err := docker.EnsureCompose(cfg); err != nil {
+	client, orchestrator, err := newOrchestrator(cfg)
+	if err != nil {
 		return err
 	}
+	defer client.Close()
 
 	ui.Info("Stopping services...")
 
-	if err := docker.ComposeDown(); err != nil {
+	if err := orchestrator.Down(); err != nil {
 		return fmt.Errorf("failed to stop services: %w", err)
 	}
 
@@ -52,65 +75,348 @@ func ServicesDown() error {
 	return nil
 }
 
-// ServicesPs lists running services
+// ServicesPs shows the status of every configured service. It fails with a
+// CheckError when an enabled service is not running or not healthy, so it
+// can be used as a health gate.
 func ServicesPs() error {
-	cfg, err := config.Load()
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	client, orchestrator, err := newOrchestrator(cfg)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	statuses, err := orchestrator.Status()
+	if err != nil {
+		return err
+	}
+
+	if JSON {
+		if statuses == nil {
+			statuses = []docker.ServiceStatus{}
+		}
+		if err := printJSON(statuses); err != nil {
+			return err
+		}
+	} else if err := printServiceStatuses(statuses); err != nil {
+		return err
+	}
+
+	var unhealthy []string
+	for _, status := range statuses {
+		if !status.Healthy() {
+			unhealthy = append(unhealthy, status.Service)
+		}
+	}
+	if len(unhealthy) > 0 {
+		return &CheckError{fmt.Sprintf("unhealthy services: %s", strings.Join(unhealthy, ", "))}
+	}
+
+	return nil
+}
+
+func printServiceStatuses(statuses []docker.ServiceStatus) error {
+	if len(statuses) == 0 {
+		ui.Info("No services configured")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tENABLED\tSTATE\tHEALTH\tUPTIME\tPORTS\tURL")
+	for _, status := range statuses {
+		enabled := "no"
+		if status.Enabled {
+			enabled = "yes"
+		}
+
+		uptime := "-"
+		if status.StartedAt != nil {
+			uptime = formatDuration(status.Uptime)
+		}
+
+		ports := make([]string, 0, len(status.Ports))
+		for _, port := range status.Ports {
+			ports = append(ports, port.String())
+		}
+
+		var urls []string
+		if status.EnvVar != "" {
+			urls = append(urls, fmt.Sprintf("%s=%s", status.EnvVar, status.URL))
+		}
+		if status.HostURL != "" {
+			urls = append(urls, status.HostURL)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			status.Service,
+			enabled,
+			status.State,
+			valueOrDash(status.Health),
+			uptime,
+			valueOrDash(strings.Join(ports, ", ")),
+			valueOrDash(strings.Join(urls, " ")),
+		)
+	}
+	return w.Flush()
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// ServicesLogs shows the logs of the named services, or of all services
+func ServicesLogs(names []string, opts docker.LogOptions) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	client, orchestrator, err := newOrchestrator(cfg)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	return orchestrator.Logs(os.Stdout, opts, names...)
+}
+
+// ServicesRestart restarts the named services, or all services
+func ServicesRestart(names []string) error {
+	return serviceAction(names, "restart", "Restarting", "Restarted", (*docker.Orchestrator).Restart)
+}
+
+// ServicesStart starts the stopped containers of the named services, or of
+// all services
+func ServicesStart(names []string) error {
+	return serviceAction(names, "start", "Starting", "Started", (*docker.Orchestrator).Start)
+}
+
+// ServicesStop stops the named services, or all services, keeping their
+// containers
+func ServicesStop(names []string) error {
+	return serviceAction(names, "stop", "Stopping", "Stopped", (*docker.Orchestrator).Stop)
+}
+
+// ServicesPull pulls the latest images of the named services, or of all
+// enabled services
+func ServicesPull(names []string) error {
+	if err := serviceAction(names, "pull", "Pulling", "Pulled", (*docker.Orchestrator).Pull); err != nil {
+		return err
+	}
+
+	ui.Info("Run `rize services up` to recreate services with new images")
+	return nil
+}
+
+// ServicesExec runs a command in the container of a service
+func ServicesExec(name string, cmd []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	client, orchestrator, err := newOrchestrator(cfg)
 	if err != nil {
 		return err
 	}
+	defer client.Close()
+
+	_, interactive := term.GetFdInfo(os.Stdin)
+	exitCode, err := orchestrator.Exec(name, cmd, interactive)
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("command exited with code %d", exitCode)
+	}
+
+	return nil
+}
+
+// ServicesSeed pipes a dump into the running container of a service. Without
+// a file the dump is read from stdin, or taken from the service's init SQL
+// files when stdin is a terminal. Gzipped dumps are decompressed.
+func ServicesSeed(name, file string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.ValidateServices([]string{name}); err != nil {
+		return err
+	}
 
-	if err := docker.EnsureCompose(cfg); err != nil {
+	var files []string
+	if file != "" {
+		files = []string{file}
+	} else if _, isTerminal := term.GetFdInfo(os.Stdin); isTerminal {
+		files, err = docker.SeedFiles(cfg.Services[name])
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("no dump given and %s has no init SQL files; usage: rize services seed %s <file>", name, name)
+		}
+	}
+
+	var readers []io.Reader
+	for _, path := range files {
+		reader, closeFn, err := openDump(path)
+		if err != nil {
+			return err
+		}
+		defer closeFn()
+		readers = append(readers, reader)
+	}
+
+	dump := io.Reader(os.Stdin)
+	source := "stdin"
+	if len(files) > 0 {
+		dump = io.MultiReader(readers...)
+		source = strings.Join(files, ", ")
+	}
+
+	client, orchestrator, err := newOrchestrator(cfg)
+	if err != nil {
 		return err
 	}
+	defer client.Close()
+
+	ui.Info("Seeding %s from %s...", name, source)
+
+	if err := orchestrator.Seed(name, dump, source); err != nil {
+		return fmt.Errorf("failed to seed %s: %w", name, err)
+	}
+
+	ui.Success("Seeded %s", name)
+	return nil
+}
+
+// openDump opens a dump file, decompressing it when it is gzipped
+func openDump(path string) (io.Reader, func(), error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open dump: %w", err)
+	}
+
+	if !strings.HasSuffix(path, ".gz") {
+		return f, func() { f.Close() }, nil
+	}
 
-	return docker.ComposePs()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return gz, func() { gz.Close(); f.Close() }, nil
 }
 
-// ServicesLogs shows service logs
-func ServicesLogs(follow bool) error {
-	cfg, err := config.Load()
+// serviceAction runs an orchestrator action on the named services and
+// reports progress
+func serviceAction(names []string, verb, doing, done string, action func(*docker.Orchestrator, ...string) error) error {
+	cfg, err := loadConfig()
 	if err != nil {
 		return err
 	}
 
-	if err := docker.EnsureCompose(cfg); err != nil {
+	client, orchestrator, err := newOrchestrator(cfg)
+	if err != nil {
 		return err
 	}
+	defer client.Close()
+
+	target := "services"
+	if len(names) > 0 {
+		target = strings.Join(names, ", ")
+	}
+
+	ui.Info("%s %s...", doing, target)
+
+	if err := action(orchestrator, names...); err != nil {
+		return fmt.Errorf("failed to %s %s: %w", verb, target, err)
+	}
 
-	return docker.ComposeLogs(follow)
+	ui.Success("%s %s", done, target)
+	return nil
 }
 
-// ServicesRestart restarts services
-func ServicesRestart() error {
-	cfg, err := config.Load()
+// ServicesExport writes a docker-compose.yml for the enabled services to
+// output, or to stdout when output is "-"
+func ServicesExport(output string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	project, err := docker.CurrentServiceProject(cfg)
+	if err != nil {
+		return err
+	}
+
+	data, err := docker.ExportCompose(cfg, project)
 	if err != nil {
 		return err
 	}
 
-	if err := docker.EnsureCompose(cfg); err != nil {
+	if output == "-" {
+		_, err := os.Stdout.Write(data)
 		return err
 	}
 
-	ui.Info("Restarting services...")
+	warnProject(cfg)
 
-	if err := docker.ComposeRestart(); err != nil {
-		return fmt.Errorf("failed to restart services: %w", err)
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		return fmt.Errorf("failed to write compose file: %w", err)
 	}
 
-	ui.Success("Services restarted")
+	ui.Success("Wrote %s", output)
 	return nil
 }
 
-// autoStartServices automatically starts enabled services if they're not running
+// NoServices disables starting the services before agents, the shell and
+// exec run
+var NoServices bool
+
+// autoStartServices starts enabled services that are not running
 func autoStartServices(cfg *config.Config) error {
+	if NoServices {
+		ui.Debug("Not starting services (--no-services)")
+		return nil
+	}
+
 	enabledServices := cfg.GetEnabledServices()
 	if len(enabledServices) == 0 {
 		return nil
 	}
 
-	// Try to start services, but don't fail if it doesn't work
-	// This will fail silently as per requirements
-	docker.ComposeUpQuiet(cfg)
+	client, orchestrator, err := newOrchestrator(cfg)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
 
-	return nil
+	return orchestrator.Quiet().Up()
+}
+
+// newOrchestrator returns a Docker client and the orchestrator for the
+// services of the current directory. The caller closes the client.
+func newOrchestrator(cfg *config.Config) (*docker.Client, *docker.Orchestrator, error) {
+	project, err := docker.CurrentServiceProject(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client, err := docker.NewClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return client, docker.NewOrchestrator(client, cfg, project), nil
 }
@@ -1,14 +1,13 @@
 package commands
 
 import (
-	"github.com/alienxp03/rize/internal/config"
 	"github.com/alienxp03/rize/internal/docker"
 	"github.com/alienxp03/rize/internal/ui"
 )
 
 // Shell starts an interactive shell
 func Shell() error {
-	cfg, err := config.Load()
+	cfg, err := loadConfig()
 	if err != nil {
 		return err
 	}
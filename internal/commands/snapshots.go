@@ -0,0 +1,142 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/alienxp03/rize/internal/docker"
+	"github.com/alienxp03/rize/internal/ui"
+)
+
+// ServicesSnapshot archives the volumes of a service to ~/.rize/snapshots
+func ServicesSnapshot(service, name string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	client, orchestrator, err := newOrchestrator(cfg)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ui.Info("Snapshotting %s...", service)
+
+	snapshot, err := orchestrator.Snapshot(service, name)
+	if err != nil {
+		return fmt.Errorf("failed to snapshot %s: %w", service, err)
+	}
+
+	ui.Success("Saved snapshot %s of %s (%s)", snapshot.Name, service, formatBytes(snapshot.Size))
+	return nil
+}
+
+// ServicesRestore replaces the volumes of a service with a snapshot
+func ServicesRestore(service, name string, yes bool) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	if !yes && !confirm(fmt.Sprintf("This will replace all data of %s with snapshot %s", service, name)) {
+		ui.Info("Restore cancelled")
+		return nil
+	}
+
+	client, orchestrator, err := newOrchestrator(cfg)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ui.Info("Restoring %s from %s...", service, name)
+
+	if err := orchestrator.Restore(service, name); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", service, err)
+	}
+
+	ui.Success("Restored %s from %s", service, name)
+	return nil
+}
+
+// ServicesReset drops the volumes of a service and starts it with fresh data
+func ServicesReset(service string, yes bool) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	if !yes && !confirm(fmt.Sprintf("This will delete all data of %s", service)) {
+		ui.Info("Reset cancelled")
+		return nil
+	}
+
+	client, orchestrator, err := newOrchestrator(cfg)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ui.Info("Resetting %s...", service)
+
+	if err := orchestrator.Reset(service); err != nil {
+		return fmt.Errorf("failed to reset %s: %w", service, err)
+	}
+
+	ui.Success("Reset %s", service)
+	return nil
+}
+
+// ServicesSnapshotsList lists the snapshots of the current service project
+func ServicesSnapshotsList(service string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	if service != "" {
+		if err := cfg.ValidateServices([]string{service}); err != nil {
+			return err
+		}
+	}
+
+	project, err := docker.CurrentServiceProject(cfg)
+	if err != nil {
+		return err
+	}
+
+	snapshots, err := docker.ListSnapshots(project, service)
+	if err != nil {
+		return err
+	}
+
+	if len(snapshots) == 0 {
+		ui.Info("No snapshots found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tSNAPSHOT\tCREATED\tSIZE")
+	for _, snapshot := range snapshots {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			snapshot.Service,
+			snapshot.Name,
+			snapshot.Created.Local().Format("2006-01-02 15:04:05"),
+			formatBytes(snapshot.Size),
+		)
+	}
+	return w.Flush()
+}
+
+// confirm asks the user to confirm a destructive action
+func confirm(message string) bool {
+	ui.Warning("%s", message)
+	ui.Info("Continue? [y/N]")
+
+	var answer string
+	fmt.Scanln(&answer)
+
+	return answer == "y" || answer == "Y"
+}
@@ -0,0 +1,165 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alienxp03/rize/internal/session"
+	"github.com/alienxp03/rize/internal/traffic"
+	"github.com/alienxp03/rize/internal/ui"
+)
+
+// TrafficList lists sessions with captured proxy traffic
+func TrafficList() error {
+	sessions, err := traffic.List()
+	if err != nil {
+		return err
+	}
+
+	if len(sessions) == 0 {
+		ui.Info("No traffic captured yet")
+		ui.Info("Traffic is captured for sessions started while the mitmproxy service is running")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SESSION\tPROJECT\tAGENT\tSTARTED\tFLOWS\tSIZE\tHOSTS")
+	for _, s := range sessions {
+		project, agent := "-", "-"
+		if meta, err := session.Load(s.ID); err == nil {
+			project, agent = meta.Project, meta.Agent
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			s.ID,
+			project,
+			agent,
+			s.First.Format("2006-01-02 15:04:05"),
+			s.Flows,
+			formatBytes(s.Size),
+			truncate(strings.Join(s.Hosts, ","), 50),
+		)
+	}
+
+	return w.Flush()
+}
+
+// TrafficShow prints the flows captured for a session
+func TrafficShow(id string, full bool) error {
+	// Loading the config sets up the user's redaction rules
+	if _, err := loadConfig(); err != nil {
+		return err
+	}
+
+	sessionID, flows, err := traffic.Read(id)
+	if err != nil {
+		return err
+	}
+
+	if meta, err := session.Load(sessionID); err == nil {
+		ui.Info("Session %s: %s in %s", sessionID, meta.Agent, meta.Project)
+	}
+
+	if full {
+		for _, flow := range flows {
+			printFlow(flow)
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tMETHOD\tSTATUS\tDURATION\tSIZE\tURL")
+	for _, flow := range flows {
+		status, size := "ERR", "-"
+		if flow.Response != nil {
+			status = fmt.Sprintf("%d", flow.Response.Status)
+			size = formatBytes(int64(flow.Response.Body.Size))
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			flow.StartedAt().Format("15:04:05"),
+			flow.Request.Method,
+			status,
+			(time.Duration(flow.DurationMs) * time.Millisecond).String(),
+			size,
+			truncate(flow.Request.URL, 100),
+		)
+	}
+
+	return w.Flush()
+}
+
+// TrafficExport writes the flows of a session as a HAR file
+func TrafficExport(id string, output string) error {
+	if _, err := loadConfig(); err != nil {
+		return err
+	}
+
+	sessionID, flows, err := traffic.Read(id)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(traffic.ToHAR(sessionID, flows), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode HAR: %w", err)
+	}
+
+	if output == "-" {
+		_, err := os.Stdout.Write(append(data, '\n'))
+		return err
+	}
+
+	if output == "" {
+		output = sessionID + ".har"
+	}
+
+	if err := os.WriteFile(output, data, 0600); err != nil {
+		return fmt.Errorf("failed to write HAR file: %w", err)
+	}
+
+	ui.Success("Exported %d flows to %s", len(flows), output)
+	return nil
+}
+
+func printFlow(flow traffic.Flow) {
+	fmt.Printf("%s %s %s\n", ui.Blue(flow.Request.Method), flow.Request.URL, flow.StartedAt().Format(time.RFC3339))
+	for _, h := range flow.Request.Headers {
+		fmt.Printf("  %s: %s\n", h[0], h[1])
+	}
+	if flow.Request.Body.Size > 0 {
+		fmt.Printf("\n%s\n", bodyPreview(flow.Request.Body))
+	}
+
+	if resp := flow.Response; resp != nil {
+		fmt.Printf("\n%s %d %s (%dms)\n", ui.Green("←"), resp.Status, resp.Reason, flow.DurationMs)
+		for _, h := range resp.Headers {
+			fmt.Printf("  %s: %s\n", h[0], h[1])
+		}
+		if resp.Body.Size > 0 {
+			fmt.Printf("\n%s\n", bodyPreview(resp.Body))
+		}
+	}
+
+	if flow.Error != "" {
+		fmt.Printf("\n%s %s\n", ui.Red("error:"), flow.Error)
+	}
+
+	fmt.Println(strings.Repeat("─", 80))
+}
+
+func bodyPreview(body traffic.Body) string {
+	if body.Encoding == "base64" {
+		return fmt.Sprintf("<binary, %s>", formatBytes(int64(body.Size)))
+	}
+
+	text := body.Text
+	if body.Truncated {
+		text += "\n<truncated>"
+	}
+	return text
+}
@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alienxp03/rize/internal/ui"
+	"github.com/alienxp03/rize/internal/usage"
+)
+
+// Usage prints LLM token usage and cost captured from proxied traffic
+func Usage(since string, by string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	sinceTime, err := ParseSince(since)
+	if err != nil {
+		return err
+	}
+
+	usages, err := usage.Load(sinceTime)
+	if err != nil {
+		return err
+	}
+
+	rows, total, err := usage.Summarize(usages, by, cfg.Pricing)
+	if err != nil {
+		return err
+	}
+
+	if JSON {
+		if rows == nil {
+			rows = []usage.Row{}
+		}
+		return printJSON(struct {
+			Since time.Time   `json:"since"`
+			By    string      `json:"by"`
+			Rows  []usage.Row `json:"rows"`
+			Total usage.Row   `json:"total"`
+		}{sinceTime, by, rows, total})
+	}
+
+	if len(rows) == 0 {
+		ui.Info("No LLM API usage recorded")
+		ui.Info("Usage is captured for sessions started while the mitmproxy service is running")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\tREQUESTS\tINPUT\tOUTPUT\tCACHE READ\tCACHE WRITE\tCOST\t\n", groupTitle(by))
+	for _, row := range append(rows, total) {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\t%s\t\n",
+			row.Key,
+			row.Requests,
+			formatCount(row.Input),
+			formatCount(row.Output),
+			formatCount(row.CacheRead),
+			formatCount(row.CacheWrite),
+			formatCost(row),
+		)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if total.Unpriced {
+		fmt.Println()
+		ui.Warning("* Some models are missing from the price table; add them under 'pricing' in the config file")
+	}
+
+	return nil
+}
+
+func groupTitle(by string) string {
+	if by == "" {
+		by = "model"
+	}
+	return strings.ToUpper(by)
+}
+
+func formatCost(row usage.Row) string {
+	cost := fmt.Sprintf("$%.4f", row.Cost)
+	if row.Unpriced {
+		cost += "*"
+	}
+	return cost
+}
+
+// formatCount formats n with thousands separators
+func formatCount(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	if len(s) <= 3 {
+		return s
+	}
+
+	var out []byte
+	for i, c := range []byte(s) {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			out = append(out, ',')
+		}
+		out = append(out, c)
+	}
+	return string(out)
+}
@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/alienxp03/rize/internal/docker"
+	"github.com/alienxp03/rize/internal/version"
+)
+
+// Version prints the binary build metadata and the rize image it runs
+func Version() error {
+	info := version.Get()
+
+	var image *docker.ImageInfo
+	imageErr := ""
+	client, err := docker.NewClient()
+	if err == nil {
+		image, err = client.ImageInfo(docker.ImageName)
+		client.Close()
+	}
+	if err != nil {
+		imageErr = err.Error()
+	}
+
+	if JSON {
+		return printJSON(struct {
+			Binary     version.Info      `json:"binary"`
+			ImageRef   string            `json:"image_ref"`
+			Image      *docker.ImageInfo `json:"image"`
+			ImageError string            `json:"image_error,omitempty"`
+		}{info, docker.ImageName, image, imageErr})
+	}
+
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Printf("  %-10s%s\n", name+":", value)
+		}
+	}
+
+	fmt.Printf("rize %s\n", info.Version)
+	commit := info.Commit
+	if commit != "" && info.Modified {
+		commit += " (modified)"
+	}
+	field("Commit", commit)
+	field("Built", info.Date)
+	field("Go", info.GoVersion)
+	field("Platform", info.Platform)
+
+	fmt.Printf("Image %s\n", docker.ImageName)
+	switch {
+	case imageErr != "":
+		field("Error", imageErr)
+	case image == nil:
+		field("Status", "not pulled (run rize update)")
+	default:
+		field("Version", image.Version)
+		field("ID", image.ID)
+		field("Digest", image.Digest)
+		field("Created", image.Created)
+	}
+	return nil
+}
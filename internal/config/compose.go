@@ -0,0 +1,580 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// composeServiceKeys are the compose service keys rize understands. Other
+// keys are reported as warnings.
+var composeServiceKeys = map[string]bool{
+	"image": true, "command": true, "environment": true, "env_file": true,
+	"ports": true, "volumes": true, "healthcheck": true, "depends_on": true,
+	"restart": true, "hostname": true, "container_name": true, "networks": true,
+	"profiles": true,
+}
+
+// composeTopLevelKeys are the top-level compose keys rize understands
+var composeTopLevelKeys = map[string]bool{
+	"version": true, "name": true, "services": true, "volumes": true, "networks": true,
+}
+
+type composeFile struct {
+	Services map[string]composeService `yaml:"services"`
+	Volumes  map[string]*struct {
+		External bool `yaml:"external"`
+	} `yaml:"volumes"`
+}
+
+type composeService struct {
+	Image         string              `yaml:"image"`
+	Command       composeCommand      `yaml:"command"`
+	Environment   composeEnvironment  `yaml:"environment"`
+	EnvFile       composeList         `yaml:"env_file"`
+	Ports         []composePort       `yaml:"ports"`
+	Volumes       []composeVolume     `yaml:"volumes"`
+	HealthCheck   *composeHealthCheck `yaml:"healthcheck"`
+	DependsOn     composeKeys         `yaml:"depends_on"`
+	Restart       string              `yaml:"restart"`
+	Hostname      string              `yaml:"hostname"`
+	ContainerName string              `yaml:"container_name"`
+	Networks      composeNetworks     `yaml:"networks"`
+	Profiles      []string            `yaml:"profiles"`
+}
+
+type composeHealthCheck struct {
+	Test     composeList `yaml:"test"`
+	Interval string      `yaml:"interval"`
+	Timeout  string      `yaml:"timeout"`
+	Retries  int         `yaml:"retries"`
+	Disable  bool        `yaml:"disable"`
+}
+
+// composeList is a string or a list of strings
+type composeList []string
+
+func (l *composeList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = composeList{node.Value}
+		return nil
+	}
+	return node.Decode((*[]string)(l))
+}
+
+// composeCommand is a command given as a list or as a shell-like string
+type composeCommand []string
+
+func (c *composeCommand) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		words, err := splitWords(node.Value)
+		if err != nil {
+			return err
+		}
+		*c = words
+		return nil
+	}
+	return node.Decode((*[]string)(c))
+}
+
+// composeEnvironment is a map or a list of KEY=VALUE entries. Keys without a
+// value are taken from the host environment.
+type composeEnvironment map[string]string
+
+func (e *composeEnvironment) UnmarshalYAML(node *yaml.Node) error {
+	env := composeEnvironment{}
+	if node.Kind == yaml.MappingNode {
+		var values map[string]*string
+		if err := node.Decode(&values); err != nil {
+			return err
+		}
+		for key, value := range values {
+			if value == nil {
+				env[key] = os.Getenv(key)
+				continue
+			}
+			env[key] = *value
+		}
+		*e = env
+		return nil
+	}
+
+	var entries []string
+	if err := node.Decode(&entries); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			value = os.Getenv(key)
+		}
+		env[key] = value
+	}
+	*e = env
+	return nil
+}
+
+// composeKeys is a list of names or a map keyed by name, as used by
+// depends_on
+type composeKeys []string
+
+func (k *composeKeys) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.MappingNode {
+		var values map[string]yaml.Node
+		if err := node.Decode(&values); err != nil {
+			return err
+		}
+		for key := range values {
+			*k = append(*k, key)
+		}
+		sort.Strings(*k)
+		return nil
+	}
+	return node.Decode((*[]string)(k))
+}
+
+// composeNetworks is a list of networks or a map of networks with aliases
+type composeNetworks struct {
+	Aliases []string
+}
+
+func (n *composeNetworks) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	var networks map[string]*struct {
+		Aliases []string `yaml:"aliases"`
+	}
+	if err := node.Decode(&networks); err != nil {
+		return err
+	}
+	for _, network := range networks {
+		if network != nil {
+			n.Aliases = append(n.Aliases, network.Aliases...)
+		}
+	}
+	sort.Strings(n.Aliases)
+	return nil
+}
+
+// composePort is a port in short ("8080:80") or long syntax
+type composePort string
+
+func (p *composePort) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*p = composePort(node.Value)
+		return nil
+	}
+
+	var long struct {
+		Target    int    `yaml:"target"`
+		Published string `yaml:"published"`
+		HostIP    string `yaml:"host_ip"`
+		Protocol  string `yaml:"protocol"`
+	}
+	if err := node.Decode(&long); err != nil {
+		return err
+	}
+
+	port := strconv.Itoa(long.Target)
+	if long.Published != "" {
+		port = long.Published + ":" + port
+		if long.HostIP != "" {
+			port = long.HostIP + ":" + port
+		}
+	}
+	if long.Protocol != "" && long.Protocol != "tcp" {
+		port += "/" + long.Protocol
+	}
+	*p = composePort(port)
+	return nil
+}
+
+// composeVolume is a volume in short ("data:/var/lib/data:ro") or long
+// syntax
+type composeVolume struct {
+	Source   string
+	Target   string
+	ReadOnly bool
+}
+
+func (v *composeVolume) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		parts := strings.Split(node.Value, ":")
+		switch len(parts) {
+		case 1:
+			v.Target = parts[0]
+		case 2, 3:
+			v.Source, v.Target = parts[0], parts[1]
+			// Options are comma separated, like "ro,z"
+			if len(parts) == 3 {
+				v.ReadOnly = slices.Contains(strings.Split(parts[2], ","), "ro")
+			}
+		default:
+			return fmt.Errorf("invalid volume %q", node.Value)
+		}
+		return nil
+	}
+
+	var long struct {
+		Source   string `yaml:"source"`
+		Target   string `yaml:"target"`
+		ReadOnly bool   `yaml:"read_only"`
+	}
+	if err := node.Decode(&long); err != nil {
+		return err
+	}
+	v.Source, v.Target, v.ReadOnly = long.Source, long.Target, long.ReadOnly
+	return nil
+}
+
+// ImportCompose reads the services of a docker-compose.yml as rize services.
+// Variables are interpolated from the environment and the .env file next to
+// the compose file, relative bind mounts are resolved against its directory
+// and services behind a profile are imported disabled. Keys rize does not
+// support are returned as warnings.
+func ImportCompose(path string) (map[string]Service, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read compose file: %w", err)
+	}
+
+	dir := filepath.Dir(path)
+	vars, err := readEnvFile(filepath.Join(dir, ".env"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+
+	interpolated, missing := interpolate(string(data), func(name string) (string, bool) {
+		if value, ok := os.LookupEnv(name); ok {
+			return value, true
+		}
+		value, ok := vars[name]
+		return value, ok
+	})
+	data = []byte(interpolated)
+
+	var warnings []string
+	for _, name := range missing {
+		warnings = append(warnings, fmt.Sprintf("variable %s is not set; defaulting to an empty string", name))
+	}
+
+	var raw struct {
+		TopLevel map[string]yaml.Node            `yaml:",inline"`
+		Services map[string]map[string]yaml.Node `yaml:"services"`
+	}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	warnings = append(warnings, unsupportedKeys("", raw.TopLevel, composeTopLevelKeys)...)
+
+	var file composeFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	for name, vol := range file.Volumes {
+		if vol != nil && vol.External {
+			warnings = append(warnings, fmt.Sprintf("volume %s: external volumes are not supported; a volume is created for the project", name))
+		}
+	}
+
+	services := make(map[string]Service, len(file.Services))
+	for name, svc := range file.Services {
+		warnings = append(warnings, unsupportedKeys(name, raw.Services[name], composeServiceKeys)...)
+
+		if svc.Image == "" {
+			warnings = append(warnings, fmt.Sprintf("service %s: skipped, it has no image (build is not supported)", name))
+			continue
+		}
+
+		converted, serviceWarnings, err := svc.convert(name, dir)
+		if err != nil {
+			return nil, nil, err
+		}
+		warnings = append(warnings, serviceWarnings...)
+		services[name] = converted
+	}
+
+	for name, svc := range services {
+		var deps []string
+		for _, dep := range svc.DependsOn {
+			if _, ok := services[dep]; ok {
+				deps = append(deps, dep)
+				continue
+			}
+			warnings = append(warnings, fmt.Sprintf("service %s: dropping dependency on skipped service %s", name, dep))
+		}
+		svc.DependsOn = deps
+		services[name] = svc
+	}
+
+	sort.Strings(warnings)
+	return services, warnings, nil
+}
+
+func (svc composeService) convert(name, dir string) (Service, []string, error) {
+	var warnings []string
+
+	env := map[string]string{}
+	for _, envFile := range svc.EnvFile {
+		if !filepath.IsAbs(envFile) {
+			envFile = filepath.Join(dir, envFile)
+		}
+		values, err := readEnvFile(envFile)
+		if err != nil {
+			return Service{}, nil, fmt.Errorf("service %s: failed to read env_file: %w", name, err)
+		}
+		for key, value := range values {
+			env[key] = value
+		}
+	}
+	for key, value := range svc.Environment {
+		env[key] = value
+	}
+	if len(env) == 0 {
+		env = nil
+	}
+
+	var ports []string
+	for _, port := range svc.Ports {
+		ports = append(ports, string(port))
+	}
+
+	var volumes []string
+	for _, vol := range svc.Volumes {
+		if vol.Source == "" {
+			warnings = append(warnings, fmt.Sprintf("service %s: anonymous volume %s is not supported", name, vol.Target))
+			continue
+		}
+		source := vol.Source
+		switch {
+		case strings.HasPrefix(source, "."):
+			source = filepath.Join(dir, source)
+		case source == "~" || strings.HasPrefix(source, "~/"):
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return Service{}, nil, fmt.Errorf("failed to get home directory: %w", err)
+			}
+			source = filepath.Join(home, strings.TrimPrefix(source, "~"))
+		}
+		entry := source + ":" + vol.Target
+		if vol.ReadOnly {
+			entry += ":ro"
+		}
+		volumes = append(volumes, entry)
+	}
+
+	var healthcheck *HealthCheck
+	if hc := svc.HealthCheck; hc != nil && !hc.Disable && len(hc.Test) > 0 && hc.Test[0] != "NONE" {
+		test := []string(hc.Test)
+		if len(test) == 1 {
+			test = []string{"CMD-SHELL", strings.Join(test, " ")}
+		}
+		healthcheck = &HealthCheck{Test: test, Interval: hc.Interval, Timeout: hc.Timeout, Retries: hc.Retries}
+	}
+
+	var aliases []string
+	for _, alias := range append([]string{svc.Hostname, svc.ContainerName}, svc.Networks.Aliases...) {
+		if alias != "" && alias != name {
+			aliases = append(aliases, alias)
+		}
+	}
+
+	return Service{
+		Enabled:     len(svc.Profiles) == 0,
+		Image:       svc.Image,
+		Command:     svc.Command,
+		Environment: env,
+		Ports:       ports,
+		Volumes:     volumes,
+		HealthCheck: healthcheck,
+		DependsOn:   svc.DependsOn,
+		Restart:     svc.Restart,
+		Aliases:     aliases,
+	}, warnings, nil
+}
+
+// unsupportedKeys returns a warning for every key not in supported.
+// Extension keys (x-*) are ignored.
+func unsupportedKeys(service string, keys map[string]yaml.Node, supported map[string]bool) []string {
+	var unsupported []string
+	for key := range keys {
+		if !supported[key] && !strings.HasPrefix(key, "x-") {
+			unsupported = append(unsupported, key)
+		}
+	}
+	if len(unsupported) == 0 {
+		return nil
+	}
+	sort.Strings(unsupported)
+
+	if service == "" {
+		return []string{fmt.Sprintf("ignoring unsupported compose keys: %s", strings.Join(unsupported, ", "))}
+	}
+	return []string{fmt.Sprintf("service %s: ignoring unsupported keys: %s", service, strings.Join(unsupported, ", "))}
+}
+
+// readEnvFile reads KEY=VALUE lines, skipping blank lines and comments
+func readEnvFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[strings.TrimSpace(key)] = value
+	}
+
+	return values, scanner.Err()
+}
+
+// interpolate replaces $VAR, ${VAR}, ${VAR:-default} and ${VAR-default} the
+// way compose does; $$ is a literal $. It also returns the variables that
+// were used without a default but are not set.
+func interpolate(s string, lookup func(string) (string, bool)) (string, []string) {
+	var b strings.Builder
+	var missing []string
+	seen := map[string]bool{}
+	expand := func(expr string) {
+		value, name, ok := expandVariable(expr, lookup)
+		if !ok && !seen[name] {
+			seen[name] = true
+			missing = append(missing, name)
+		}
+		b.WriteString(value)
+	}
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		switch next := s[i+1]; {
+		case next == '$':
+			b.WriteByte('$')
+			i++
+		case next == '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				b.WriteString(s[i:])
+				return b.String(), missing
+			}
+			expand(s[i+2 : i+end])
+			i += end
+		case next == '_' || isAlpha(next):
+			j := i + 1
+			for j < len(s) && isNameChar(s[j]) {
+				j++
+			}
+			expand(s[i+1 : j])
+			i = j - 1
+		default:
+			b.WriteByte('$')
+		}
+	}
+	return b.String(), missing
+}
+
+// expandVariable expands the body of a ${...} expression. It reports false
+// when the variable is not set and has no default.
+func expandVariable(expr string, lookup func(string) (string, bool)) (string, string, bool) {
+	end := 0
+	for end < len(expr) && isNameChar(expr[end]) {
+		end++
+	}
+	name, rest := expr[:end], expr[end:]
+	value, set := lookup(name)
+
+	switch {
+	case strings.HasPrefix(rest, ":-"):
+		if value == "" {
+			return rest[2:], name, true
+		}
+	case strings.HasPrefix(rest, "-"):
+		if !set {
+			return rest[1:], name, true
+		}
+	}
+	return value, name, set
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || isAlpha(c) || (c >= '0' && c <= '9')
+}
+
+func isAlpha(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// splitWords splits a command string into words, honouring single and double
+// quotes and backslash escapes
+func splitWords(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote byte
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' && i+1 < len(s) {
+				i++
+				word.WriteByte(s[i])
+			} else {
+				word.WriteByte(c)
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			inWord = true
+		case c == '\\' && i+1 < len(s):
+			i++
+			word.WriteByte(s[i])
+			inWord = true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in command %q", s)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
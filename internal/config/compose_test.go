@@ -0,0 +1,181 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestImportCompose(t *testing.T) {
+	t.Setenv("PG_VERSION", "")
+
+	dir, err := filepath.Abs("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	services, warnings, err := ImportCompose(filepath.Join(dir, "docker-compose.yml"))
+	if err != nil {
+		t.Fatalf("ImportCompose() failed: %v", err)
+	}
+
+	if _, ok := services["api"]; ok {
+		t.Error("Expected the build-only api service to be skipped")
+	}
+
+	db := services["db"]
+	if !db.Enabled || db.Image != "postgres:15" || db.Restart != "unless-stopped" {
+		t.Errorf("Unexpected db service: %+v", db)
+	}
+	wantEnv := map[string]string{"POSTGRES_PASSWORD": "from-dotenv", "POSTGRES_DB": "myapp", "POSTGRES_USER": "app"}
+	if !reflect.DeepEqual(db.Environment, wantEnv) {
+		t.Errorf("Expected db environment %v, got %v", wantEnv, db.Environment)
+	}
+	if want := []string{"127.0.0.1:15432:5432"}; !reflect.DeepEqual(db.Ports, want) {
+		t.Errorf("Expected db ports %v, got %v", want, db.Ports)
+	}
+	wantVolumes := []string{"pgdata:/var/lib/postgresql/data", filepath.Join(dir, "db/init") + ":/docker-entrypoint-initdb.d:ro"}
+	if !reflect.DeepEqual(db.Volumes, wantVolumes) {
+		t.Errorf("Expected db volumes %v, got %v", wantVolumes, db.Volumes)
+	}
+	if db.HealthCheck == nil || !reflect.DeepEqual(db.HealthCheck.Test, []string{"CMD-SHELL", "pg_isready -U postgres"}) || db.HealthCheck.Retries != 5 {
+		t.Errorf("Unexpected db healthcheck: %+v", db.HealthCheck)
+	}
+	if want := []string{"myapp-db"}; !reflect.DeepEqual(db.Aliases, want) {
+		t.Errorf("Expected db aliases %v, got %v", want, db.Aliases)
+	}
+
+	search := services["search"]
+	if want := []string{"db"}; !reflect.DeepEqual(search.DependsOn, want) {
+		t.Errorf("Expected search to depend on %v, got %v", want, search.DependsOn)
+	}
+	if search.Environment["OPENSEARCH_JAVA_OPTS"] != "-Xms512m -Xmx512m" || search.Environment["PRICE"] != "$5" {
+		t.Errorf("Unexpected search environment: %v", search.Environment)
+	}
+	if want := []string{"es"}; !reflect.DeepEqual(search.Aliases, want) {
+		t.Errorf("Expected search aliases %v, got %v", want, search.Aliases)
+	}
+
+	mailhog := services["mailhog"]
+	if mailhog.Enabled {
+		t.Error("Expected a service behind a profile to be disabled")
+	}
+	if want := []string{"MailHog", "-smtp-bind-addr", "0.0.0.0:1025"}; !reflect.DeepEqual(mailhog.Command, want) {
+		t.Errorf("Expected mailhog command %v, got %v", want, mailhog.Command)
+	}
+
+	all := strings.Join(warnings, "\n")
+	for _, want := range []string{
+		"ignoring unsupported compose keys: secrets",
+		"service db: ignoring unsupported keys: deploy",
+		"service api: skipped",
+		"service search: dropping dependency on skipped service api",
+		"volume shared: external volumes are not supported",
+	} {
+		if !strings.Contains(all, want) {
+			t.Errorf("Expected warning %q, got:\n%s", want, all)
+		}
+	}
+	if strings.Contains(all, "x-common") || strings.Contains(all, "PG_VERSION") {
+		t.Errorf("Unexpected warnings:\n%s", all)
+	}
+}
+
+func TestComposeVolumes(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	var svc composeService
+	doc := "volumes:\n  - ~/.aws:/root/.aws:ro,z\n  - ./conf:/conf:z\n  - data:/data:rw\n  - cache:/cache:ro\n"
+	if err := yaml.Unmarshal([]byte(doc), &svc); err != nil {
+		t.Fatal(err)
+	}
+
+	converted, _, err := svc.convert("app", "/project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(home, ".aws") + ":/root/.aws:ro", "/project/conf:/conf", "data:/data", "cache:/cache:ro"}
+	if !reflect.DeepEqual(converted.Volumes, want) {
+		t.Errorf("Expected volumes %v, got %v", want, converted.Volumes)
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	vars := map[string]string{"SET": "value", "EMPTY": ""}
+	lookup := func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}
+
+	tests := map[string]string{
+		"$SET and ${SET}":          "value and value",
+		"${EMPTY:-fallback}":       "fallback",
+		"${EMPTY-fallback}":        "",
+		"${UNSET-fallback}":        "fallback",
+		"${UNSET:-a-b}":            "a-b",
+		"cost $$5":                 "cost $5",
+		"${UNSET:?must be set}":    "",
+		"trailing $":               "trailing $",
+		"${SET}_suffix $SET_other": "value_suffix ",
+	}
+	for input, want := range tests {
+		if got, _ := interpolate(input, lookup); got != want {
+			t.Errorf("interpolate(%q) = %q, want %q", input, got, want)
+		}
+	}
+
+	_, missing := interpolate("$UNSET ${UNSET} ${DEFAULTED:-x} $SET_other", lookup)
+	if want := []string{"UNSET", "SET_other"}; !reflect.DeepEqual(missing, want) {
+		t.Errorf("Expected missing %v, got %v", want, missing)
+	}
+}
+
+func TestSplitWords(t *testing.T) {
+	tests := map[string][]string{
+		`redis-server --appendonly yes`: {"redis-server", "--appendonly", "yes"},
+		`sh -c "echo 'hi there'"`:       {"sh", "-c", "echo 'hi there'"},
+		`echo a\ b 'c d'`:               {"echo", "a b", "c d"},
+		`echo ""`:                       {"echo", ""},
+	}
+	for input, want := range tests {
+		got, err := splitWords(input)
+		if err != nil {
+			t.Errorf("splitWords(%q) failed: %v", input, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("splitWords(%q) = %q, want %q", input, got, want)
+		}
+	}
+
+	if _, err := splitWords(`echo "unterminated`); err == nil {
+		t.Error("Expected an error for an unterminated quote")
+	}
+}
+
+func TestApplyProjectCompose(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ApplyProject(&ProjectConfig{
+		ComposeServices: map[string]Service{
+			"postgres": {Enabled: true, Image: "postgres:15"},
+			"search":   {Enabled: true, Image: "opensearch"},
+		},
+		Services: map[string]Service{
+			"search": {Image: "opensearch:2"},
+		},
+	})
+
+	if got := cfg.Services["postgres"].Image; got != "postgres:15" {
+		t.Errorf("Expected the compose postgres to replace the default, got %q", got)
+	}
+	if got := cfg.Services["search"]; !got.Enabled || got.Image != "opensearch:2" {
+		t.Errorf("Expected .rize.yml to override the compose service, got %+v", got)
+	}
+	if _, ok := cfg.Services["redis"]; !ok {
+		t.Error("Expected configured services to be kept")
+	}
+}
@@ -4,12 +4,26 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
+// pathOverride replaces the default config file when set
+var pathOverride string
+
+// SetPath makes rize read and write the config file at path
+func SetPath(path string) {
+	pathOverride = path
+}
+
 // ConfigPath returns the path to the config file
 func ConfigPath() (string, error) {
+	if pathOverride != "" {
+		return pathOverride, nil
+	}
+
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", fmt.Errorf("failed to get home directory: %w", err)
@@ -21,6 +35,27 @@ func ConfigPath() (string, error) {
 	return configFile, nil
 }
 
+// DataDir returns the path to the rize data directory (~/.rize)
+func DataDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	return filepath.Join(home, ".rize"), nil
+}
+
+// ContainerDataDir returns the part of the data directory mounted read-write
+// into project containers (~/.rize/container)
+func ContainerDataDir() (string, error) {
+	dataDir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dataDir, "container"), nil
+}
+
 // Load loads the configuration from the config file
 // If the file doesn't exist, it creates it with default configuration
 func Load() (*Config, error) {
@@ -44,11 +79,18 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	var cfg Config
+	// Limits missing from the file keep their defaults; an explicit 0
+	// disables the limit
+	defaults := DefaultConfig()
+	cfg := Config{Recording: defaults.Recording, Usage: defaults.Usage}
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
+	for _, svc := range cfg.Services {
+		resolveVolumes(svc.Volumes, filepath.Dir(configFile))
+	}
+
 	// Merge with defaults for missing fields
 	merged := mergeWithDefaults(&cfg)
 	if normalizeLegacyDefaults(merged) {
@@ -101,6 +143,10 @@ func mergeWithDefaults(cfg *Config) *Config {
 		}
 	}
 
+	if cfg.ServiceScope == "" {
+		cfg.ServiceScope = defaults.ServiceScope
+	}
+
 	// Merge environment
 	if cfg.Environment == nil {
 		cfg.Environment = make(map[string]string)
@@ -116,6 +162,28 @@ func mergeWithDefaults(cfg *Config) *Config {
 		cfg.Volumes = defaults.Volumes
 	}
 
+	// Merge pricing, keeping user overrides
+	if cfg.Pricing == nil {
+		cfg.Pricing = defaults.Pricing
+	} else {
+		for model, price := range defaults.Pricing {
+			if _, exists := cfg.Pricing[model]; !exists {
+				cfg.Pricing[model] = price
+			}
+		}
+	}
+
+	// Merge caches, keeping user overrides
+	if cfg.Caches == nil {
+		cfg.Caches = defaults.Caches
+	} else {
+		for name, path := range defaults.Caches {
+			if _, exists := cfg.Caches[name]; !exists {
+				cfg.Caches[name] = path
+			}
+		}
+	}
+
 	return cfg
 }
 
@@ -164,6 +232,13 @@ func normalizeLegacyDefaults(cfg *Config) bool {
 		"mitmproxy": {
 			{"mitmweb", "--web-host", "0.0.0.0", "--set", "block_global=false"},
 			{"mitmweb", "--web-host", "0.0.0.0", "--set", "block_global=false", "--set", "web_username=", "--set", "web_password="},
+			{"/bin/sh", "-c", legacyNoAuthScript + "exec mitmweb --web-host 0.0.0.0 --set block_global=false --set web_password= --set web_open_browser=false -s /tmp/rize-noauth.py"},
+		},
+	}
+	legacyVolumes := map[string][][]string{
+		"mitmproxy": {
+			{"rize-mitmproxy:/home/mitmproxy/.mitmproxy"},
+			{"rize-mitmproxy:/home/mitmproxy/.mitmproxy", "~/.rize/mitmproxy:/opt/rize:ro", "~/.rize/traffic:/rize/traffic"},
 		},
 	}
 
@@ -181,6 +256,22 @@ func normalizeLegacyDefaults(cfg *Config) bool {
 		}
 	}
 
+	for name, legacyList := range legacyVolumes {
+		svc, exists := cfg.Services[name]
+		if !exists {
+			continue
+		}
+
+		for _, legacy := range legacyList {
+			if portsEqual(svc.Volumes, legacy) {
+				svc.Volumes = defaults.Services[name].Volumes
+				cfg.Services[name] = svc
+				changed = true
+				break
+			}
+		}
+	}
+
 	for name, legacyList := range legacyCommands {
 		svc, exists := cfg.Services[name]
 		if !exists {
@@ -200,6 +291,21 @@ func normalizeLegacyDefaults(cfg *Config) bool {
 	return changed
 }
 
+// legacyNoAuthScript is the mitmweb auth bypass prelude shared by the legacy
+// mitmproxy commands
+const legacyNoAuthScript = `cat > /tmp/rize-noauth.py <<'PY'
+from mitmproxy import ctx
+
+class DisableWebAuth:
+    def running(self):
+        app = getattr(ctx.master, "app", None)
+        if app:
+            app.settings["is_valid_password"] = lambda _password: True
+
+addons = [DisableWebAuth()]
+PY
+`
+
 func portsEqual(a []string, b []string) bool {
 	if len(a) != len(b) {
 		return false
@@ -228,6 +334,30 @@ func commandsEqual(a []string, b []string) bool {
 	return true
 }
 
+// ServiceNames returns the names of all configured services, sorted
+func (c *Config) ServiceNames() []string {
+	names := make([]string, 0, len(c.Services))
+	for name := range c.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateServices checks that every name is a configured, enabled service
+func (c *Config) ValidateServices(names []string) error {
+	for _, name := range names {
+		svc, ok := c.Services[name]
+		if !ok {
+			return fmt.Errorf("unknown service %q (available: %s)", name, strings.Join(c.ServiceNames(), ", "))
+		}
+		if !svc.Enabled {
+			return fmt.Errorf("service %s is disabled in the config", name)
+		}
+	}
+	return nil
+}
+
 // GetEnabledServices returns a list of enabled service names
 func (c *Config) GetEnabledServices() []string {
 	var enabled []string
@@ -32,6 +32,23 @@ func TestDefaultConfig(t *testing.T) {
 	}
 }
 
+func TestMergeCaches(t *testing.T) {
+	cfg := mergeWithDefaults(&Config{Caches: map[string]string{
+		"npm":    "",
+		"gradle": "~/.gradle/caches",
+	}})
+
+	if path, ok := cfg.Caches["npm"]; !ok || path != "" {
+		t.Errorf("Expected the disabled npm cache to stay disabled, got %q", path)
+	}
+	if cfg.Caches["gradle"] != "~/.gradle/caches" {
+		t.Errorf("Expected the gradle cache to be kept, got %q", cfg.Caches["gradle"])
+	}
+	if cfg.Caches["pip"] != "~/.cache/pip" {
+		t.Errorf("Expected the default pip cache to be added, got %q", cfg.Caches["pip"])
+	}
+}
+
 func TestGetEnabledServices(t *testing.T) {
 	cfg := DefaultConfig()
 
@@ -54,6 +71,26 @@ func TestGetEnabledServices(t *testing.T) {
 	}
 }
 
+func TestValidateServices(t *testing.T) {
+	cfg := DefaultConfig()
+
+	if err := cfg.ValidateServices([]string{"postgres", "redis"}); err != nil {
+		t.Errorf("Expected configured services to be valid, got %v", err)
+	}
+
+	if err := cfg.ValidateServices([]string{"mysql"}); err == nil {
+		t.Error("Expected an error for an unknown service")
+	}
+
+	svc := cfg.Services["redis"]
+	svc.Enabled = false
+	cfg.Services["redis"] = svc
+
+	if err := cfg.ValidateServices([]string{"redis"}); err == nil {
+		t.Error("Expected an error for a disabled service")
+	}
+}
+
 func TestSaveAndLoad(t *testing.T) {
 	// Create a temporary directory for testing
 	tmpDir, err := os.MkdirTemp("", "rize-test-*")
@@ -79,3 +116,89 @@ func TestSaveAndLoad(t *testing.T) {
 		t.Error("Services should not be nil")
 	}
 }
+
+func TestNormalizeLegacyMitmproxy(t *testing.T) {
+	cfg := DefaultConfig()
+
+	svc := cfg.Services["mitmproxy"]
+	svc.Command = []string{"/bin/sh", "-c", legacyNoAuthScript + "exec mitmweb --web-host 0.0.0.0 --set block_global=false --set web_password= --set web_open_browser=false -s /tmp/rize-noauth.py"}
+	svc.Volumes = []string{"rize-mitmproxy:/home/mitmproxy/.mitmproxy"}
+	cfg.Services["mitmproxy"] = svc
+
+	if !normalizeLegacyDefaults(cfg) {
+		t.Fatal("Expected legacy mitmproxy config to be normalized")
+	}
+
+	defaults := DefaultConfig().Services["mitmproxy"]
+	if !commandsEqual(cfg.Services["mitmproxy"].Command, defaults.Command) {
+		t.Error("Expected mitmproxy command to be updated to the default")
+	}
+	if !portsEqual(cfg.Services["mitmproxy"].Volumes, defaults.Volumes) {
+		t.Error("Expected mitmproxy volumes to be updated to the default")
+	}
+}
+
+func TestLoadRecordingLimits(t *testing.T) {
+	tests := []struct {
+		config        string
+		retentionDays int
+		maxRecordings int
+	}{
+		{"recording:\n  enabled: true\n", 30, 200},
+		{"recording:\n  enabled: true\n  retention_days: 0\n  max_recordings: 0\n", 0, 0},
+		{"recording:\n  retention_days: 7\n", 7, 200},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "config.yml")
+		if err := os.WriteFile(path, []byte(tt.config), 0644); err != nil {
+			t.Fatal(err)
+		}
+		SetPath(path)
+		cfg, err := Load()
+		SetPath("")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if cfg.Usage.RetentionDays != 365 {
+			t.Errorf("Load(%q) usage retention = %d days, want the default", tt.config, cfg.Usage.RetentionDays)
+		}
+		if cfg.Recording.RetentionDays != tt.retentionDays || cfg.Recording.MaxRecordings != tt.maxRecordings {
+			t.Errorf("Load(%q) limits = %d days, %d recordings, want %d, %d",
+				tt.config, cfg.Recording.RetentionDays, cfg.Recording.MaxRecordings, tt.retentionDays, tt.maxRecordings)
+		}
+	}
+}
+
+func TestLoadResolvesRelativeVolumes(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yml")
+	data := "services:\n  postgres:\n    image: postgres:16\n    volumes:\n      - ./data:/var/lib/postgresql/data\n      - ../shared:/shared:ro\n      - pgdata:/backups\n      - ~/dumps:/dumps\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	SetPath(path)
+	defer SetPath("")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		filepath.Join(dir, "data") + ":/var/lib/postgresql/data",
+		filepath.Join(filepath.Dir(dir), "shared") + ":/shared:ro",
+		"pgdata:/backups",
+		"~/dumps:/dumps",
+	}
+	got := cfg.Services["postgres"].Volumes
+	if len(got) != len(want) {
+		t.Fatalf("Expected volumes %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected volumes %v, got %v", want, got)
+		}
+	}
+}
@@ -43,7 +43,12 @@ func DefaultConfig() *Config {
 				Enabled: true,
 				Image:   "mitmproxy/mitmproxy:latest",
 				Ports:   []string{"8080:8080", "8081:8081"},
-				Volumes: []string{"rize-mitmproxy:/home/mitmproxy/.mitmproxy"},
+				Volumes: []string{
+					"rize-mitmproxy:/home/mitmproxy/.mitmproxy",
+					"~/.rize/mitmproxy:/opt/rize:ro",
+					"~/.rize/traffic:/rize/traffic",
+					"~/.rize/usage:/rize/usage",
+				},
 				Command: []string{
 					"/bin/sh",
 					"-c",
@@ -58,10 +63,11 @@ class DisableWebAuth:
 
 addons = [DisableWebAuth()]
 PY
-exec mitmweb --web-host 0.0.0.0 --set block_global=false --set web_password= --set web_open_browser=false -s /tmp/rize-noauth.py`,
+exec mitmweb --web-host 0.0.0.0 --set block_global=false --set web_password= --set web_open_browser=false -s /tmp/rize-noauth.py -s /opt/rize/rize_addons.py`,
 				},
 			},
 		},
+		ServiceScope: ServiceScopeShared,
 		Environment: map[string]string{
 			"ANTHROPIC_API_KEY": "",
 			"OPENAI_API_KEY":    "",
@@ -76,5 +82,64 @@ exec mitmweb --web-host 0.0.0.0 --set block_global=false --set web_password= --s
 			"rize-redis",
 			"rize-mitmproxy",
 		},
+		Recording: RecordingConfig{
+			Enabled:       false,
+			RetentionDays: 30,
+			MaxRecordings: 200,
+		},
+		Usage: UsageConfig{
+			RetentionDays: 365,
+		},
+		Pricing: DefaultPricing(),
+		Caches:  DefaultCaches(),
+	}
+}
+
+// DefaultCaches returns the package-manager caches shared by all project
+// containers
+func DefaultCaches() map[string]string {
+	return map[string]string{
+		"npm":      "~/.npm",
+		"pip":      "~/.cache/pip",
+		"go-build": "~/.cache/go-build",
+		"go-mod":   "~/go/pkg/mod",
+		"cargo":    "~/.cargo/registry",
+		"bundle":   "~/.bundle",
+		"mise":     "~/.cache/mise",
+	}
+}
+
+// DefaultPricing returns the built-in LLM price table (USD per million
+// tokens). Models are matched by longest prefix ending at a delimiter, so
+// "claude-sonnet-4" also prices "claude-sonnet-4-5-20250929"; cheaper
+// variants like "o3-mini" need their own entry.
+func DefaultPricing() map[string]ModelPrice {
+	return map[string]ModelPrice{
+		// Anthropic
+		"claude-opus-4":     {Input: 15, Output: 75, CacheRead: 1.5, CacheWrite: 18.75},
+		"claude-opus-4-5":   {Input: 5, Output: 25, CacheRead: 0.5, CacheWrite: 6.25},
+		"claude-sonnet-4":   {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+		"claude-3-7-sonnet": {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+		"claude-haiku-4-5":  {Input: 1, Output: 5, CacheRead: 0.1, CacheWrite: 1.25},
+		"claude-3-5-haiku":  {Input: 0.8, Output: 4, CacheRead: 0.08, CacheWrite: 1},
+
+		// OpenAI
+		"gpt-5":        {Input: 1.25, Output: 10, CacheRead: 0.125},
+		"gpt-5-mini":   {Input: 0.25, Output: 2, CacheRead: 0.025},
+		"gpt-5-nano":   {Input: 0.05, Output: 0.4, CacheRead: 0.005},
+		"gpt-4.1":      {Input: 2, Output: 8, CacheRead: 0.5},
+		"gpt-4.1-mini": {Input: 0.4, Output: 1.6, CacheRead: 0.1},
+		"gpt-4.1-nano": {Input: 0.1, Output: 0.4, CacheRead: 0.025},
+		"gpt-4o":       {Input: 2.5, Output: 10, CacheRead: 1.25},
+		"gpt-4o-mini":  {Input: 0.15, Output: 0.6, CacheRead: 0.075},
+		"o3":           {Input: 2, Output: 8, CacheRead: 0.5},
+		"o3-mini":      {Input: 1.1, Output: 4.4, CacheRead: 0.55},
+		"o3-pro":       {Input: 20, Output: 80},
+		"o4-mini":      {Input: 1.1, Output: 4.4, CacheRead: 0.275},
+
+		// Google
+		"gemini-2.5-pro":        {Input: 1.25, Output: 10, CacheRead: 0.31},
+		"gemini-2.5-flash":      {Input: 0.3, Output: 2.5, CacheRead: 0.075},
+		"gemini-2.5-flash-lite": {Input: 0.1, Output: 0.4, CacheRead: 0.025},
 	}
 }
@@ -0,0 +1,522 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DevContainerPaths are the locations a devcontainer.json is read from,
+// relative to the project directory
+var DevContainerPaths = []string{
+	filepath.Join(".devcontainer", "devcontainer.json"),
+	".devcontainer.json",
+}
+
+// miseFeatures maps devcontainer features to the mise tools that replace them
+var miseFeatures = map[string]string{
+	"node":   "node",
+	"python": "python",
+	"go":     "go",
+	"rust":   "rust",
+	"java":   "java",
+	"ruby":   "ruby",
+	"deno":   "deno",
+	"bun":    "bun",
+	"dotnet": "dotnet",
+}
+
+// DevContainer is the subset of a devcontainer.json rize applies to the
+// project container
+type DevContainer struct {
+	// Path is the path of the devcontainer.json
+	Path string
+	// Image is the image to run instead of the rize image
+	Image string
+	// Build builds the image from a Dockerfile instead
+	Build *DevContainerBuild
+	// ContainerEnv is set on the container
+	ContainerEnv map[string]string
+	// RemoteEnv is set on commands run in the container. Values may refer to
+	// the container environment as ${containerEnv:NAME}.
+	RemoteEnv map[string]string
+	// Mounts are added below rize's own mounts
+	Mounts []DevContainerMount
+	// ForwardPorts are container ports published on localhost
+	ForwardPorts []int
+	// PostCreateCommand runs once after the container is created
+	PostCreateCommand [][]string
+	// PostStartCommand runs every time the container starts
+	PostStartCommand [][]string
+	// Tools are mise tools installed for supported features ("node@20")
+	Tools []string
+
+	// Applied and Ignored list the devcontainer properties rize did and did
+	// not apply
+	Applied []string
+	Ignored []string
+}
+
+// DevContainerBuild is the build section of a devcontainer.json. Paths are
+// absolute.
+type DevContainerBuild struct {
+	Dockerfile string
+	Context    string
+	Args       map[string]string
+	Target     string
+}
+
+// DevContainerMount is a bind or volume mount
+type DevContainerMount struct {
+	Type     string
+	Source   string
+	Target   string
+	ReadOnly bool
+}
+
+// LoadDevContainer loads the devcontainer.json of the project at projectDir.
+// It returns nil when the project has none. workspaceDir is where the project
+// is mounted in the container, used for ${containerWorkspaceFolder}.
+func LoadDevContainer(projectDir, workspaceDir string) (*DevContainer, error) {
+	for _, rel := range DevContainerPaths {
+		path := filepath.Join(projectDir, rel)
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", rel, err)
+		}
+
+		dc, err := parseDevContainer(data, devContainerVars(projectDir, workspaceDir))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		dc.Path = path
+		dc.resolvePaths(filepath.Dir(path))
+		return dc, nil
+	}
+
+	return nil, nil
+}
+
+// devContainerVars returns the values of the ${...} variables devcontainer.json
+// files may use
+func devContainerVars(projectDir, workspaceDir string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		switch name {
+		case "localWorkspaceFolder":
+			return projectDir, true
+		case "localWorkspaceFolderBasename":
+			return filepath.Base(projectDir), true
+		case "containerWorkspaceFolder":
+			return workspaceDir, true
+		case "containerWorkspaceFolderBasename":
+			return filepath.Base(workspaceDir), true
+		}
+		if env, ok := strings.CutPrefix(name, "localEnv:"); ok {
+			env, fallback, _ := strings.Cut(env, ":")
+			if value, ok := os.LookupEnv(env); ok {
+				return value, true
+			}
+			return fallback, true
+		}
+		return "", false
+	}
+}
+
+// substitute replaces the ${...} variables vars knows; others are kept
+func substitute(s string, vars func(string) (string, bool)) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+
+		b.WriteString(s[:start])
+		if value, ok := vars(s[start+2 : start+end]); ok {
+			b.WriteString(value)
+		} else {
+			b.WriteString(s[start : start+end+1])
+		}
+		s = s[start+end+1:]
+	}
+}
+
+func parseDevContainer(data []byte, vars func(string) (string, bool)) (*DevContainer, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(stripJSONC(data), &raw); err != nil {
+		return nil, err
+	}
+
+	dc := &DevContainer{}
+	decodeString := func(key string, target *string) error {
+		if err := json.Unmarshal(raw[key], target); err != nil {
+			return err
+		}
+		*target = substitute(*target, vars)
+		return nil
+	}
+	decodeEnv := func(key string) (map[string]string, error) {
+		var env map[string]string
+		if err := json.Unmarshal(raw[key], &env); err != nil {
+			return nil, err
+		}
+		for name, value := range env {
+			env[name] = substitute(value, vars)
+		}
+		return env, nil
+	}
+
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		var err error
+		switch key {
+		case "$schema":
+			continue
+		case "image":
+			err = decodeString(key, &dc.Image)
+		case "build":
+			var build struct {
+				Dockerfile string            `json:"dockerfile"`
+				Context    string            `json:"context"`
+				Args       map[string]string `json:"args"`
+				Target     string            `json:"target"`
+			}
+			if err = json.Unmarshal(raw[key], &build); err == nil {
+				if dc.Build == nil {
+					dc.Build = &DevContainerBuild{}
+				}
+				if build.Dockerfile != "" {
+					dc.Build.Dockerfile = build.Dockerfile
+				}
+				if build.Context != "" {
+					dc.Build.Context = build.Context
+				}
+				for name, value := range build.Args {
+					build.Args[name] = substitute(value, vars)
+				}
+				dc.Build.Args = build.Args
+				dc.Build.Target = build.Target
+			}
+		case "dockerFile", "context":
+			var value string
+			if err = json.Unmarshal(raw[key], &value); err == nil {
+				if dc.Build == nil {
+					dc.Build = &DevContainerBuild{}
+				}
+				if key == "dockerFile" {
+					dc.Build.Dockerfile = value
+				} else {
+					dc.Build.Context = value
+				}
+			}
+		case "containerEnv":
+			dc.ContainerEnv, err = decodeEnv(key)
+		case "remoteEnv":
+			dc.RemoteEnv, err = decodeEnv(key)
+		case "mounts":
+			err = dc.parseMounts(raw[key], vars)
+		case "forwardPorts":
+			err = dc.parseForwardPorts(raw[key])
+		case "postCreateCommand":
+			dc.PostCreateCommand, err = parseLifecycleCommand(raw[key])
+		case "postStartCommand":
+			dc.PostStartCommand, err = parseLifecycleCommand(raw[key])
+		case "features":
+			if err := dc.parseFeatures(raw[key]); err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			continue
+		default:
+			dc.Ignored = append(dc.Ignored, key)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		dc.Applied = append(dc.Applied, key)
+	}
+
+	if dc.Image != "" && dc.Build != nil {
+		dc.Build = nil
+		var applied []string
+		for _, key := range dc.Applied {
+			if key == "build" || key == "dockerFile" || key == "context" {
+				dc.Ignored = append(dc.Ignored, key+" (image is set)")
+				continue
+			}
+			applied = append(applied, key)
+		}
+		dc.Applied = applied
+	}
+	if dc.Build != nil && dc.Build.Dockerfile == "" {
+		return nil, fmt.Errorf("build: dockerfile is required")
+	}
+
+	sort.Strings(dc.Applied)
+	sort.Strings(dc.Ignored)
+	return dc, nil
+}
+
+// resolvePaths makes build paths absolute. They are relative to the
+// directory of the devcontainer.json.
+func (dc *DevContainer) resolvePaths(dir string) {
+	if dc.Build == nil {
+		return
+	}
+	if !filepath.IsAbs(dc.Build.Dockerfile) {
+		dc.Build.Dockerfile = filepath.Join(dir, dc.Build.Dockerfile)
+	}
+	if dc.Build.Context == "" {
+		dc.Build.Context = "."
+	}
+	if !filepath.IsAbs(dc.Build.Context) {
+		dc.Build.Context = filepath.Join(dir, dc.Build.Context)
+	}
+}
+
+// parseMounts accepts mounts as "source=...,target=...,type=..." strings or
+// objects
+func (dc *DevContainer) parseMounts(data json.RawMessage, vars func(string) (string, bool)) error {
+	var entries []json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		m := DevContainerMount{Type: "bind"}
+
+		var spec string
+		if err := json.Unmarshal(entry, &spec); err == nil {
+			for _, field := range strings.Split(substitute(spec, vars), ",") {
+				key, value, _ := strings.Cut(strings.TrimSpace(field), "=")
+				switch key {
+				case "type":
+					m.Type = value
+				case "source", "src":
+					m.Source = value
+				case "target", "destination", "dst":
+					m.Target = value
+				case "readonly", "ro":
+					m.ReadOnly = value == "" || value == "true" || value == "1"
+				}
+			}
+		} else {
+			var object struct {
+				Type   string `json:"type"`
+				Source string `json:"source"`
+				Target string `json:"target"`
+			}
+			if err := json.Unmarshal(entry, &object); err != nil {
+				return err
+			}
+			m.Source = substitute(object.Source, vars)
+			m.Target = substitute(object.Target, vars)
+			if object.Type != "" {
+				m.Type = object.Type
+			}
+		}
+
+		if m.Target == "" || (m.Type != "bind" && m.Type != "volume") {
+			dc.Ignored = append(dc.Ignored, fmt.Sprintf("mounts: %s", strings.Trim(string(entry), `"`)))
+			continue
+		}
+		dc.Mounts = append(dc.Mounts, m)
+	}
+
+	return nil
+}
+
+// parseForwardPorts accepts ports as numbers or strings. Ports of other
+// containers ("db:5432") are ignored, services publish their own ports.
+func (dc *DevContainer) parseForwardPorts(data json.RawMessage) error {
+	var entries []interface{}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		switch value := entry.(type) {
+		case float64:
+			dc.ForwardPorts = append(dc.ForwardPorts, int(value))
+			continue
+		case string:
+			if port, err := strconv.Atoi(value); err == nil {
+				dc.ForwardPorts = append(dc.ForwardPorts, port)
+				continue
+			}
+			if host, port, ok := strings.Cut(value, ":"); ok && (host == "localhost" || host == "127.0.0.1") {
+				if port, err := strconv.Atoi(port); err == nil {
+					dc.ForwardPorts = append(dc.ForwardPorts, port)
+					continue
+				}
+			}
+		}
+		dc.Ignored = append(dc.Ignored, fmt.Sprintf("forwardPorts: %v", entry))
+	}
+
+	return nil
+}
+
+// parseLifecycleCommand accepts a command as a shell string, an argument list,
+// or an object of named commands, which are run in name order
+func parseLifecycleCommand(data json.RawMessage) ([][]string, error) {
+	var shell string
+	if err := json.Unmarshal(data, &shell); err == nil {
+		return [][]string{{"/bin/sh", "-c", shell}}, nil
+	}
+
+	var args []string
+	if err := json.Unmarshal(data, &args); err == nil {
+		return [][]string{args}, nil
+	}
+
+	var named map[string]json.RawMessage
+	if err := json.Unmarshal(data, &named); err != nil {
+		return nil, fmt.Errorf("expected a string, list or object")
+	}
+	names := make([]string, 0, len(named))
+	for name := range named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var commands [][]string
+	for _, name := range names {
+		cmds, err := parseLifecycleCommand(named[name])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		commands = append(commands, cmds...)
+	}
+	return commands, nil
+}
+
+// parseFeatures installs language features with mise; other features are
+// ignored
+func (dc *DevContainer) parseFeatures(data json.RawMessage) error {
+	var features map[string]interface{}
+	if err := json.Unmarshal(data, &features); err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(features))
+	for id := range features {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		// ghcr.io/devcontainers/features/node:1 -> node
+		name := id[strings.LastIndex(id, "/")+1:]
+		if i := strings.IndexAny(name, ":@"); i >= 0 {
+			name = name[:i]
+		}
+
+		tool, ok := miseFeatures[name]
+		if !ok {
+			dc.Ignored = append(dc.Ignored, "features: "+id)
+			continue
+		}
+
+		// Options are an object with a version, or just the version
+		version, _ := features[id].(string)
+		if options, ok := features[id].(map[string]interface{}); ok {
+			version, _ = options["version"].(string)
+		}
+		if version == "" || version == "lts" || version == "os-provided" {
+			version = "latest"
+		}
+		dc.Tools = append(dc.Tools, tool+"@"+version)
+		dc.Applied = append(dc.Applied, fmt.Sprintf("features: %s (mise %s@%s)", id, tool, version))
+	}
+
+	return nil
+}
+
+// stripJSONC removes comments and trailing commas, which devcontainer.json
+// allows
+func stripJSONC(data []byte) []byte {
+	return stripTrailingCommas(stripComments(data))
+}
+
+// scanJSON calls fn for every byte outside strings; fn returns how many bytes
+// to skip. Strings are copied to out unchanged.
+func scanJSON(data []byte, fn func(out []byte, i int) ([]byte, int)) []byte {
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		if data[i] != '"' {
+			var skip int
+			out, skip = fn(out, i)
+			i += skip
+			continue
+		}
+
+		start := i
+		for i++; i < len(data) && data[i] != '"'; i++ {
+			if data[i] == '\\' {
+				i++
+			}
+		}
+		if i >= len(data) {
+			return append(out, data[start:]...)
+		}
+		out = append(out, data[start:i+1]...)
+	}
+	return out
+}
+
+func stripComments(data []byte) []byte {
+	return scanJSON(data, func(out []byte, i int) ([]byte, int) {
+		if data[i] != '/' || i+1 >= len(data) {
+			return append(out, data[i]), 0
+		}
+
+		switch data[i+1] {
+		case '/':
+			end := strings.IndexByte(string(data[i:]), '\n')
+			if end < 0 {
+				return out, len(data) - i
+			}
+			return append(out, '\n'), end
+		case '*':
+			end := strings.Index(string(data[i+2:]), "*/")
+			if end < 0 {
+				return out, len(data) - i
+			}
+			return append(out, ' '), end + 3
+		}
+		return append(out, data[i]), 0
+	})
+}
+
+func stripTrailingCommas(data []byte) []byte {
+	return scanJSON(data, func(out []byte, i int) ([]byte, int) {
+		if data[i] != ',' {
+			return append(out, data[i]), 0
+		}
+
+		rest := strings.TrimLeft(string(data[i+1:]), " \t\r\n")
+		if strings.HasPrefix(rest, "}") || strings.HasPrefix(rest, "]") {
+			return out, 0
+		}
+		return append(out, ','), 0
+	})
+}
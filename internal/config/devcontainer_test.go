@@ -0,0 +1,137 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadDevContainer(t *testing.T) {
+	t.Setenv("RIZE_TEST_TOKEN", "secret")
+
+	dir := t.TempDir()
+	if dc, err := LoadDevContainer(dir, "/workspace/app"); err != nil || dc != nil {
+		t.Fatalf("LoadDevContainer() without devcontainer.json = %+v, %v", dc, err)
+	}
+
+	data := []byte(`{
+	// Comments and trailing commas are allowed
+	"name": "app",
+	"build": {
+		"dockerfile": "Dockerfile",
+		"context": "..",
+		"args": {"VARIANT": "bookworm"},
+	},
+	"containerEnv": {
+		"TOKEN": "${localEnv:RIZE_TEST_TOKEN}",
+		"MISSING": "${localEnv:RIZE_TEST_MISSING:fallback}",
+		"URL": "http://example.com/*not-a-comment*/", /* block comment */
+	},
+	"remoteEnv": {"PATH": "${containerEnv:PATH}:${containerWorkspaceFolder}/bin"},
+	"mounts": [
+		"source=${localWorkspaceFolder}/.cache,target=/cache,type=bind,readonly",
+		{"source": "app-data", "target": "/data", "type": "volume"},
+		"type=tmpfs,target=/tmp/scratch"
+	],
+	"forwardPorts": [3000, "8080", "db:5432"],
+	"postCreateCommand": "npm install",
+	"postStartCommand": {"server": ["npm", "start"], "assets": "npm run watch"},
+	"features": {
+		"ghcr.io/devcontainers/features/node:1": {"version": "20"},
+		"ghcr.io/devcontainers/features/python:1": "3.12",
+		"ghcr.io/devcontainers/features/docker-in-docker:2": {}
+	},
+	"customizations": {"vscode": {"extensions": ["golang.go"]}}
+}`)
+	if err := os.MkdirAll(filepath.Join(dir, ".devcontainer"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".devcontainer", "devcontainer.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dc, err := LoadDevContainer(dir, "/workspace/app")
+	if err != nil {
+		t.Fatalf("LoadDevContainer() failed: %v", err)
+	}
+
+	wantBuild := &DevContainerBuild{
+		Dockerfile: filepath.Join(dir, ".devcontainer", "Dockerfile"),
+		Context:    dir,
+		Args:       map[string]string{"VARIANT": "bookworm"},
+	}
+	if !reflect.DeepEqual(dc.Build, wantBuild) {
+		t.Errorf("Expected build %+v, got %+v", wantBuild, dc.Build)
+	}
+
+	wantEnv := map[string]string{"TOKEN": "secret", "MISSING": "fallback", "URL": "http://example.com/*not-a-comment*/"}
+	if !reflect.DeepEqual(dc.ContainerEnv, wantEnv) {
+		t.Errorf("Expected containerEnv %v, got %v", wantEnv, dc.ContainerEnv)
+	}
+	if got := dc.RemoteEnv["PATH"]; got != "${containerEnv:PATH}:/workspace/app/bin" {
+		t.Errorf("Expected containerEnv references to be kept, got %q", got)
+	}
+
+	wantMounts := []DevContainerMount{
+		{Type: "bind", Source: filepath.Join(dir, ".cache"), Target: "/cache", ReadOnly: true},
+		{Type: "volume", Source: "app-data", Target: "/data"},
+	}
+	if !reflect.DeepEqual(dc.Mounts, wantMounts) {
+		t.Errorf("Expected mounts %+v, got %+v", wantMounts, dc.Mounts)
+	}
+
+	if want := []int{3000, 8080}; !reflect.DeepEqual(dc.ForwardPorts, want) {
+		t.Errorf("Expected forwardPorts %v, got %v", want, dc.ForwardPorts)
+	}
+
+	if want := [][]string{{"/bin/sh", "-c", "npm install"}}; !reflect.DeepEqual(dc.PostCreateCommand, want) {
+		t.Errorf("Expected postCreateCommand %v, got %v", want, dc.PostCreateCommand)
+	}
+	wantStart := [][]string{{"/bin/sh", "-c", "npm run watch"}, {"npm", "start"}}
+	if !reflect.DeepEqual(dc.PostStartCommand, wantStart) {
+		t.Errorf("Expected postStartCommand %v, got %v", wantStart, dc.PostStartCommand)
+	}
+
+	if want := []string{"node@20", "python@3.12"}; !reflect.DeepEqual(dc.Tools, want) {
+		t.Errorf("Expected tools %v, got %v", want, dc.Tools)
+	}
+
+	wantApplied := []string{
+		"build", "containerEnv",
+		"features: ghcr.io/devcontainers/features/node:1 (mise node@20)",
+		"features: ghcr.io/devcontainers/features/python:1 (mise python@3.12)",
+		"forwardPorts", "mounts", "postCreateCommand", "postStartCommand", "remoteEnv",
+	}
+	if !reflect.DeepEqual(dc.Applied, wantApplied) {
+		t.Errorf("Expected applied %v, got %v", wantApplied, dc.Applied)
+	}
+	wantIgnored := []string{
+		"customizations",
+		"features: ghcr.io/devcontainers/features/docker-in-docker:2",
+		"forwardPorts: db:5432",
+		"mounts: type=tmpfs,target=/tmp/scratch",
+		"name",
+	}
+	if !reflect.DeepEqual(dc.Ignored, wantIgnored) {
+		t.Errorf("Expected ignored %v, got %v", wantIgnored, dc.Ignored)
+	}
+}
+
+func TestParseDevContainerImage(t *testing.T) {
+	dc, err := parseDevContainer([]byte(`{"image": "mcr.microsoft.com/devcontainers/go:1", "dockerFile": "Dockerfile"}`), func(string) (string, bool) { return "", false })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if dc.Image != "mcr.microsoft.com/devcontainers/go:1" || dc.Build != nil {
+		t.Errorf("Expected the image to win over the Dockerfile, got %+v", dc)
+	}
+	if want := []string{"dockerFile (image is set)"}; !reflect.DeepEqual(dc.Ignored, want) {
+		t.Errorf("Expected ignored %v, got %v", want, dc.Ignored)
+	}
+
+	if _, err := parseDevContainer([]byte(`{"build": {"context": ".."}}`), nil); err == nil {
+		t.Error("Expected an error for a build without a dockerfile")
+	}
+}
@@ -0,0 +1,216 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectConfigFile is the per-project config file, read from the workspace
+// root and layered on top of the user config
+const ProjectConfigFile = ".rize.yml"
+
+// ProjectConfig represents a project's .rize.yml
+type ProjectConfig struct {
+	// Services adds project services or overrides fields of configured ones
+	Services map[string]Service `yaml:"services"`
+	// Compose is a docker-compose.yml whose services run alongside the rize
+	// services
+	Compose string `yaml:"compose,omitempty"`
+	// Build derives the project container image from the rize image
+	Build *ProjectBuild `yaml:"build,omitempty"`
+
+	// Dir is the directory the config was loaded from
+	Dir string `yaml:"-"`
+	// Path is the path of the config file
+	Path string `yaml:"-"`
+	// ComposeServices are the services imported from the compose file
+	ComposeServices map[string]Service `yaml:"-"`
+	// Warnings lists compose keys and values rize could not import
+	Warnings []string `yaml:"-"`
+
+	// enabled records services that set enabled explicitly, since an
+	// override that leaves it out must not disable the service
+	enabled map[string]bool
+}
+
+// ProjectBuild is the build section of a .rize.yml. The image is built on
+// top of the base image: a Dockerfile, package lists, or both.
+type ProjectBuild struct {
+	// Dockerfile is a Dockerfile path. A Dockerfile without a FROM line is
+	// applied to the base image, which is also available as the
+	// RIZE_BASE_IMAGE build arg.
+	Dockerfile string `yaml:"dockerfile,omitempty"`
+	// Context is the build context directory; defaults to the directory of
+	// the Dockerfile
+	Context string            `yaml:"context,omitempty"`
+	Args    map[string]string `yaml:"args,omitempty"`
+	Target  string            `yaml:"target,omitempty"`
+
+	// Apt lists system packages installed with apt-get
+	Apt []string `yaml:"apt,omitempty"`
+	// Mise lists tools installed with mise use -g, e.g. protoc@29
+	Mise []string `yaml:"mise,omitempty"`
+	// Npm lists packages installed with npm install -g
+	Npm []string `yaml:"npm,omitempty"`
+}
+
+// UnmarshalYAML decodes a project config and records which services set
+// enabled
+func (p *ProjectConfig) UnmarshalYAML(node *yaml.Node) error {
+	type plain ProjectConfig
+	if err := node.Decode((*plain)(p)); err != nil {
+		return err
+	}
+
+	var explicit struct {
+		Services map[string]struct {
+			Enabled *bool `yaml:"enabled"`
+		} `yaml:"services"`
+	}
+	if err := node.Decode(&explicit); err != nil {
+		return err
+	}
+
+	p.enabled = make(map[string]bool)
+	for name, svc := range explicit.Services {
+		if svc.Enabled != nil {
+			p.enabled[name] = *svc.Enabled
+		}
+	}
+
+	return nil
+}
+
+// LoadProject loads the .rize.yml in dir. It returns nil when the project has
+// none. Relative init paths are resolved against dir.
+func LoadProject(dir string) (*ProjectConfig, error) {
+	path := filepath.Join(dir, ProjectConfigFile)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", ProjectConfigFile, err)
+	}
+
+	project := &ProjectConfig{}
+	if err := yaml.Unmarshal(data, project); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	project.Dir = dir
+	project.Path = path
+
+	for name, svc := range project.Services {
+		resolveVolumes(svc.Volumes, dir)
+		for i, asset := range svc.Init {
+			if asset != "" && asset[0] != '~' && !filepath.IsAbs(asset) {
+				svc.Init[i] = filepath.Join(dir, asset)
+			}
+		}
+		project.Services[name] = svc
+	}
+
+	if build := project.Build; build != nil {
+		if build.Dockerfile == "" && len(build.Apt) == 0 && len(build.Mise) == 0 && len(build.Npm) == 0 {
+			return nil, fmt.Errorf("%s: build needs a dockerfile or apt, mise or npm packages", path)
+		}
+		if build.Dockerfile != "" {
+			build.Dockerfile = resolvePath(dir, build.Dockerfile)
+			if build.Context == "" {
+				build.Context = filepath.Dir(build.Dockerfile)
+			}
+		}
+		if build.Context != "" {
+			build.Context = resolvePath(dir, build.Context)
+		}
+	}
+
+	if project.Compose != "" {
+		project.ComposeServices, project.Warnings, err = ImportCompose(resolvePath(dir, project.Compose))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return project, nil
+}
+
+// resolvePath resolves a path relative to the project directory
+func resolvePath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// resolveVolumes resolves bind sources starting with "." in compose-style
+// volume strings against dir, like compose resolves them against the
+// compose file
+func resolveVolumes(volumes []string, dir string) {
+	for i, vol := range volumes {
+		if strings.HasPrefix(vol, ".") {
+			source, rest, found := strings.Cut(vol, ":")
+			volumes[i] = filepath.Join(dir, source)
+			if found {
+				volumes[i] += ":" + rest
+			}
+		}
+	}
+}
+
+// ApplyProject layers a project config on top of the user config. Services
+// imported from the project's compose file are added, replacing configured
+// services of the same name. Services the project defines that are not
+// configured are added and enabled unless they set enabled: false; for
+// configured services the fields the project sets replace the user's.
+func (c *Config) ApplyProject(project *ProjectConfig) {
+	if project == nil {
+		return
+	}
+	c.Project = project
+
+	if c.Services == nil {
+		c.Services = make(map[string]Service)
+	}
+
+	for name, svc := range project.ComposeServices {
+		c.Services[name] = svc
+	}
+
+	for name, override := range project.Services {
+		enabled, explicit := project.enabled[name]
+
+		svc, exists := c.Services[name]
+		if !exists {
+			override.Enabled = !explicit || enabled
+			c.Services[name] = override
+			continue
+		}
+
+		svc = mergeServiceWithDefaults(override, svc)
+		if svc.Init == nil {
+			svc.Init = c.Services[name].Init
+		}
+		if svc.Seed == nil {
+			svc.Seed = c.Services[name].Seed
+		}
+		if svc.DependsOn == nil {
+			svc.DependsOn = c.Services[name].DependsOn
+		}
+		if svc.Aliases == nil {
+			svc.Aliases = c.Services[name].Aliases
+		}
+		if svc.Restart == "" {
+			svc.Restart = c.Services[name].Restart
+		}
+		svc.Enabled = c.Services[name].Enabled
+		if explicit {
+			svc.Enabled = enabled
+		}
+		c.Services[name] = svc
+	}
+}
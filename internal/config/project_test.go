@@ -0,0 +1,150 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadProject(t *testing.T) {
+	dir := t.TempDir()
+
+	project, err := LoadProject(dir)
+	if err != nil {
+		t.Fatalf("LoadProject() without .rize.yml: %v", err)
+	}
+	if project != nil {
+		t.Fatalf("Expected no project config, got %+v", project)
+	}
+
+	data := []byte(`services:
+  postgres:
+    init:
+      - db/schema.sql
+      - /abs/seed.sql
+      - ~/dumps/base.sql
+    volumes:
+      - ./pgdata:/var/lib/postgresql/data
+`)
+	if err := os.WriteFile(filepath.Join(dir, ProjectConfigFile), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	project, err = LoadProject(dir)
+	if err != nil {
+		t.Fatalf("LoadProject() failed: %v", err)
+	}
+
+	want := []string{filepath.Join(dir, "db/schema.sql"), "/abs/seed.sql", "~/dumps/base.sql"}
+	got := project.Services["postgres"].Init
+	if len(got) != len(want) {
+		t.Fatalf("Expected init %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected init %v, got %v", want, got)
+		}
+	}
+
+	volumes := project.Services["postgres"].Volumes
+	if len(volumes) != 1 || volumes[0] != filepath.Join(dir, "pgdata")+":/var/lib/postgresql/data" {
+		t.Errorf("Expected the volume to be resolved against the project, got %v", volumes)
+	}
+}
+
+func TestLoadProjectBuild(t *testing.T) {
+	dir := t.TempDir()
+	write := func(data string) {
+		if err := os.WriteFile(filepath.Join(dir, ProjectConfigFile), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`build:
+  dockerfile: docker/rize.Dockerfile
+  apt: [libvips-dev]
+`)
+	project, err := LoadProject(dir)
+	if err != nil {
+		t.Fatalf("LoadProject() failed: %v", err)
+	}
+	if got, want := project.Build.Dockerfile, filepath.Join(dir, "docker/rize.Dockerfile"); got != want {
+		t.Errorf("Expected dockerfile %s, got %s", want, got)
+	}
+	if got, want := project.Build.Context, filepath.Join(dir, "docker"); got != want {
+		t.Errorf("Expected the context to default to the Dockerfile directory %s, got %s", want, got)
+	}
+
+	write(`build:
+  context: .
+  mise: [protoc@29]
+`)
+	project, err = LoadProject(dir)
+	if err != nil {
+		t.Fatalf("LoadProject() failed: %v", err)
+	}
+	if project.Build.Context != dir {
+		t.Errorf("Expected context %s, got %s", dir, project.Build.Context)
+	}
+
+	write("build:\n  context: .\n")
+	if _, err := LoadProject(dir); err == nil {
+		t.Error("Expected an error for a build without a Dockerfile or packages")
+	}
+}
+
+func TestApplyProject(t *testing.T) {
+	dir := t.TempDir()
+	data := []byte(`services:
+  postgres:
+    init: [init]
+    environment:
+      POSTGRES_DB: app
+  redis:
+    enabled: false
+  mysql:
+    image: mysql:8
+  worker:
+    image: busybox
+    enabled: false
+`)
+	if err := os.WriteFile(filepath.Join(dir, ProjectConfigFile), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := LoadProject(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.ApplyProject(project)
+
+	if cfg.Project != project {
+		t.Error("Expected the project config to be recorded")
+	}
+
+	postgres := cfg.Services["postgres"]
+	if !postgres.Enabled {
+		t.Error("Expected an override without enabled to keep postgres enabled")
+	}
+	if postgres.Image != DefaultConfig().Services["postgres"].Image {
+		t.Errorf("Expected the user image to be kept, got %q", postgres.Image)
+	}
+	if postgres.Environment["POSTGRES_DB"] != "app" || postgres.Environment["POSTGRES_USER"] == "" {
+		t.Errorf("Expected project environment merged over the user's, got %v", postgres.Environment)
+	}
+	if len(postgres.Init) != 1 || postgres.Init[0] != filepath.Join(dir, "init") {
+		t.Errorf("Expected init resolved against the project, got %v", postgres.Init)
+	}
+
+	if cfg.Services["redis"].Enabled {
+		t.Error("Expected redis to be disabled by the project")
+	}
+	if !cfg.Services["mysql"].Enabled {
+		t.Error("Expected a new project service to be enabled")
+	}
+	if cfg.Services["worker"].Enabled {
+		t.Error("Expected a new project service with enabled: false to stay disabled")
+	}
+}
@@ -2,13 +2,34 @@ package config
 
 // Config represents the main configuration structure
 type Config struct {
-	Services    map[string]Service    `yaml:"services"`
-	Environment map[string]string     `yaml:"environment"`
-	Network     NetworkConfig         `yaml:"network"`
-	Volumes     []string              `yaml:"volumes"`
+	Services     map[string]Service    `yaml:"services"`
+	ServiceScope string                `yaml:"service_scope"`
+	Environment  map[string]string     `yaml:"environment"`
+	Network      NetworkConfig         `yaml:"network"`
+	Volumes      []string              `yaml:"volumes"`
+	Recording    RecordingConfig       `yaml:"recording"`
+	Usage        UsageConfig           `yaml:"usage"`
+	Pricing      map[string]ModelPrice `yaml:"pricing"`
+	Redaction    RedactionConfig       `yaml:"redaction"`
+	// Caches maps package-manager cache names to the directories in the
+	// container they are mounted at; an empty path disables a cache
+	Caches map[string]string `yaml:"caches"`
+
+	// Project is the .rize.yml of the current project, if any
+	Project *ProjectConfig `yaml:"-"`
+	// DevContainer is the devcontainer.json of the current project, if any
+	DevContainer *DevContainer `yaml:"-"`
 }
 
-// Service represents a docker compose service
+// Service scopes
+const (
+	// ServiceScopeShared runs one set of services shared by all projects
+	ServiceScopeShared = "shared"
+	// ServiceScopeProject runs a separate set of services for each project
+	ServiceScopeProject = "project"
+)
+
+// Service represents a service container run alongside the agent container
 type Service struct {
 	Enabled     bool              `yaml:"enabled"`
 	Image       string            `yaml:"image"`
@@ -17,6 +38,16 @@ type Service struct {
 	Environment map[string]string `yaml:"environment,omitempty"`
 	Volumes     []string          `yaml:"volumes,omitempty"`
 	HealthCheck *HealthCheck      `yaml:"healthcheck,omitempty"`
+	DependsOn   []string          `yaml:"depends_on,omitempty"`
+	Restart     string            `yaml:"restart,omitempty"`
+	// Aliases are extra hostnames of the service on the rize network
+	Aliases []string `yaml:"aliases,omitempty"`
+	// Init lists SQL/shell files or a directory mounted into
+	// /docker-entrypoint-initdb.d, run by postgres/mysql on first start
+	Init []string `yaml:"init,omitempty"`
+	// Seed is the command `rize services seed` pipes a dump into; postgres
+	// and mysql images have a default
+	Seed []string `yaml:"seed,omitempty"`
 }
 
 // HealthCheck represents a service health check
@@ -32,3 +63,30 @@ type NetworkConfig struct {
 	Name   string `yaml:"name"`
 	Driver string `yaml:"driver"`
 }
+
+// RecordingConfig represents session recording configuration
+type RecordingConfig struct {
+	Enabled       bool `yaml:"enabled"`
+	RetentionDays int  `yaml:"retention_days"`
+	MaxRecordings int  `yaml:"max_recordings"`
+}
+
+// UsageConfig represents LLM usage record configuration
+type UsageConfig struct {
+	RetentionDays int `yaml:"retention_days"`
+}
+
+// ModelPrice is the price of a model in USD per million tokens
+type ModelPrice struct {
+	Input      float64 `yaml:"input"`
+	Output     float64 `yaml:"output"`
+	CacheRead  float64 `yaml:"cache_read,omitempty"`
+	CacheWrite float64 `yaml:"cache_write,omitempty"`
+}
+
+// RedactionConfig represents user-defined secret redaction rules, applied
+// on top of the built-in detectors before data is written under ~/.rize
+type RedactionConfig struct {
+	Patterns []string `yaml:"patterns,omitempty"`
+	Headers  []string `yaml:"headers,omitempty"`
+}
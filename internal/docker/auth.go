@@ -0,0 +1,174 @@
+package docker
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/alienxp03/rize/internal/ui"
+	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types/registry"
+)
+
+// dockerHubAuthKey is the key Docker stores Docker Hub credentials under
+const dockerHubAuthKey = "https://index.docker.io/v1/"
+
+// dockerConfig is the part of ~/.docker/config.json holding credentials
+type dockerConfig struct {
+	Auths       map[string]dockerAuth `json:"auths"`
+	CredsStore  string                `json:"credsStore"`
+	CredHelpers map[string]string     `json:"credHelpers"`
+}
+
+type dockerAuth struct {
+	Auth          string `json:"auth"`
+	Username      string `json:"username"`
+	Password      string `json:"password"`
+	IdentityToken string `json:"identitytoken"`
+}
+
+// registryAuth returns the encoded credentials the Docker config has for
+// the registry of an image, or "" to pull anonymously
+func registryAuth(ref string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return "", fmt.Errorf("invalid image reference %s: %w", ref, err)
+	}
+
+	cfg, err := loadDockerConfig()
+	if err != nil || cfg == nil {
+		return "", err
+	}
+
+	authConfig, err := cfg.credentials(reference.Domain(named))
+	if err != nil || authConfig == nil {
+		return "", err
+	}
+	return registry.EncodeAuthConfig(*authConfig)
+}
+
+// dockerConfigPath returns the Docker config file, in $DOCKER_CONFIG or
+// ~/.docker
+func dockerConfigPath() (string, error) {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return filepath.Join(dir, "config.json"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".docker", "config.json"), nil
+}
+
+// loadDockerConfig reads the Docker config, nil when there is none
+func loadDockerConfig() (*dockerConfig, error) {
+	path, err := dockerConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Docker config: %w", err)
+	}
+
+	var cfg dockerConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// credentials returns the credentials of a registry domain, from its
+// credential helper or from auths, nil when there are none
+func (cfg *dockerConfig) credentials(domain string) (*registry.AuthConfig, error) {
+	key := domain
+	if domain == "docker.io" {
+		key = dockerHubAuthKey
+	}
+
+	helper := cfg.CredsStore
+	if h, ok := cfg.CredHelpers[domain]; ok {
+		helper = h
+	}
+	if helper != "" {
+		return helperCredentials(helper, key)
+	}
+
+	for server, auth := range cfg.Auths {
+		if server != key && authDomain(server) != domain {
+			continue
+		}
+		authConfig := &registry.AuthConfig{
+			Username:      auth.Username,
+			Password:      auth.Password,
+			IdentityToken: auth.IdentityToken,
+			ServerAddress: server,
+		}
+		if auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("invalid auth for %s in Docker config: %w", server, err)
+			}
+			user, password, ok := strings.Cut(string(decoded), ":")
+			if !ok {
+				return nil, fmt.Errorf("invalid auth for %s in Docker config", server)
+			}
+			authConfig.Username, authConfig.Password = user, password
+		}
+		return authConfig, nil
+	}
+	return nil, nil
+}
+
+// authDomain returns the domain of a server address of auths, which can be
+// a URL like https://registry.example.com/v1/
+func authDomain(server string) string {
+	server = strings.TrimPrefix(server, "https://")
+	server = strings.TrimPrefix(server, "http://")
+	domain, _, _ := strings.Cut(server, "/")
+	return domain
+}
+
+// helperCredentials asks a credential helper (docker-credential-<helper>)
+// for the credentials of a server, nil when it has none
+func helperCredentials(helper, server string) (*registry.AuthConfig, error) {
+	name := "docker-credential-" + helper
+	ui.Trace("exec %s get (%s)", name, server)
+
+	cmd := exec.Command(name, "get")
+	cmd.Stdin = strings.NewReader(server)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		output := strings.TrimSpace(stdout.String() + stderr.String())
+		if strings.Contains(output, "credentials not found") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get credentials from %s: %w", name, err)
+	}
+
+	var creds struct {
+		Username string
+		Secret   string
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &creds); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials from %s: %w", name, err)
+	}
+
+	authConfig := &registry.AuthConfig{ServerAddress: server}
+	if creds.Username == "<token>" {
+		authConfig.IdentityToken = creds.Secret
+	} else {
+		authConfig.Username, authConfig.Password = creds.Username, creds.Secret
+	}
+	return authConfig, nil
+}
@@ -0,0 +1,87 @@
+package docker
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types/registry"
+)
+
+func writeDockerConfig(t *testing.T, cfg string) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(cfg), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DOCKER_CONFIG", dir)
+}
+
+func decodeRegistryAuth(t *testing.T, encoded string) registry.AuthConfig {
+	t.Helper()
+	data, err := base64.URLEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var authConfig registry.AuthConfig
+	if err := json.Unmarshal(data, &authConfig); err != nil {
+		t.Fatal(err)
+	}
+	return authConfig
+}
+
+func TestRegistryAuth(t *testing.T) {
+	mirror := base64.StdEncoding.EncodeToString([]byte("alice:s3cret:x"))
+	hub := base64.StdEncoding.EncodeToString([]byte("bob:hunter2"))
+	writeDockerConfig(t, `{"auths": {
+		"https://mirror.example.com/v1/": {"auth": "`+mirror+`"},
+		"https://index.docker.io/v1/": {"auth": "`+hub+`"}
+	}}`)
+
+	tests := []struct {
+		ref      string
+		user     string
+		password string
+	}{
+		{"mirror.example.com/team/rize:latest", "alice", "s3cret:x"},
+		{"alienxp03/rize:latest", "bob", "hunter2"},
+		{"docker.io/library/postgres:16", "bob", "hunter2"},
+		{"ghcr.io/team/rize:latest", "", ""},
+	}
+
+	for _, tt := range tests {
+		encoded, err := registryAuth(tt.ref)
+		if err != nil {
+			t.Fatalf("registryAuth(%q) error = %v", tt.ref, err)
+		}
+		if tt.user == "" {
+			if encoded != "" {
+				t.Errorf("registryAuth(%q) = %q, want no credentials", tt.ref, encoded)
+			}
+			continue
+		}
+		authConfig := decodeRegistryAuth(t, encoded)
+		if authConfig.Username != tt.user || authConfig.Password != tt.password {
+			t.Errorf("registryAuth(%q) = %s:%s, want %s:%s", tt.ref, authConfig.Username, authConfig.Password, tt.user, tt.password)
+		}
+	}
+}
+
+func TestRegistryAuthWithoutConfig(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+
+	encoded, err := registryAuth(ImageName)
+	if err != nil || encoded != "" {
+		t.Errorf("registryAuth() = %q, %v, want no credentials", encoded, err)
+	}
+}
+
+func TestRegistryAuthInvalid(t *testing.T) {
+	writeDockerConfig(t, `{"auths": {"registry.example.com": {"auth": "bm9jb2xvbg=="}}}`)
+
+	if _, err := registryAuth("registry.example.com/rize"); err == nil {
+		t.Error("registryAuth() succeeded with an auth without a password")
+	}
+}
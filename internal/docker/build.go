@@ -0,0 +1,353 @@
+package docker
+
+import (
+	"archive/tar"
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alienxp03/rize/internal/audit"
+	"github.com/alienxp03/rize/internal/ui"
+	"github.com/docker/docker/api/types/build"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/moby/term"
+)
+
+// BuildLabel marks images built by rize with the repository they belong to
+const BuildLabel = "rize.build"
+
+// buildDockerfile is the name the Dockerfile gets in the build context
+const buildDockerfile = ".rize.Dockerfile"
+
+// BuildSpec describes an image rize builds. The image is tagged with a hash
+// of its inputs, so it is only rebuilt when they change.
+type BuildSpec struct {
+	// Repository is the image name; the tag is the content hash
+	Repository string
+	// Dockerfile is the Dockerfile content
+	Dockerfile string
+	// Context is the build context directory; empty for no context
+	Context string
+	Args    map[string]string
+	Target  string
+	// BaseID is the ID of the base image, so the image is rebuilt when the
+	// base image is updated under the same tag
+	BaseID string
+}
+
+// BuildImage builds an image unless an image with the same inputs exists and
+// returns its reference
+func (c *Client) BuildImage(spec BuildSpec) (string, error) {
+	hash, err := contextHash(spec)
+	if err != nil {
+		return "", err
+	}
+
+	ref := spec.Repository + ":" + hash
+	if _, err := c.cli.ImageInspect(c.ctx, ref); err == nil {
+		return ref, nil
+	}
+
+	ui.Info("Building %s...", ref)
+
+	start := time.Now()
+	err = c.build(spec, ref)
+	audit.Log(audit.Event{
+		Type:       audit.ImageBuild,
+		Image:      ref,
+		DurationMs: time.Since(start).Milliseconds(),
+		Error:      audit.ErrorString(err),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	c.removeOldBuilds(spec.Repository, ref)
+
+	return ref, nil
+}
+
+// removeOldBuilds removes the images previously built for a repository.
+// Failures are ignored, an image may still be used by a container.
+func (c *Client) removeOldBuilds(repository, keep string) {
+	images, err := c.cli.ImageList(c.ctx, image.ListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", BuildLabel+"="+repository)),
+	})
+	if err != nil {
+		return
+	}
+
+	for _, img := range images {
+		if slices.Contains(img.RepoTags, keep) {
+			continue
+		}
+		for _, tag := range img.RepoTags {
+			c.cli.ImageRemove(c.ctx, tag, image.RemoveOptions{PruneChildren: true})
+		}
+	}
+}
+
+func (c *Client) build(spec BuildSpec, ref string) error {
+	args := make(map[string]*string, len(spec.Args))
+	for key, value := range spec.Args {
+		args[key] = &value
+	}
+
+	// Stream the context to docker instead of holding it in memory
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(writeContext(writer, spec))
+	}()
+	defer reader.Close()
+
+	resp, err := c.cli.ImageBuild(c.ctx, reader, build.ImageBuildOptions{
+		Tags:        []string{ref},
+		Dockerfile:  buildDockerfile,
+		BuildArgs:   args,
+		Target:      spec.Target,
+		Labels:      map[string]string{BuildLabel: spec.Repository},
+		Remove:      true,
+		ForceRemove: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	fd, isTerminal := term.GetFdInfo(os.Stderr)
+	if err := jsonmessage.DisplayJSONMessagesStream(resp.Body, os.Stderr, fd, isTerminal, nil); err != nil {
+		return fmt.Errorf("failed to build image: %w", err)
+	}
+
+	return nil
+}
+
+// walkContext calls fn for every file of the build context that is not
+// excluded by .dockerignore, in lexical order
+func walkContext(spec BuildSpec, fn func(p, rel string, entry fs.DirEntry) error) error {
+	if spec.Context == "" {
+		return nil
+	}
+
+	ignore, err := readDockerignore(spec.Context)
+	if err != nil {
+		return err
+	}
+
+	err = filepath.WalkDir(spec.Context, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(spec.Context, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if rel == ".git" || ignore.matches(rel) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		return fn(p, rel, entry)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read build context: %w", err)
+	}
+	return nil
+}
+
+// contextHash hashes everything that affects the image while walking the
+// build context, without archiving it. File times and owners are left out
+// so the hash only changes with the content.
+func contextHash(spec BuildSpec) (string, error) {
+	hash := sha256.New()
+	err := walkContext(spec, func(p, rel string, entry fs.DirEntry) error {
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		}
+
+		size := int64(0)
+		if info.Mode().IsRegular() {
+			size = info.Size()
+		}
+		fmt.Fprintf(hash, "\x00file %q %v %d %q\x00", rel, info.Mode(), size, link)
+		if size == 0 {
+			return nil
+		}
+
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = io.CopyN(hash, f, size)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Fprintf(hash, "\x00dockerfile %d\x00%s", len(spec.Dockerfile), spec.Dockerfile)
+	keys := make([]string, 0, len(spec.Args))
+	for key := range spec.Args {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(hash, "\x00arg %s=%s", key, spec.Args[key])
+	}
+	fmt.Fprintf(hash, "\x00target %s", spec.Target)
+	fmt.Fprintf(hash, "\x00base %s", spec.BaseID)
+
+	return hex.EncodeToString(hash.Sum(nil))[:12], nil
+}
+
+// writeContext writes the build context as a tar archive with the Dockerfile
+// added. File times and owners are left out of the archive.
+func writeContext(w io.Writer, spec BuildSpec) error {
+	tw := tar.NewWriter(w)
+
+	err := walkContext(spec, func(p, rel string, entry fs.DirEntry) error {
+		return addToTar(tw, p, rel, entry)
+	})
+	if err != nil {
+		return err
+	}
+
+	header := &tar.Header{Name: buildDockerfile, Mode: 0644, Size: int64(len(spec.Dockerfile))}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(tw, spec.Dockerfile); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+func addToTar(tw *tar.Writer, p, rel string, entry fs.DirEntry) error {
+	info, err := entry.Info()
+	if err != nil {
+		return err
+	}
+
+	link := ""
+	if info.Mode()&os.ModeSymlink != 0 {
+		if link, err = os.Readlink(p); err != nil {
+			return err
+		}
+	}
+
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	header.Name = rel
+	header.ModTime = time.Time{}
+	header.AccessTime = time.Time{}
+	header.ChangeTime = time.Time{}
+	header.Uid, header.Gid = 0, 0
+	header.Uname, header.Gname = "", ""
+	header.Format = tar.FormatPAX
+
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// dockerignore holds the patterns of a .dockerignore file
+type dockerignore []string
+
+func readDockerignore(dir string) (dockerignore, error) {
+	f, err := os.Open(filepath.Join(dir, ".dockerignore"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var patterns dockerignore
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		negate := strings.HasPrefix(line, "!")
+		line = path.Clean(strings.TrimPrefix(strings.TrimPrefix(line, "!"), "/"))
+		if negate {
+			line = "!" + line
+		}
+		patterns = append(patterns, line)
+	}
+
+	return patterns, scanner.Err()
+}
+
+// matches reports whether a slash-separated path is excluded. Like docker,
+// the last matching pattern wins and a pattern matching a directory excludes
+// everything below it.
+func (d dockerignore) matches(rel string) bool {
+	excluded := false
+	for _, pattern := range d {
+		negate := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		if matchIgnorePattern(pattern, rel) {
+			excluded = !negate
+		}
+	}
+	return excluded
+}
+
+func matchIgnorePattern(pattern, rel string) bool {
+	for p := rel; p != "."; p = path.Dir(p) {
+		if ok, _ := path.Match(pattern, p); ok {
+			return true
+		}
+		// "**/name" matches at any depth
+		if suffix, ok := strings.CutPrefix(pattern, "**/"); ok {
+			if ok, _ := path.Match(suffix, path.Base(p)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func contextArchive(t *testing.T, spec BuildSpec) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := writeContext(&buf, spec); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestBuildContext(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"package.json":             "{}",
+		"src/index.js":             "console.log(1)",
+		"node_modules/dep/x.js":    "x",
+		"logs/debug.log":           "debug",
+		"logs/keep.log":            "keep",
+		"docs/deep/notes.tmp":      "tmp",
+		".git/HEAD":                "ref",
+		".dockerignore":            "# deps\nnode_modules\nlogs/*.log\n!logs/keep.log\n**/*.tmp\n",
+		"nested/node_modules/a.js": "a",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	spec := BuildSpec{Repository: "rize-test", Dockerfile: "FROM alpine\n", Context: dir}
+	archive := contextArchive(t, spec)
+	hash, err := contextHash(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var files []string
+	tr := tar.NewReader(bytes.NewReader(archive))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			files = append(files, header.Name)
+		}
+	}
+	want := []string{".dockerignore", "logs/keep.log", "nested/node_modules/a.js", "package.json", "src/index.js", buildDockerfile}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("Expected context files %v, got %v", want, files)
+	}
+
+	// Touching files does not change the hash; changing content, args or
+	// the Dockerfile does
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "package.json"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	if same, _ := contextHash(spec); same != hash {
+		t.Error("Expected the hash to ignore file times")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "node_modules/dep/x.js"), []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if ignored, _ := contextHash(spec); ignored != hash {
+		t.Error("Expected the hash to ignore excluded files")
+	}
+
+	for name, changed := range map[string]BuildSpec{
+		"dockerfile": {Repository: spec.Repository, Dockerfile: "FROM alpine:3\n", Context: dir},
+		"args":       {Repository: spec.Repository, Dockerfile: spec.Dockerfile, Context: dir, Args: map[string]string{"A": "1"}},
+		"target":     {Repository: spec.Repository, Dockerfile: spec.Dockerfile, Context: dir, Target: "dev"},
+		"base image": {Repository: spec.Repository, Dockerfile: spec.Dockerfile, Context: dir, BaseID: "sha256:abc"},
+	} {
+		if other, _ := contextHash(changed); other == hash {
+			t.Errorf("Expected a %s change to change the hash", name)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "src/index.js"), []byte("console.log(2)"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if other, _ := contextHash(spec); other == hash {
+		t.Error("Expected a content change to change the hash")
+	}
+}
+
+func TestBuildContextWithoutContext(t *testing.T) {
+	archive := contextArchive(t, BuildSpec{Dockerfile: "FROM alpine\n"})
+
+	header, err := tar.NewReader(bytes.NewReader(archive)).Next()
+	if err != nil || header.Name != buildDockerfile {
+		t.Errorf("Expected only the Dockerfile, got %+v, %v", header, err)
+	}
+}
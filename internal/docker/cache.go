@@ -0,0 +1,199 @@
+package docker
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alienxp03/rize/internal/audit"
+	"github.com/alienxp03/rize/internal/config"
+	"github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
+)
+
+// CacheLabel marks the package-manager cache volumes with the cache name
+const CacheLabel = "rize.cache"
+
+// cacheVolumePrefix prefixes the names of the cache volumes
+const cacheVolumePrefix = "rize-cache-"
+
+// Cache is a package-manager cache volume shared by all project containers
+type Cache struct {
+	Name   string
+	Volume string
+	// Path is where the cache is mounted; empty for volumes of caches that
+	// are no longer configured
+	Path    string
+	Created time.Time
+	// Exists reports whether the volume was created
+	Exists bool
+	// Size is the size in bytes, -1 when unknown
+	Size int64
+	// InUse is the number of containers using the volume, -1 when unknown
+	InUse int64
+}
+
+// CacheVolume returns the volume of a cache
+func CacheVolume(name string) string {
+	return cacheVolumePrefix + name
+}
+
+// cachePath returns the container directory of a cache path, which may be
+// relative to the agent's home
+func cachePath(p string) string {
+	if rest, ok := strings.CutPrefix(p, "~/"); ok {
+		return path.Join(ContainerHome, rest)
+	}
+	return p
+}
+
+// cacheNames returns the enabled caches, sorted
+func cacheNames(caches map[string]string) []string {
+	var names []string
+	for name, p := range caches {
+		if p != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// cacheMounts returns the mounts of the enabled caches
+func cacheMounts(caches map[string]string) []mount.Mount {
+	var mounts []mount.Mount
+	for _, name := range cacheNames(caches) {
+		mounts = append(mounts, mount.Mount{
+			Type:   mount.TypeVolume,
+			Source: CacheVolume(name),
+			Target: cachePath(caches[name]),
+			VolumeOptions: &mount.VolumeOptions{
+				Labels: managedLabels(map[string]string{CacheLabel: name}),
+			},
+		})
+	}
+	return mounts
+}
+
+// cacheDirs returns the cache directories for the entrypoint, which hands
+// them to the agent user
+func cacheDirs(caches map[string]string) string {
+	var dirs []string
+	for _, name := range cacheNames(caches) {
+		dirs = append(dirs, cachePath(caches[name]))
+	}
+	return strings.Join(dirs, ":")
+}
+
+// Caches returns the configured caches and the cache volumes of caches that
+// are no longer configured. Sizes are only computed when withSize is set,
+// since docker has to walk every volume for them.
+func (c *Client) Caches(cfg *config.Config, withSize bool) ([]Cache, error) {
+	caches := map[string]*Cache{}
+	for _, name := range cacheNames(cfg.Caches) {
+		caches[name] = &Cache{
+			Name:   name,
+			Volume: CacheVolume(name),
+			Path:   cachePath(cfg.Caches[name]),
+			Size:   -1,
+			InUse:  -1,
+		}
+	}
+
+	var volumes []*volume.Volume
+	if withSize {
+		usage, err := c.cli.DiskUsage(c.ctx, types.DiskUsageOptions{Types: []types.DiskUsageObject{types.VolumeObject}})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get volume sizes: %w", err)
+		}
+		volumes = usage.Volumes
+	} else {
+		resp, err := c.cli.VolumeList(c.ctx, volume.ListOptions{Filters: filters.NewArgs(filters.Arg("label", CacheLabel))})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list volumes: %w", err)
+		}
+		volumes = resp.Volumes
+	}
+
+	for _, vol := range volumes {
+		name, ok := vol.Labels[CacheLabel]
+		if !ok || vol.Name != CacheVolume(name) {
+			continue
+		}
+
+		cache := caches[name]
+		if cache == nil {
+			cache = &Cache{Name: name, Volume: vol.Name, Size: -1, InUse: -1}
+			caches[name] = cache
+		}
+		cache.Exists = true
+		cache.Created, _ = time.Parse(time.RFC3339, vol.CreatedAt)
+		if vol.UsageData != nil {
+			cache.Size = vol.UsageData.Size
+			cache.InUse = vol.UsageData.RefCount
+		}
+	}
+
+	result := make([]Cache, 0, len(caches))
+	for _, cache := range caches {
+		result = append(result, *cache)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// ClearCache empties a cache. Unused volumes are removed; volumes mounted in
+// a project container are emptied in place.
+func (c *Client) ClearCache(name string) error {
+	err := c.clearCache(name)
+	audit.Log(audit.Event{
+		Type:    audit.CacheClear,
+		Details: map[string]string{"cache": name},
+		Error:   audit.ErrorString(err),
+	})
+	return err
+}
+
+func (c *Client) clearCache(name string) error {
+	vol := CacheVolume(name)
+
+	err := c.cli.VolumeRemove(c.ctx, vol, false)
+	if err == nil || errdefs.IsNotFound(err) {
+		return nil
+	}
+	if !errdefs.IsConflict(err) {
+		return fmt.Errorf("failed to remove volume %s: %w", vol, err)
+	}
+
+	if err := c.ensureImageRef(SnapshotImage); err != nil {
+		return err
+	}
+
+	resp, err := c.cli.ContainerCreate(c.ctx,
+		&container.Config{
+			Image:  SnapshotImage,
+			Cmd:    []string{"find", "/cache", "-mindepth", "1", "-delete"},
+			Labels: managedLabels(map[string]string{helperLabel: "cache"}),
+		},
+		&container.HostConfig{Mounts: []mount.Mount{{Type: mount.TypeVolume, Source: vol, Target: "/cache"}}},
+		nil, nil, "")
+	if err != nil {
+		return fmt.Errorf("failed to create helper container: %w", err)
+	}
+	defer c.cli.ContainerRemove(c.ctx, resp.ID, container.RemoveOptions{Force: true})
+
+	if err := c.cli.ContainerStart(c.ctx, resp.ID, container.StartOptions{}); err != nil {
+		return fmt.Errorf("failed to start helper container: %w", err)
+	}
+	if err := c.waitContainer(resp.ID); err != nil {
+		return fmt.Errorf("failed to empty volume %s: %w", vol, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/mount"
+)
+
+func TestCacheMounts(t *testing.T) {
+	caches := map[string]string{
+		"npm":    "~/.npm",
+		"pip":    "",
+		"gradle": "/opt/gradle/caches",
+	}
+
+	mounts := cacheMounts(caches)
+	if len(mounts) != 2 {
+		t.Fatalf("Expected 2 mounts for the enabled caches, got %+v", mounts)
+	}
+
+	want := []struct{ source, target, label string }{
+		{"rize-cache-gradle", "/opt/gradle/caches", "gradle"},
+		{"rize-cache-npm", "/home/agent/.npm", "npm"},
+	}
+	for i, m := range mounts {
+		if m.Type != mount.TypeVolume || m.Source != want[i].source || m.Target != want[i].target {
+			t.Errorf("Expected mount %s:%s, got %+v", want[i].source, want[i].target, m)
+		}
+		if m.VolumeOptions == nil || m.VolumeOptions.Labels[CacheLabel] != want[i].label {
+			t.Errorf("Expected volume %s to be labelled %s", m.Source, want[i].label)
+		}
+	}
+
+	if dirs := cacheDirs(caches); dirs != "/opt/gradle/caches:/home/agent/.npm" {
+		t.Errorf("cacheDirs() = %q", dirs)
+	}
+	if dirs := cacheDirs(nil); dirs != "" {
+		t.Errorf("Expected no cache dirs without caches, got %q", dirs)
+	}
+}
@@ -2,7 +2,12 @@ package docker
 
 import (
 	"context"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
 
+	"github.com/alienxp03/rize/internal/ui"
 	"github.com/docker/docker/client"
 )
 
@@ -12,13 +17,31 @@ type Client struct {
 	ctx context.Context
 }
 
-// NewClient creates a new Docker client
+// NewClient creates a new Docker client. At trace level every API call is
+// logged with its status and duration.
 func NewClient() (*Client, error) {
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	opts := []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}
+
+	cli, err := client.NewClientWithOpts(opts...)
 	if err != nil {
 		return nil, err
 	}
 
+	if ui.Enabled(ui.LevelTrace) {
+		// The transport configured for DOCKER_HOST is wrapped in a new
+		// client; its scheme is lost with the transport and set again
+		httpClient := cli.HTTPClient()
+		httpClient.Transport = tracingTransport{httpClient.Transport}
+		scheme := "http"
+		if os.Getenv(client.EnvOverrideCertPath) != "" {
+			scheme = "https"
+		}
+		if cli, err = client.NewClientWithOpts(append(opts, client.WithHTTPClient(httpClient), client.WithScheme(scheme))...); err != nil {
+			return nil, err
+		}
+		ui.Trace("docker host %s", cli.DaemonHost())
+	}
+
 	return &Client{
 		cli: cli,
 		ctx: context.Background(),
@@ -29,3 +52,26 @@ func NewClient() (*Client, error) {
 func (c *Client) Close() error {
 	return c.cli.Close()
 }
+
+// tracingTransport logs the Docker API calls going through it
+type tracingTransport struct {
+	next http.RoundTripper
+}
+
+func (t tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	call := req.Method + " " + req.URL.Path
+	if query, err := url.QueryUnescape(req.URL.RawQuery); err == nil && query != "" {
+		call += "?" + query
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Microsecond)
+	if err != nil {
+		ui.Trace("docker %s: %v (%s)", call, err, elapsed)
+		return nil, err
+	}
+	// Streams (logs, attach, pulls) are timed until their headers arrive
+	ui.Trace("docker %s: %s (%s)", call, resp.Status, elapsed)
+	return resp, nil
+}
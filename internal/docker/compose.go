@@ -2,10 +2,9 @@ package docker
 
 import (
 	"fmt"
-	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/alienxp03/rize/internal/config"
 	"gopkg.in/yaml.v3"
@@ -13,15 +12,18 @@ import (
 
 // ComposeFile represents a docker-compose.yml structure
 type ComposeFile struct {
-	Version  string                       `yaml:"version,omitempty"`
-	Services map[string]ComposeService    `yaml:"services"`
-	Networks map[string]ComposeNetwork    `yaml:"networks"`
-	Volumes  map[string]ComposeVolume     `yaml:"volumes"`
+	Name     string                    `yaml:"name,omitempty"`
+	Version  string                    `yaml:"version,omitempty"`
+	Services map[string]ComposeService `yaml:"services"`
+	Networks map[string]ComposeNetwork `yaml:"networks"`
+	Volumes  map[string]ComposeVolume  `yaml:"volumes"`
 }
 
 type ComposeService struct {
 	Image       string              `yaml:"image"`
 	Command     []string            `yaml:"command,omitempty"`
+	Restart     string              `yaml:"restart,omitempty"`
+	DependsOn   []string            `yaml:"depends_on,omitempty"`
 	Ports       []string            `yaml:"ports,omitempty"`
 	Environment map[string]string   `yaml:"environment,omitempty"`
 	Volumes     []string            `yaml:"volumes,omitempty"`
@@ -45,17 +47,19 @@ type ComposeVolume struct {
 	Driver string `yaml:"driver,omitempty"`
 }
 
-// GenerateComposeFile generates a docker-compose.yml from config
-func GenerateComposeFile(cfg *config.Config) (*ComposeFile, error) {
+// GenerateComposeFile generates a docker-compose.yml from config for the
+// given service project
+func GenerateComposeFile(cfg *config.Config, project ServiceProject) (*ComposeFile, error) {
 	compose := &ComposeFile{
+		Name:     project.Name,
 		Services: make(map[string]ComposeService),
 		Networks: make(map[string]ComposeNetwork),
 		Volumes:  make(map[string]ComposeVolume),
 	}
 
 	// Add network
-	compose.Networks[cfg.Network.Name] = ComposeNetwork{
-		Name:   cfg.Network.Name,
+	compose.Networks[project.Network] = ComposeNetwork{
+		Name:   project.Network,
 		Driver: cfg.Network.Driver,
 	}
 
@@ -65,13 +69,24 @@ func GenerateComposeFile(cfg *config.Config) (*ComposeFile, error) {
 			continue
 		}
 
+		initVols, err := initVolumes(name, svc)
+		if err != nil {
+			return nil, err
+		}
+
 		composeSvc := ComposeService{
 			Image:       svc.Image,
 			Command:     svc.Command,
+			Restart:     svc.Restart,
+			DependsOn:   svc.DependsOn,
 			Ports:       svc.Ports,
 			Environment: svc.Environment,
-			Volumes:     svc.Volumes,
-			Networks:    []string{cfg.Network.Name},
+			Volumes:     append(expandVolumes(svc.Volumes), initVols...),
+			Networks:    []string{project.Network},
+		}
+
+		if project.Scoped {
+			composeSvc.Ports = scopedPorts(svc.Ports)
 		}
 
 		if svc.HealthCheck != nil {
@@ -84,9 +99,18 @@ func GenerateComposeFile(cfg *config.Config) (*ComposeFile, error) {
 		}
 
 		compose.Services[name] = composeSvc
+
+		// Compose rejects named volumes that are not declared, and services
+		// from .rize.yml or an imported compose file use their own
+		for _, vol := range composeSvc.Volumes {
+			if source := namedVolumeSource(vol); source != "" {
+				compose.Volumes[source] = ComposeVolume{}
+			}
+		}
 	}
 
-	// Add volumes
+	// Add volumes. Compose prefixes named volumes with the project name, so
+	// each scoped project gets its own data.
 	for _, vol := range cfg.Volumes {
 		compose.Volumes[vol] = ComposeVolume{}
 	}
@@ -94,146 +118,50 @@ func GenerateComposeFile(cfg *config.Config) (*ComposeFile, error) {
 	return compose, nil
 }
 
-// WriteComposeFile writes the compose file to disk
-func WriteComposeFile(compose *ComposeFile, path string) error {
-	data, err := yaml.Marshal(compose)
-	if err != nil {
-		return fmt.Errorf("failed to marshal compose file: %w", err)
-	}
-
-	if err := os.WriteFile(path, data, 0644); err != nil {
-		return fmt.Errorf("failed to write compose file: %w", err)
+// namedVolumeSource returns the volume name of a "source:target[:mode]"
+// mount of a named volume, "" for bind mounts and anonymous volumes
+func namedVolumeSource(vol string) string {
+	source, _, ok := strings.Cut(vol, ":")
+	if !ok || source == "" || strings.HasPrefix(source, "/") || strings.HasPrefix(source, ".") || strings.HasPrefix(source, "~") {
+		return ""
 	}
-
-	return nil
-}
-
-// GetComposePath returns the path to the docker-compose.yml file
-func GetComposePath() (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get home directory: %w", err)
-	}
-
-	configDir := filepath.Join(home, ".config", "rize")
-	composePath := filepath.Join(configDir, "docker-compose.yml")
-
-	return composePath, nil
-}
-
-// EnsureCompose generates and writes the docker-compose.yml file
-func EnsureCompose(cfg *config.Config) error {
-	composePath, err := GetComposePath()
-	if err != nil {
-		return err
-	}
-
-	// Ensure directory exists
-	if err := os.MkdirAll(filepath.Dir(composePath), 0755); err != nil {
-		return fmt.Errorf("failed to create config directory: %w", err)
-	}
-
-	compose, err := GenerateComposeFile(cfg)
-	if err != nil {
-		return err
-	}
-
-	return WriteComposeFile(compose, composePath)
+	return source
 }
 
-// ComposeUp starts the services
-func ComposeUp(cfg *config.Config) error {
-	if err := EnsureCompose(cfg); err != nil {
-		return err
+// expandVolumes expands a leading ~ in bind mount sources to the home directory
+func expandVolumes(volumes []string) []string {
+	if volumes == nil {
+		return nil
 	}
 
-	composePath, err := GetComposePath()
-	if err != nil {
-		return err
-	}
-
-	cmd := exec.Command("docker", "compose", "-f", composePath, "up", "-d")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	return cmd.Run()
-}
-
-// ComposeUpQuiet starts services without emitting compose output
-func ComposeUpQuiet(cfg *config.Config) error {
-	if err := EnsureCompose(cfg); err != nil {
-		return err
-	}
-
-	composePath, err := GetComposePath()
-	if err != nil {
-		return err
-	}
-
-	cmd := exec.Command("docker", "compose", "-f", composePath, "up", "-d")
-	cmd.Stdout = io.Discard
-	cmd.Stderr = io.Discard
-
-	return cmd.Run()
-}
-
-// ComposeDown stops the services
-func ComposeDown() error {
-	composePath, err := GetComposePath()
+	home, err := os.UserHomeDir()
 	if err != nil {
-		return err
+		return volumes
 	}
 
-	cmd := exec.Command("docker", "compose", "-f", composePath, "down")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	return cmd.Run()
-}
-
-// ComposePs lists running services
-func ComposePs() error {
-	composePath, err := GetComposePath()
-	if err != nil {
-		return err
+	expanded := make([]string, 0, len(volumes))
+	for _, vol := range volumes {
+		if vol == "~" || strings.HasPrefix(vol, "~/") {
+			vol = filepath.Join(home, strings.TrimPrefix(vol, "~"))
+		}
+		expanded = append(expanded, vol)
 	}
 
-	cmd := exec.Command("docker", "compose", "-f", composePath, "ps")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	return cmd.Run()
+	return expanded
 }
 
-// ComposeLogs shows service logs
-func ComposeLogs(follow bool) error {
-	composePath, err := GetComposePath()
+// ExportCompose returns a docker-compose.yml equivalent to the services rize
+// runs for the project, for use with `docker compose`
+func ExportCompose(cfg *config.Config, project ServiceProject) ([]byte, error) {
+	compose, err := GenerateComposeFile(cfg, project)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	args := []string{"compose", "-f", composePath, "logs"}
-	if follow {
-		args = append(args, "-f")
-	}
-
-	cmd := exec.Command("docker", args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	return cmd.Run()
-}
-
-// ComposeRestart restarts services
-func ComposeRestart() error {
-	composePath, err := GetComposePath()
+	data, err := yaml.Marshal(compose)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to marshal compose file: %w", err)
 	}
 
-	cmd := exec.Command("docker", "compose", "-f", composePath, "restart")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	return cmd.Run()
+	return data, nil
 }
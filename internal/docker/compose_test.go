@@ -1,6 +1,7 @@
 package docker
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/alienxp03/rize/internal/config"
@@ -9,7 +10,7 @@ import (
 func TestGenerateComposeFile(t *testing.T) {
 	cfg := config.DefaultConfig()
 
-	compose, err := GenerateComposeFile(cfg)
+	compose, err := GenerateComposeFile(cfg, sharedProject(t, cfg))
 	if err != nil {
 		t.Fatalf("Failed to generate compose file: %v", err)
 	}
@@ -38,6 +39,27 @@ func TestGenerateComposeFile(t *testing.T) {
 	}
 }
 
+func TestGenerateComposeFileDeclaresServiceVolumes(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Services["api"] = config.Service{
+		Enabled: true,
+		Image:   "example/api",
+		Volumes: []string{"api-data:/data", "/srv/api:/srv:ro", "~/.cache/api:/cache", "/tmp/scratch"},
+	}
+
+	compose, err := GenerateComposeFile(cfg, sharedProject(t, cfg))
+	if err != nil {
+		t.Fatalf("Failed to generate compose file: %v", err)
+	}
+
+	if _, ok := compose.Volumes["api-data"]; !ok {
+		t.Errorf("Expected the api-data volume to be declared, got %v", compose.Volumes)
+	}
+	if len(compose.Volumes) != 4 {
+		t.Errorf("Expected only named volumes to be declared, got %v", compose.Volumes)
+	}
+}
+
 func TestGenerateComposeFileWithDisabledService(t *testing.T) {
 	cfg := config.DefaultConfig()
 
@@ -46,7 +68,7 @@ func TestGenerateComposeFileWithDisabledService(t *testing.T) {
 	svc.Enabled = false
 	cfg.Services["postgres"] = svc
 
-	compose, err := GenerateComposeFile(cfg)
+	compose, err := GenerateComposeFile(cfg, sharedProject(t, cfg))
 	if err != nil {
 		t.Fatalf("Failed to generate compose file: %v", err)
 	}
@@ -61,3 +83,69 @@ func TestGenerateComposeFileWithDisabledService(t *testing.T) {
 		t.Error("Postgres should not be in compose file when disabled")
 	}
 }
+
+func TestGenerateComposeFileForScopedProject(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.ServiceScope = config.ServiceScopeProject
+
+	project, err := ResolveServiceProject(cfg, "/work/My App")
+	if err != nil {
+		t.Fatalf("Failed to resolve service project: %v", err)
+	}
+
+	if !project.Scoped || project.Name != projectContainerName("/work/My App") || project.Network != project.Name {
+		t.Errorf("Unexpected service project: %+v", project)
+	}
+
+	compose, err := GenerateComposeFile(cfg, project)
+	if err != nil {
+		t.Fatalf("Failed to generate compose file: %v", err)
+	}
+
+	if compose.Name != project.Name {
+		t.Errorf("Expected compose project name %s, got %s", project.Name, compose.Name)
+	}
+	if _, exists := compose.Networks[project.Network]; !exists {
+		t.Errorf("Network %s not found in compose file", project.Network)
+	}
+	if got := compose.Services["postgres"].Networks; !reflect.DeepEqual(got, []string{project.Network}) {
+		t.Errorf("Expected postgres on the project network, got %v", got)
+	}
+
+	// Host ports are left to docker so projects do not collide
+	if got := compose.Services["mitmproxy"].Ports; !reflect.DeepEqual(got, []string{"8080", "8081"}) {
+		t.Errorf("Unexpected scoped ports: %v", got)
+	}
+}
+
+func TestResolveServiceProjectShared(t *testing.T) {
+	cfg := config.DefaultConfig()
+
+	project := sharedProject(t, cfg)
+	if project.Scoped || project.Name != SharedProjectName || project.Network != cfg.Network.Name {
+		t.Errorf("Unexpected shared service project: %+v", project)
+	}
+
+	cfg.ServiceScope = "bogus"
+	if _, err := ResolveServiceProject(cfg, "/work/app"); err == nil {
+		t.Error("Expected an error for an invalid service scope")
+	}
+}
+
+func TestScopedPorts(t *testing.T) {
+	got := scopedPorts([]string{"8381:3000", "127.0.0.1:8080:8080", "9000"})
+	want := []string{"3000", "127.0.0.1::8080", "9000"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("scopedPorts = %v, want %v", got, want)
+	}
+}
+
+func sharedProject(t *testing.T, cfg *config.Config) ServiceProject {
+	t.Helper()
+
+	project, err := ResolveServiceProject(cfg, "/work/app")
+	if err != nil {
+		t.Fatalf("Failed to resolve service project: %v", err)
+	}
+	return project
+}
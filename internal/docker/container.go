@@ -9,10 +9,18 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/alienxp03/rize/internal/audit"
 	"github.com/alienxp03/rize/internal/config"
+	"github.com/alienxp03/rize/internal/recording"
+	"github.com/alienxp03/rize/internal/redact"
+	"github.com/alienxp03/rize/internal/session"
+	"github.com/alienxp03/rize/internal/ui"
+	"github.com/alienxp03/rize/internal/usage"
+	"github.com/alienxp03/rize/internal/version"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/image"
@@ -24,35 +32,44 @@ import (
 )
 
 const (
-	ImageName       = "alienxp03/rize:latest"
+	// ImageRepository is the repository of the rize image
+	ImageRepository = "alienxp03/rize"
 	ContainerHome   = "/home/agent"
+	ContainerUser   = "agent"
 	ClaudeConfigDir = "/home/agent/.agents/claude"
-)
 
-var defaultContainerCmd = []string{"sleep", "infinity"}
+	// ProjectLabel marks project containers with the project they belong to
+	ProjectLabel = "rize.project"
+)
 
-func (c *Client) isComposeServiceRunning(networkName, serviceName string) bool {
-	args := filters.NewArgs()
-	args.Add("status", "running")
-	args.Add("label", fmt.Sprintf("com.docker.compose.service=%s", serviceName))
+// ImageName is the rize image: RIZE_IMAGE, or the image tag matching the
+// binary version, so a binary and its image are always updated together
+var ImageName = defaultImageName()
 
-	if networkName != "" {
-		args.Add("network", networkName)
-		containers, err := c.cli.ContainerList(c.ctx, container.ListOptions{Filters: args})
-		if err == nil && len(containers) > 0 {
-			return true
-		}
-		args = filters.NewArgs()
-		args.Add("status", "running")
-		args.Add("label", fmt.Sprintf("com.docker.compose.service=%s", serviceName))
+func defaultImageName() string {
+	if image := os.Getenv("RIZE_IMAGE"); image != "" {
+		return image
 	}
+	return ImageRef(version.Version)
+}
 
-	containers, err := c.cli.ContainerList(c.ctx, container.ListOptions{Filters: args})
-	if err != nil {
-		return false
-	}
+// ImageRef returns the rize image of a binary version
+func ImageRef(v string) string {
+	return ImageRepository + ":" + version.ImageTag(v)
+}
+
+var defaultContainerCmd = []string{"sleep", "infinity"}
+
+// isComposeServiceRunning reports whether a service of the given compose
+// project is running
+func (c *Client) isComposeServiceRunning(project ServiceProject, serviceName string) bool {
+	return c.findComposeServiceContainerID(project, serviceName) != ""
+}
 
-	return len(containers) > 0
+// WorkspaceDir returns where the project at projectDir is mounted in the
+// project container
+func WorkspaceDir(projectDir string) string {
+	return fmt.Sprintf("/workspace/%s", filepath.Base(projectDir))
 }
 
 func projectContainerName(cwd string) string {
@@ -92,33 +109,135 @@ func shortHash(value string) string {
 	return hex.EncodeToString(sum[:])[:6]
 }
 
+// dataMounts returns the mounts of rize data in the project container. Only
+// ~/.rize/container, holding the shell history and the audit spool, is
+// writable; the rest of ~/.rize (traffic, recordings, proxy addons, the
+// install record) stays out of reach of the agent. The audit log, the
+// redaction rules and ~/.rize/config.yml are mounted read-only.
+func dataMounts() []mount.Mount {
+	containerDir, err := config.ContainerDataDir()
+	if err != nil {
+		ui.Warning("Failed to locate the rize data directory: %v", err)
+		return nil
+	}
+	if err := os.MkdirAll(containerDir, 0700); err != nil {
+		ui.Warning("Failed to create %s: %v", containerDir, err)
+		return nil
+	}
+	migrateHistory(containerDir)
+
+	target := filepath.Join(ContainerHome, ".local/share/rize")
+	mounts := []mount.Mount{{
+		Type:   mount.TypeBind,
+		Source: containerDir,
+		Target: target,
+	}}
+
+	if err := audit.Prepare(); err != nil {
+		ui.Warning("Failed to prepare the audit log: %v", err)
+	} else if auditPath, err := audit.Path(); err == nil {
+		// Create the mount point as the host user, docker would create it
+		// as root
+		mountPoint := filepath.Join(containerDir, filepath.Base(auditPath))
+		if file, err := os.OpenFile(mountPoint, os.O_CREATE|os.O_RDONLY, 0600); err == nil {
+			file.Close()
+		}
+		mounts = append(mounts, mount.Mount{
+			Type:     mount.TypeBind,
+			Source:   auditPath,
+			Target:   filepath.Join(target, filepath.Base(auditPath)),
+			ReadOnly: true,
+		})
+	}
+
+	// Environment for the entrypoint, see the API keys section of the README
+	envPath := filepath.Join(filepath.Dir(containerDir), "config.yml")
+	if _, err := os.Stat(envPath); err == nil {
+		mounts = append(mounts, mount.Mount{
+			Type:     mount.TypeBind,
+			Source:   envPath,
+			Target:   filepath.Join(ContainerHome, ".rize", "config.yml"),
+			ReadOnly: true,
+		})
+	}
+
+	if rulesPath, err := redact.RulesPath(); err == nil {
+		if _, err := os.Stat(rulesPath); err == nil {
+			mounts = append(mounts, mount.Mount{
+				Type:     mount.TypeBind,
+				Source:   rulesPath,
+				Target:   containerRedactRules,
+				ReadOnly: true,
+			})
+		}
+	}
+	return mounts
+}
+
+// containerRedactRules is where rize-audit reads the redaction rules
+const containerRedactRules = "/etc/rize/redact.json"
+
+// migrateHistory moves the shell history of earlier versions, kept in
+// ~/.rize, to the container data directory
+func migrateHistory(containerDir string) {
+	oldPath := filepath.Join(filepath.Dir(containerDir), "zsh_history")
+	newPath := filepath.Join(containerDir, "zsh_history")
+	if _, err := os.Stat(newPath); err == nil {
+		return
+	}
+	if err := os.Rename(oldPath, newPath); err != nil && !os.IsNotExist(err) {
+		ui.Debug("Failed to move %s: %v", oldPath, err)
+	}
+}
+
 // RunContainer runs the rize container with the given command
 func (c *Client) RunContainer(cfg *config.Config, cmd []string, interactive bool) error {
-	// Ensure image exists
-	if err := c.ensureImage(); err != nil {
+	project, err := CurrentServiceProject(cfg)
+	if err != nil {
 		return err
 	}
 
 	// Ensure network exists
-	if err := c.ensureNetwork(cfg.Network); err != nil {
+	if err := c.ensureNetwork(config.NetworkConfig{Name: project.Network, Driver: cfg.Network.Driver}); err != nil {
 		return err
 	}
 
 	// Build container config
-	containerName, workspaceDir, containerConfig, hostConfig, networkConfig := c.buildContainerConfigs(cfg)
+	containerName, workspaceDir, containerConfig, hostConfig, networkConfig := c.buildContainerConfigs(cfg, project)
 
-	containerID, err := c.ensureProjectContainer(containerName, containerConfig, hostConfig, networkConfig)
+	// Ensure image exists
+	image, err := c.projectImage(cfg, containerName)
 	if err != nil {
 		return err
 	}
+	containerConfig.Image = image
+	containerConfig.Entrypoint = projectEntrypoint(image)
 
-	if err := c.startContainerIfNeeded(containerID); err != nil {
+	containerID, created, err := c.ensureProjectContainer(containerName, containerConfig, hostConfig, networkConfig)
+	if err != nil {
 		return err
 	}
 
-	c.ensureConnectedToServiceNetworks(containerID, cfg)
+	started, err := c.startContainerIfNeeded(containerID)
+	if err != nil {
+		return err
+	}
 
-	return c.execInContainer(containerID, workspaceDir, cfg, cmd, interactive)
+	c.ensureConnectedToServiceNetworks(containerID, cfg, project)
+
+	env := c.containerRemoteEnv(containerID, cfg.DevContainer)
+	if created {
+		if err := c.runLifecycle(containerID, workspaceDir, image, "postCreateCommand", devContainerSetup(cfg.DevContainer), env); err != nil {
+			return err
+		}
+	}
+	if started && cfg.DevContainer != nil {
+		if err := c.runLifecycle(containerID, workspaceDir, image, "postStartCommand", cfg.DevContainer.PostStartCommand, env); err != nil {
+			return err
+		}
+	}
+
+	return c.execInContainer(containerID, workspaceDir, image, cfg, project, cmd, interactive)
 }
 
 func (c *Client) ensureNetwork(netCfg config.NetworkConfig) error {
@@ -137,6 +256,7 @@ func (c *Client) ensureNetwork(netCfg config.NetworkConfig) error {
 
 	_, err = c.cli.NetworkCreate(c.ctx, netCfg.Name, network.CreateOptions{
 		Driver: netCfg.Driver,
+		Labels: managedLabels(nil),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create network %s: %w", netCfg.Name, err)
@@ -152,20 +272,12 @@ func (c *Client) ensureImage() error {
 		return nil
 	}
 
-	// Pull image
-	reader, err := c.cli.ImagePull(c.ctx, ImageName, image.PullOptions{})
-	if err != nil {
-		return fmt.Errorf("failed to pull image: %w", err)
-	}
-	defer reader.Close()
-
-	// Wait for pull to complete
-	_, err = io.Copy(os.Stdout, reader)
-	return err
+	ui.Info("Pulling %s...", ImageName)
+	return c.pullImage(ImageName, true)
 }
 
 // buildContainerConfigs builds container, host, and network configurations
-func (c *Client) buildContainerConfigs(cfg *config.Config) (string, string, *container.Config, *container.HostConfig, *network.NetworkingConfig) {
+func (c *Client) buildContainerConfigs(cfg *config.Config, project ServiceProject) (string, string, *container.Config, *container.HostConfig, *network.NetworkingConfig) {
 	// Get current directory
 	cwd, _ := os.Getwd()
 	absPath, err := filepath.Abs(cwd)
@@ -175,7 +287,7 @@ func (c *Client) buildContainerConfigs(cfg *config.Config) (string, string, *con
 
 	projectName := filepath.Base(absPath)
 	projectDir := projectName
-	workspaceDir := fmt.Sprintf("/workspace/%s", projectDir)
+	workspaceDir := WorkspaceDir(absPath)
 	containerName := projectContainerName(absPath)
 
 	// Build environment variables
@@ -190,33 +302,35 @@ func (c *Client) buildContainerConfigs(cfg *config.Config) (string, string, *con
 		fmt.Sprintf("CLAUDE_CONFIG_DIR=%s", ClaudeConfigDir),
 	}
 
-	// Add custom environment variables from config
-	for key, value := range cfg.Environment {
-		if value != "" {
+	// Add custom environment variables from config, in a stable order so
+	// the config hash does not change between runs
+	keys := make([]string, 0, len(cfg.Environment))
+	for key := range cfg.Environment {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if value := cfg.Environment[key]; value != "" {
 			env = append(env, fmt.Sprintf("%s=%s", key, value))
 		}
 	}
 
 	// Add service URLs for enabled services
-	for name, svc := range cfg.Services {
-		if svc.Enabled {
-			switch name {
-			case "postgres":
-				user := svc.Environment["POSTGRES_USER"]
-				password := svc.Environment["POSTGRES_PASSWORD"]
-				db := svc.Environment["POSTGRES_DB"]
-				env = append(env, fmt.Sprintf("DATABASE_URL=postgresql://%s:%s@%s:5432/%s", user, password, name, db))
-			case "redis":
-				env = append(env, fmt.Sprintf("REDIS_URL=redis://%s:6379", name))
-			case "playwright":
-				env = append(env, fmt.Sprintf("PLAYWRIGHT_URL=http://%s:3000", name))
-			case "mitmproxy":
-				if c.isComposeServiceRunning(cfg.Network.Name, name) {
-					env = append(env, fmt.Sprintf("HTTP_PROXY=http://%s:8080", name))
-					env = append(env, fmt.Sprintf("HTTPS_PROXY=http://%s:8080", name))
-					env = append(env, "NO_PROXY=localhost,127.0.0.1")
-				}
+	for _, name := range cfg.ServiceNames() {
+		svc := cfg.Services[name]
+		if !svc.Enabled {
+			continue
+		}
+		if name == "mitmproxy" {
+			if c.isComposeServiceRunning(project, name) {
+				env = append(env, fmt.Sprintf("HTTP_PROXY=http://%s:8080", name))
+				env = append(env, fmt.Sprintf("HTTPS_PROXY=http://%s:8080", name))
+				env = append(env, "NO_PROXY=localhost,127.0.0.1")
 			}
+			continue
+		}
+		if key, url := ServiceURL(name, svc); key != "" {
+			env = append(env, fmt.Sprintf("%s=%s", key, url))
 		}
 	}
 
@@ -231,8 +345,11 @@ func (c *Client) buildContainerConfigs(cfg *config.Config) (string, string, *con
 		// Agent volume
 		{
 			Type:   mount.TypeVolume,
-			Source: "rize-agents",
+			Source: AgentVolume,
 			Target: "/home/agent/.agents",
+			VolumeOptions: &mount.VolumeOptions{
+				Labels: managedLabels(nil),
+			},
 		},
 	}
 
@@ -261,38 +378,32 @@ func (c *Client) buildContainerConfigs(cfg *config.Config) (string, string, *con
 		})
 	}
 
-	// Optional mounts
-	optionalMounts := map[string]string{
-		".config/opencode": ".config/opencode",
-		".netrc":           ".netrc",
-		".gitconfig":       ".gitconfig",
-		".env":             ".env",
-	}
+	// Optional mounts, relative to the home directory on both sides
+	optionalMounts := []string{".config/opencode", ".netrc", ".gitconfig", ".env"}
 
-	for hostSuffix, containerSuffix := range optionalMounts {
-		hostPath := filepath.Join(home, hostSuffix)
+	for _, suffix := range optionalMounts {
+		hostPath := filepath.Join(home, suffix)
 		if _, err := os.Stat(hostPath); err == nil {
 			readOnly := true
-			if strings.Contains(hostSuffix, "opencode") {
+			if strings.Contains(suffix, "opencode") {
 				readOnly = false
 			}
 			mounts = append(mounts, mount.Mount{
 				Type:     mount.TypeBind,
 				Source:   hostPath,
-				Target:   filepath.Join(ContainerHome, containerSuffix),
+				Target:   filepath.Join(ContainerHome, suffix),
 				ReadOnly: readOnly,
 			})
 		}
 	}
 
-	// .rize directory
-	rizePath := filepath.Join(home, ".rize")
-	os.MkdirAll(rizePath, 0755)
-	mounts = append(mounts, mount.Mount{
-		Type:   mount.TypeBind,
-		Source: rizePath,
-		Target: filepath.Join(ContainerHome, ".local/share/rize"),
-	})
+	mounts = append(mounts, dataMounts()...)
+
+	// Package-manager caches shared by all projects
+	mounts = append(mounts, cacheMounts(cfg.Caches)...)
+	if dirs := cacheDirs(cfg.Caches); dirs != "" {
+		env = append(env, fmt.Sprintf("RIZE_CACHE_DIRS=%s", dirs))
+	}
 
 	// Docker socket
 	if runtime.GOOS != "windows" {
@@ -320,6 +431,7 @@ func (c *Client) buildContainerConfigs(cfg *config.Config) (string, string, *con
 	// Container config
 	containerConfig := &container.Config{
 		Image:        ImageName,
+		Labels:       managedLabels(map[string]string{ProjectLabel: projectName}),
 		Cmd:          defaultContainerCmd,
 		Env:          env,
 		WorkingDir:   workspaceDir,
@@ -335,27 +447,51 @@ func (c *Client) buildContainerConfigs(cfg *config.Config) (string, string, *con
 	hostConfig := &container.HostConfig{
 		Mounts:      mounts,
 		AutoRemove:  false,
-		NetworkMode: container.NetworkMode(cfg.Network.Name),
+		NetworkMode: container.NetworkMode(project.Network),
 	}
 
 	// Network config
 	networkConfig := &network.NetworkingConfig{}
 
+	applyDevContainer(cfg.DevContainer, containerConfig, hostConfig)
+
 	return containerName, workspaceDir, containerConfig, hostConfig, networkConfig
 }
 
-func (c *Client) ensureProjectContainer(name string, containerConfig *container.Config, hostConfig *container.HostConfig, networkConfig *network.NetworkingConfig) (string, error) {
+// ensureProjectContainer returns the project container, creating it when
+// it does not exist, is stopped, or was created from other settings and no
+// commands are running in it. It reports whether the container was created.
+func (c *Client) ensureProjectContainer(name string, containerConfig *container.Config, hostConfig *container.HostConfig, networkConfig *network.NetworkingConfig) (string, bool, error) {
+	hash := projectConfigHash(containerConfig, hostConfig)
+	containerConfig.Labels[ConfigHashLabel] = hash
+
 	inspect, err := c.cli.ContainerInspect(c.ctx, name)
 	if err == nil {
+		reason := "stale"
 		if inspect.State != nil && inspect.State.Running {
-			return inspect.ID, nil
+			if inspect.Config.Labels[ConfigHashLabel] == hash {
+				return inspect.ID, false, nil
+			}
+			if len(inspect.ExecIDs) > 0 {
+				ui.Warning("Project container settings changed; they apply once all sessions in %s have exited", name)
+				return inspect.ID, false, nil
+			}
+			reason = "config changed"
 		}
 
-		if err := c.cli.ContainerRemove(c.ctx, inspect.ID, container.RemoveOptions{Force: true}); err != nil {
-			return "", fmt.Errorf("failed to remove existing container %s: %w", name, err)
+		err := c.cli.ContainerRemove(c.ctx, inspect.ID, container.RemoveOptions{Force: true})
+		audit.Log(audit.Event{
+			Type:      audit.ContainerRemove,
+			Project:   containerConfig.Labels[ProjectLabel],
+			Container: name,
+			Details:   map[string]string{"reason": reason},
+			Error:     audit.ErrorString(err),
+		})
+		if err != nil {
+			return "", false, fmt.Errorf("failed to remove existing container %s: %w", name, err)
 		}
 	} else if !dockerclient.IsErrNotFound(err) {
-		return "", fmt.Errorf("failed to inspect container %s: %w", name, err)
+		return "", false, fmt.Errorf("failed to inspect container %s: %w", name, err)
 	}
 
 	resp, err := c.cli.ContainerCreate(
@@ -370,39 +506,110 @@ func (c *Client) ensureProjectContainer(name string, containerConfig *container.
 		if strings.Contains(err.Error(), "Conflict") {
 			inspect, inspectErr := c.cli.ContainerInspect(c.ctx, name)
 			if inspectErr == nil {
-				return inspect.ID, nil
+				return inspect.ID, false, nil
 			}
 		}
-		return "", fmt.Errorf("failed to create container: %w", err)
+		audit.Log(audit.Event{
+			Type:      audit.ContainerCreate,
+			Project:   containerConfig.Labels[ProjectLabel],
+			Container: name,
+			Image:     containerConfig.Image,
+			Error:     err.Error(),
+		})
+		return "", false, fmt.Errorf("failed to create container: %w", err)
+	}
+
+	audit.Log(audit.Event{
+		Type:      audit.ContainerCreate,
+		Project:   containerConfig.Labels[ProjectLabel],
+		Container: name,
+		Image:     containerConfig.Image,
+	})
+
+	return resp.ID, true, nil
+}
+
+// volatileEnv are project container variables that change between
+// invocations without needing a new container: the terminal, the SSH agent
+// socket and the proxy, which every exec sets again with its session
+var volatileEnv = map[string]bool{
+	"TERM":          true,
+	"COLORTERM":     true,
+	"SSH_AUTH_SOCK": true,
+	"HTTP_PROXY":    true,
+	"HTTPS_PROXY":   true,
+	"NO_PROXY":      true,
+	"http_proxy":    true,
+	"https_proxy":   true,
+	"no_proxy":      true,
+}
+
+// projectConfigHash hashes the project container settings in a canonical
+// form: env and mounts are sorted, and volatile variables and the SSH agent
+// socket mount are left out.
+func projectConfigHash(containerConfig *container.Config, hostConfig *container.HostConfig) string {
+	config := *containerConfig
+	config.Env = nil
+	var sshAuthSock string
+	for _, entry := range containerConfig.Env {
+		key, value, _ := strings.Cut(entry, "=")
+		if key == "SSH_AUTH_SOCK" {
+			sshAuthSock = value
+		}
+		if !volatileEnv[key] {
+			config.Env = append(config.Env, entry)
+		}
 	}
+	sort.Strings(config.Env)
 
-	return resp.ID, nil
+	host := *hostConfig
+	host.Mounts = nil
+	for _, m := range hostConfig.Mounts {
+		if sshAuthSock == "" || m.Target != sshAuthSock {
+			host.Mounts = append(host.Mounts, m)
+		}
+	}
+	sort.SliceStable(host.Mounts, func(i, j int) bool {
+		return host.Mounts[i].Target < host.Mounts[j].Target
+	})
+
+	return configHash(&serviceSpec{Config: &config, Host: &host})
 }
 
-func (c *Client) startContainerIfNeeded(containerID string) error {
+// startContainerIfNeeded starts the container unless it is running and
+// reports whether it was started
+func (c *Client) startContainerIfNeeded(containerID string) (bool, error) {
 	inspect, err := c.cli.ContainerInspect(c.ctx, containerID)
 	if err != nil {
-		return fmt.Errorf("failed to inspect container %s: %w", containerID, err)
+		return false, fmt.Errorf("failed to inspect container %s: %w", containerID, err)
 	}
 
 	if inspect.State != nil && inspect.State.Running {
-		return nil
+		return false, nil
 	}
 
 	if err := c.cli.ContainerStart(c.ctx, containerID, container.StartOptions{}); err != nil {
-		return fmt.Errorf("failed to start container: %w", err)
+		return false, fmt.Errorf("failed to start container: %w", err)
 	}
 
-	return nil
+	return true, nil
 }
 
-func (c *Client) execInContainer(containerID, workspaceDir string, cfg *config.Config, cmd []string, interactive bool) error {
-	execEnv := c.buildExecEnv(cfg)
-	execCmd := []string{"/usr/local/bin/entrypoint.sh"}
-	execCmd = append(execCmd, cmd...)
+func (c *Client) execInContainer(containerID, workspaceDir, image string, cfg *config.Config, project ServiceProject, cmd []string, interactive bool) error {
+	sess := session.New(filepath.Base(workspaceDir), filepath.Base(cmd[0]), cmd[1:], image)
+
+	if err := sess.Save(); err != nil {
+		ui.Warning("Failed to save session metadata: %v", err)
+	}
 
+	execEnv := c.buildExecEnv(cfg, project, sess.ID)
+	execEnv = append(execEnv, c.containerRemoteEnv(containerID, cfg.DevContainer)...)
+	execEnv = append(execEnv,
+		fmt.Sprintf("RIZE_SESSION_ID=%s", sess.ID),
+		fmt.Sprintf("RIZE_AGENT=%s", sess.Agent),
+	)
 	execConfig := container.ExecOptions{
-		Cmd:          execCmd,
+		Cmd:          execCommand(image, cmd),
 		AttachStdout: true,
 		AttachStderr: true,
 		AttachStdin:  interactive,
@@ -416,10 +623,106 @@ func (c *Client) execInContainer(containerID, workspaceDir string, cfg *config.C
 		return fmt.Errorf("failed to create exec: %w", err)
 	}
 
-	return c.attachExec(resp.ID, execConfig.Tty, interactive)
+	execEvent := audit.Event{
+		Project:   sess.Project,
+		Session:   sess.ID,
+		Agent:     sess.Agent,
+		Container: shortID(containerID),
+		Image:     image,
+		Command:   cmd,
+		User:      ContainerUser,
+		Cwd:       workspaceDir,
+	}
+	startEvent := execEvent
+	startEvent.Type = audit.ExecStart
+	audit.Log(startEvent)
+
+	var rec *recording.Recorder
+	if cfg.Recording.Enabled {
+		rec = startRecording(sess, cmd)
+	}
+
+	exitCode, err := c.attachExec(resp.ID, execConfig.Tty, interactive, rec)
+	sess.Finish(exitCode)
+	_ = sess.Save()
+
+	if err := audit.MergeContainerEvents(); err != nil {
+		ui.Debug("%v", err)
+	}
+
+	endEvent := execEvent
+	endEvent.Type = audit.ExecEnd
+	endEvent.ExitCode = audit.ExitCode(exitCode)
+	endEvent.DurationMs = sess.Duration().Milliseconds()
+	endEvent.Error = audit.ErrorString(err)
+	audit.Log(endEvent)
+
+	if rec != nil {
+		if err := rec.Close(sess); err != nil {
+			ui.Warning("Failed to save recording: %v", err)
+		} else if dropped := rec.Dropped(); dropped > 0 {
+			ui.Warning("Recording %s is missing %d output events the disk could not keep up with", rec.Path(), dropped)
+		}
+		if _, err := recording.Prune(cfg.Recording.RetentionDays, cfg.Recording.MaxRecordings); err != nil {
+			ui.Warning("Failed to prune recordings: %v", err)
+		}
+	}
+	if _, err := usage.Prune(cfg.Usage.RetentionDays); err != nil {
+		ui.Warning("Failed to prune usage records: %v", err)
+	}
+
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("command exited with code %d", exitCode)
+	}
+
+	return nil
+}
+
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+// startRecording starts an asciicast recording for the session. Failures are
+// reported but never prevent the session from running.
+func startRecording(sess *session.Session, cmd []string) *recording.Recorder {
+	path, err := recording.Path(sanitizeContainerName(sess.Project), sess.ID)
+	if err != nil {
+		ui.Warning("Failed to start recording: %v", err)
+		return nil
+	}
+
+	width, height := 80, 24
+	if fd, isTerm := term.GetFdInfo(os.Stdout); isTerm {
+		if ws, err := term.GetWinsize(fd); err == nil && ws.Width > 0 && ws.Height > 0 {
+			width, height = int(ws.Width), int(ws.Height)
+		}
+	}
+
+	rec, err := recording.Start(path, recording.Header{
+		Width:   width,
+		Height:  height,
+		Command: strings.Join(cmd, " "),
+		Title:   fmt.Sprintf("%s (%s)", sess.Agent, sess.Project),
+		Env: map[string]string{
+			"TERM":  os.Getenv("TERM"),
+			"SHELL": "/bin/zsh",
+		},
+	})
+	if err != nil {
+		ui.Warning("Failed to start recording: %v", err)
+		return nil
+	}
+
+	return rec
 }
 
-func (c *Client) ensureConnectedToServiceNetworks(containerID string, cfg *config.Config) {
+func (c *Client) ensureConnectedToServiceNetworks(containerID string, cfg *config.Config, project ServiceProject) {
 	inspect, err := c.cli.ContainerInspect(c.ctx, containerID)
 	if err != nil {
 		return
@@ -435,7 +738,7 @@ func (c *Client) ensureConnectedToServiceNetworks(containerID string, cfg *confi
 			continue
 		}
 
-		serviceID := c.findComposeServiceContainerID(name)
+		serviceID := c.findComposeServiceContainerID(project, name)
 		if serviceID == "" {
 			continue
 		}
@@ -457,9 +760,10 @@ func (c *Client) ensureConnectedToServiceNetworks(containerID string, cfg *confi
 	}
 }
 
-func (c *Client) findComposeServiceContainerID(serviceName string) string {
+func (c *Client) findComposeServiceContainerID(project ServiceProject, serviceName string) string {
 	args := filters.NewArgs()
 	args.Add("status", "running")
+	args.Add("label", fmt.Sprintf("%s=%s", composeProjectLabel, project.Name))
 	args.Add("label", fmt.Sprintf("com.docker.compose.service=%s", serviceName))
 
 	containers, err := c.cli.ContainerList(c.ctx, container.ListOptions{Filters: args})
@@ -470,17 +774,18 @@ func (c *Client) findComposeServiceContainerID(serviceName string) string {
 	return containers[0].ID
 }
 
-func (c *Client) buildExecEnv(cfg *config.Config) []string {
+func (c *Client) buildExecEnv(cfg *config.Config, project ServiceProject, sessionID string) []string {
 	var env []string
 	for name, svc := range cfg.Services {
 		if !svc.Enabled {
 			continue
 		}
-		if name == "mitmproxy" && c.isComposeServiceRunning(cfg.Network.Name, name) {
-			env = append(env, fmt.Sprintf("HTTP_PROXY=http://%s:8080", name))
-			env = append(env, fmt.Sprintf("HTTPS_PROXY=http://%s:8080", name))
-			env = append(env, fmt.Sprintf("http_proxy=http://%s:8080", name))
-			env = append(env, fmt.Sprintf("https_proxy=http://%s:8080", name))
+		if name == "mitmproxy" && c.isComposeServiceRunning(project, name) {
+			proxy := sessionProxyURL(name, sessionID)
+			env = append(env, fmt.Sprintf("HTTP_PROXY=%s", proxy))
+			env = append(env, fmt.Sprintf("HTTPS_PROXY=%s", proxy))
+			env = append(env, fmt.Sprintf("http_proxy=%s", proxy))
+			env = append(env, fmt.Sprintf("https_proxy=%s", proxy))
 			env = append(env, "NO_PROXY=localhost,127.0.0.1")
 			env = append(env, "no_proxy=localhost,127.0.0.1")
 		}
@@ -488,7 +793,16 @@ func (c *Client) buildExecEnv(cfg *config.Config) []string {
 	return env
 }
 
-func (c *Client) attachExec(execID string, tty bool, interactive bool) error {
+// sessionProxyURL returns the proxy URL for a session. The session ID is sent
+// as the proxy username so the rize mitmproxy addon can attribute flows to it.
+func sessionProxyURL(host, sessionID string) string {
+	if sessionID == "" {
+		return fmt.Sprintf("http://%s:8080", host)
+	}
+	return fmt.Sprintf("http://%s:rize@%s:8080", sessionID, host)
+}
+
+func (c *Client) attachExec(execID string, tty bool, interactive bool, rec *recording.Recorder) (int, error) {
 	var oldState *term.State
 	var inFd uintptr
 
@@ -513,10 +827,15 @@ func (c *Client) attachExec(execID string, tty bool, interactive bool) error {
 	if oldState != nil {
 		defer term.RestoreTerminal(inFd, oldState)
 	}
+	if tty {
+		// Trace lines of the API calls made while the session runs would
+		// land in the raw terminal
+		defer ui.Attach()()
+	}
 
 	attachResp, err := c.cli.ContainerExecAttach(c.ctx, execID, container.ExecAttachOptions{Tty: tty})
 	if err != nil {
-		return fmt.Errorf("failed to attach exec: %w", err)
+		return -1, fmt.Errorf("failed to attach exec: %w", err)
 	}
 	defer attachResp.Close()
 
@@ -524,27 +843,44 @@ func (c *Client) attachExec(execID string, tty bool, interactive bool) error {
 		go io.Copy(attachResp.Conn, os.Stdin)
 	}
 
-	if tty {
-		go io.Copy(os.Stdout, attachResp.Reader)
-	} else {
-		go stdcopy.StdCopy(os.Stdout, os.Stderr, attachResp.Reader)
+	var stdout, stderr io.Writer = os.Stdout, os.Stderr
+	if rec != nil {
+		stdout = io.MultiWriter(os.Stdout, rec)
+		stderr = io.MultiWriter(os.Stderr, rec)
+	}
+
+	outputDone := make(chan struct{})
+	go func() {
+		defer close(outputDone)
+		if tty {
+			io.Copy(stdout, attachResp.Reader)
+		} else {
+			stdcopy.StdCopy(stdout, stderr, attachResp.Reader)
+		}
+	}()
+
+	exitCode, err := c.waitExec(execID)
+
+	// Give the output stream a moment to drain so recordings are complete
+	if rec != nil {
+		select {
+		case <-outputDone:
+		case <-time.After(time.Second):
+		}
 	}
 
-	return c.waitExec(execID)
+	return exitCode, err
 }
 
-func (c *Client) waitExec(execID string) error {
+func (c *Client) waitExec(execID string) (int, error) {
 	for {
 		inspect, err := c.cli.ContainerExecInspect(c.ctx, execID)
 		if err != nil {
-			return fmt.Errorf("failed to inspect exec: %w", err)
+			return -1, fmt.Errorf("failed to inspect exec: %w", err)
 		}
 
 		if !inspect.Running {
-			if inspect.ExitCode != 0 {
-				return fmt.Errorf("command exited with code %d", inspect.ExitCode)
-			}
-			return nil
+			return inspect.ExitCode, nil
 		}
 
 		time.Sleep(100 * time.Millisecond)
@@ -576,6 +912,11 @@ func (c *Client) attachInteractive(containerID string, tty bool) error {
 	if oldState != nil {
 		defer term.RestoreTerminal(inFd, oldState)
 	}
+	if tty {
+		// Trace lines of the API calls made while the session runs would
+		// land in the raw terminal
+		defer ui.Attach()()
+	}
 
 	// Attach to container
 	attachResp, err := c.cli.ContainerAttach(c.ctx, containerID, container.AttachOptions{
@@ -627,16 +968,43 @@ func (c *Client) waitContainer(containerID string) error {
 	return nil
 }
 
-// PullImage pulls the latest rize image
-func (c *Client) PullImage() error {
-	reader, err := c.cli.ImagePull(c.ctx, ImageName, image.PullOptions{})
+// VersionLabel is the label the rize image records its version in
+const VersionLabel = "org.opencontainers.image.version"
+
+// ImageInfo describes a local image
+type ImageInfo struct {
+	Ref     string `json:"ref"`
+	ID      string `json:"id"`
+	Digest  string `json:"digest,omitempty"`
+	Version string `json:"version,omitempty"`
+	Created string `json:"created,omitempty"`
+}
+
+// ImageInfo returns the local image ref, or nil when it is not pulled
+func (c *Client) ImageInfo(ref string) (*ImageInfo, error) {
+	inspect, err := c.cli.ImageInspect(c.ctx, ref)
+	if dockerclient.IsErrNotFound(err) {
+		return nil, nil
+	}
 	if err != nil {
-		return fmt.Errorf("failed to pull image: %w", err)
+		return nil, fmt.Errorf("failed to inspect image %s: %w", ref, err)
 	}
-	defer reader.Close()
 
-	_, err = io.Copy(os.Stdout, reader)
-	return err
+	info := &ImageInfo{Ref: ref, ID: inspect.ID, Created: inspect.Created}
+	if inspect.Config != nil {
+		info.Version = inspect.Config.Labels[VersionLabel]
+	}
+	repository := ref
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		repository = ref[:i]
+	}
+	for _, digest := range inspect.RepoDigests {
+		if strings.HasPrefix(digest, repository+"@") {
+			info.Digest = digest
+			break
+		}
+	}
+	return info, nil
 }
 
 // RemoveImage removes the rize image
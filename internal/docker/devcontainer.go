@@ -0,0 +1,204 @@
+package docker
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/alienxp03/rize/internal/config"
+	"github.com/alienxp03/rize/internal/ui"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	dockerclient "github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
+)
+
+// entrypointScript runs commands through the rize entrypoint when the image
+// has it, so images from a devcontainer.json work too
+const entrypointScript = `if [ -x /usr/local/bin/entrypoint.sh ]; then exec /usr/local/bin/entrypoint.sh "$@"; fi; exec "$@"`
+
+// projectEntrypoint returns the entrypoint of the project container
+func projectEntrypoint(image string) []string {
+	if image == ImageName {
+		return []string{"/usr/local/bin/entrypoint.sh"}
+	}
+	return []string{"/bin/sh", "-c", entrypointScript, "rize"}
+}
+
+// execCommand wraps a command run in the project container with the
+// entrypoint
+func execCommand(image string, cmd []string) []string {
+	return append(projectEntrypoint(image), cmd...)
+}
+
+// projectImage returns the image of the project container: the image or
+// build of the project's devcontainer.json, or the rize image, with the
+// .rize.yml build applied on top. Missing images are pulled or built.
+func (c *Client) projectImage(cfg *config.Config, containerName string) (string, error) {
+	base, err := c.baseImage(cfg, containerName)
+	if err != nil {
+		return "", err
+	}
+
+	if cfg.Project != nil && cfg.Project.Build != nil {
+		return c.projectBuildImage(cfg.Project.Build, base, containerName)
+	}
+	return base, nil
+}
+
+// baseImage returns the image of the project's devcontainer.json, or the
+// rize image
+func (c *Client) baseImage(cfg *config.Config, containerName string) (string, error) {
+	dc := cfg.DevContainer
+	switch {
+	case dc != nil && dc.Image != "":
+		return dc.Image, c.ensureImageRef(dc.Image)
+
+	case dc != nil && dc.Build != nil:
+		dockerfile, err := os.ReadFile(dc.Build.Dockerfile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read devcontainer Dockerfile: %w", err)
+		}
+		return c.BuildImage(BuildSpec{
+			Repository: containerName + "-devcontainer",
+			Dockerfile: string(dockerfile),
+			Context:    dc.Build.Context,
+			Args:       dc.Build.Args,
+			Target:     dc.Build.Target,
+		})
+	}
+
+	return ImageName, c.ensureImage()
+}
+
+// ensureImageRef pulls an image unless it exists locally
+func (c *Client) ensureImageRef(ref string) error {
+	_, err := c.cli.ImageInspect(c.ctx, ref)
+	if err == nil {
+		return nil
+	}
+	if !dockerclient.IsErrNotFound(err) {
+		return fmt.Errorf("failed to inspect image %s: %w", ref, err)
+	}
+
+	ui.Info("Pulling %s...", ref)
+	return c.pullImage(ref, true)
+}
+
+// applyDevContainer layers the devcontainer.json settings below rize's own:
+// containerEnv comes before the rize environment, and mounts whose target a
+// rize mount already uses are skipped
+func applyDevContainer(dc *config.DevContainer, containerConfig *container.Config, hostConfig *container.HostConfig) {
+	if dc == nil {
+		return
+	}
+
+	env := envList(dc.ContainerEnv)
+	containerConfig.Env = append(env, containerConfig.Env...)
+
+	targets := map[string]bool{}
+	for _, m := range hostConfig.Mounts {
+		targets[m.Target] = true
+	}
+	for _, m := range dc.Mounts {
+		if targets[m.Target] {
+			continue
+		}
+		hostConfig.Mounts = append(hostConfig.Mounts, mount.Mount{
+			Type:     mount.Type(m.Type),
+			Source:   m.Source,
+			Target:   m.Target,
+			ReadOnly: m.ReadOnly,
+		})
+	}
+
+	if len(dc.ForwardPorts) > 0 {
+		containerConfig.ExposedPorts = nat.PortSet{}
+		hostConfig.PortBindings = nat.PortMap{}
+		for _, port := range dc.ForwardPorts {
+			p := nat.Port(strconv.Itoa(port) + "/tcp")
+			containerConfig.ExposedPorts[p] = struct{}{}
+			hostConfig.PortBindings[p] = []nat.PortBinding{{HostIP: "127.0.0.1", HostPort: strconv.Itoa(port)}}
+		}
+	}
+}
+
+// remoteEnv returns the devcontainer remoteEnv for commands run in a
+// container with the given environment, resolving ${containerEnv:NAME}
+func remoteEnv(dc *config.DevContainer, containerEnv []string) []string {
+	if dc == nil || len(dc.RemoteEnv) == 0 {
+		return nil
+	}
+
+	values := map[string]string{}
+	for _, entry := range containerEnv {
+		key, value, _ := strings.Cut(entry, "=")
+		values[key] = value
+	}
+
+	env := map[string]string{}
+	for key, value := range dc.RemoteEnv {
+		env[key] = os.Expand(strings.ReplaceAll(value, "${containerEnv:", "${"), func(name string) string {
+			return values[name]
+		})
+	}
+	return envList(env)
+}
+
+// containerRemoteEnv returns the devcontainer remoteEnv for commands run in
+// a container
+func (c *Client) containerRemoteEnv(containerID string, dc *config.DevContainer) []string {
+	if dc == nil || len(dc.RemoteEnv) == 0 {
+		return nil
+	}
+
+	var containerEnv []string
+	if inspect, err := c.cli.ContainerInspect(c.ctx, containerID); err == nil && inspect.Config != nil {
+		containerEnv = inspect.Config.Env
+	}
+	return remoteEnv(dc, containerEnv)
+}
+
+// runLifecycle runs devcontainer lifecycle commands in the project container
+// and fails on the first command that fails
+func (c *Client) runLifecycle(containerID, workspaceDir, image, phase string, cmds [][]string, env []string) error {
+	for _, cmd := range cmds {
+		ui.Info("Running %s: %s", phase, strings.Join(cmd, " "))
+
+		resp, err := c.cli.ContainerExecCreate(c.ctx, containerID, container.ExecOptions{
+			Cmd:          execCommand(image, cmd),
+			AttachStdout: true,
+			AttachStderr: true,
+			Env:          env,
+			WorkingDir:   workspaceDir,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create exec: %w", err)
+		}
+
+		exitCode, err := c.attachExec(resp.ID, false, false, nil)
+		if err != nil {
+			return err
+		}
+		if exitCode != 0 {
+			return fmt.Errorf("%s exited with code %d", phase, exitCode)
+		}
+	}
+
+	return nil
+}
+
+// devContainerSetup returns the commands run after the project container is
+// created: installing feature tools with mise, then postCreateCommand
+func devContainerSetup(dc *config.DevContainer) [][]string {
+	if dc == nil {
+		return nil
+	}
+
+	var cmds [][]string
+	if len(dc.Tools) > 0 {
+		cmds = append(cmds, append([]string{"mise", "use", "-g"}, dc.Tools...))
+	}
+	return append(cmds, dc.PostCreateCommand...)
+}
@@ -0,0 +1,160 @@
+package docker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/alienxp03/rize/internal/config"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-connections/nat"
+)
+
+func TestApplyDevContainer(t *testing.T) {
+	containerConfig := &container.Config{Env: []string{"RIZE_PROJECT_NAME=app", "EDITOR=vim"}}
+	hostConfig := &container.HostConfig{Mounts: []mount.Mount{{Type: mount.TypeBind, Source: "/src/app", Target: "/workspace/app"}}}
+
+	applyDevContainer(&config.DevContainer{
+		ContainerEnv: map[string]string{"EDITOR": "code", "NODE_ENV": "development"},
+		Mounts: []config.DevContainerMount{
+			{Type: "bind", Source: "/elsewhere", Target: "/workspace/app"},
+			{Type: "volume", Source: "cache", Target: "/cache", ReadOnly: true},
+		},
+		ForwardPorts: []int{3000},
+	}, containerConfig, hostConfig)
+
+	wantEnv := []string{"EDITOR=code", "NODE_ENV=development", "RIZE_PROJECT_NAME=app", "EDITOR=vim"}
+	if !reflect.DeepEqual(containerConfig.Env, wantEnv) {
+		t.Errorf("Expected rize env after the devcontainer env %v, got %v", wantEnv, containerConfig.Env)
+	}
+
+	wantMounts := []mount.Mount{
+		{Type: mount.TypeBind, Source: "/src/app", Target: "/workspace/app"},
+		{Type: mount.TypeVolume, Source: "cache", Target: "/cache", ReadOnly: true},
+	}
+	if !reflect.DeepEqual(hostConfig.Mounts, wantMounts) {
+		t.Errorf("Expected rize mounts to win, got %+v", hostConfig.Mounts)
+	}
+
+	want := nat.PortMap{"3000/tcp": {{HostIP: "127.0.0.1", HostPort: "3000"}}}
+	if !reflect.DeepEqual(hostConfig.PortBindings, want) {
+		t.Errorf("Expected port bindings %v, got %v", want, hostConfig.PortBindings)
+	}
+}
+
+func TestRemoteEnv(t *testing.T) {
+	dc := &config.DevContainer{RemoteEnv: map[string]string{
+		"PATH":  "${containerEnv:PATH}:/workspace/app/bin",
+		"DEBUG": "1",
+	}}
+
+	got := remoteEnv(dc, []string{"PATH=/usr/bin:/bin", "HOME=/home/agent"})
+	want := []string{"DEBUG=1", "PATH=/usr/bin:/bin:/workspace/app/bin"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("remoteEnv() = %v, want %v", got, want)
+	}
+
+	if env := remoteEnv(nil, nil); env != nil {
+		t.Errorf("Expected no env without a devcontainer, got %v", env)
+	}
+}
+
+func TestExecCommand(t *testing.T) {
+	if got := execCommand(ImageName, []string{"claude"}); !reflect.DeepEqual(got, []string{"/usr/local/bin/entrypoint.sh", "claude"}) {
+		t.Errorf("execCommand(rize image) = %v", got)
+	}
+
+	got := execCommand("mcr.microsoft.com/devcontainers/go:1", []string{"go", "test"})
+	if len(got) != 6 || got[0] != "/bin/sh" || got[4] != "go" || got[5] != "test" {
+		t.Errorf("execCommand(other image) = %v", got)
+	}
+}
+
+func TestProjectConfigHash(t *testing.T) {
+	hostConfig := &container.HostConfig{}
+	hash := projectConfigHash(&container.Config{Image: ImageName, Env: []string{"TERM=xterm", "A=1"}}, hostConfig)
+
+	if other := projectConfigHash(&container.Config{Image: ImageName, Env: []string{"TERM=screen", "A=1"}}, hostConfig); other != hash {
+		t.Error("Expected the terminal to be left out of the hash")
+	}
+	if other := projectConfigHash(&container.Config{Image: "other", Env: []string{"TERM=xterm", "A=1"}}, hostConfig); other == hash {
+		t.Error("Expected the image to change the hash")
+	}
+}
+
+func TestProjectConfigHashStable(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	for _, name := range []string{".netrc", ".gitconfig", ".env"} {
+		if err := os.WriteFile(filepath.Join(home, name), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := config.DefaultConfig()
+	svc := cfg.Services["mitmproxy"]
+	svc.Enabled = false
+	cfg.Services["mitmproxy"] = svc
+	for _, key := range []string{"A", "B", "C", "D", "E", "F", "G", "H"} {
+		cfg.Environment[key] = "value-" + key
+	}
+	project := ServiceProject{Name: "rize", Network: "rize"}
+
+	c := &Client{}
+	var hash string
+	for i := 0; i < 20; i++ {
+		// The SSH agent socket and the terminal change between logins
+		t.Setenv("SSH_AUTH_SOCK", filepath.Join(home, fmt.Sprintf("agent.%d", i)))
+		t.Setenv("TERM", fmt.Sprintf("term-%d", i))
+
+		_, _, containerConfig, hostConfig, _ := c.buildContainerConfigs(cfg, project)
+		got := projectConfigHash(containerConfig, hostConfig)
+		if hash == "" {
+			hash = got
+		} else if got != hash {
+			t.Fatalf("Run %d hashed to %s, want %s", i, got, hash)
+		}
+	}
+
+	cfg.Environment["A"] = "changed"
+	_, _, containerConfig, hostConfig, _ := c.buildContainerConfigs(cfg, project)
+	if projectConfigHash(containerConfig, hostConfig) == hash {
+		t.Error("Expected a config change to change the hash")
+	}
+}
+
+func TestDataMounts(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dataDir := filepath.Join(home, ".rize")
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dataDir, "zsh_history"), []byte(": 1:0;ls\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	writable := map[string]bool{}
+	for _, m := range dataMounts() {
+		if m.Source == dataDir {
+			t.Fatalf("Expected ~/.rize not to be mounted, got %+v", m)
+		}
+		if !m.ReadOnly {
+			writable[m.Source] = true
+		}
+	}
+	containerDir := filepath.Join(dataDir, "container")
+	if len(writable) != 1 || !writable[containerDir] {
+		t.Errorf("Writable mounts = %v, want only %s", writable, containerDir)
+	}
+
+	if _, err := os.Stat(filepath.Join(containerDir, "zsh_history")); err != nil {
+		t.Errorf("Expected the shell history to be moved: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(containerDir, "audit-container.log")); err != nil {
+		t.Errorf("Expected the audit spool to be created: %v", err)
+	}
+}
@@ -0,0 +1,182 @@
+package docker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/mount"
+	dockerclient "github.com/docker/docker/client"
+)
+
+// mitmproxyCAPath is where the entrypoint installs the mitmproxy CA
+const mitmproxyCAPath = "/usr/local/share/ca-certificates/mitmproxy-ca-cert.crt"
+
+// DaemonInfo describes the Docker daemon rize talks to
+type DaemonInfo struct {
+	Version       string `json:"version"`
+	APIVersion    string `json:"api_version"`
+	MinAPIVersion string `json:"min_api_version"`
+	// ClientAPIVersion is the API version rize is built against
+	ClientAPIVersion string `json:"client_api_version"`
+	OS               string `json:"os"`
+	Arch             string `json:"arch"`
+	RootDir          string `json:"root_dir"`
+	// Desktop is set for Docker Desktop, which runs the daemon in a VM
+	Desktop bool `json:"desktop"`
+}
+
+// Daemon returns the daemon version and platform
+func (c *Client) Daemon() (*DaemonInfo, error) {
+	v, err := c.cli.ServerVersion(c.ctx)
+	if err != nil {
+		return nil, err
+	}
+	info, err := c.cli.Info(c.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get docker info: %w", err)
+	}
+
+	return &DaemonInfo{
+		Version:          v.Version,
+		APIVersion:       v.APIVersion,
+		MinAPIVersion:    v.MinAPIVersion,
+		ClientAPIVersion: api.DefaultVersion,
+		OS:               info.OperatingSystem,
+		Arch:             v.Arch,
+		RootDir:          info.DockerRootDir,
+		Desktop:          strings.Contains(info.OperatingSystem, "Docker Desktop"),
+	}, nil
+}
+
+// ProjectContainer describes the project container of a workspace
+type ProjectContainer struct {
+	Name    string `json:"name"`
+	ID      string `json:"id,omitempty"`
+	State   string `json:"state"`
+	Image   string `json:"image,omitempty"`
+	ImageID string `json:"image_id,omitempty"`
+	Created string `json:"created,omitempty"`
+	// HostUID is the user the container was created for
+	HostUID string `json:"host_uid,omitempty"`
+	// Proxy is the HTTP proxy the container was created with
+	Proxy string `json:"proxy,omitempty"`
+	// Sessions is the number of commands running in the container
+	Sessions int `json:"sessions"`
+}
+
+// Running reports whether the container is running
+func (p *ProjectContainer) Running() bool {
+	return p.State == "running"
+}
+
+// ProjectContainer returns the project container of the workspace at cwd;
+// its state is "not created" when it does not exist
+func (c *Client) ProjectContainer(cwd string) (*ProjectContainer, error) {
+	result := &ProjectContainer{Name: projectContainerName(cwd), State: "not created"}
+
+	inspect, err := c.cli.ContainerInspect(c.ctx, result.Name)
+	if dockerclient.IsErrNotFound(err) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container %s: %w", result.Name, err)
+	}
+
+	result.ID = inspect.ID
+	result.ImageID = inspect.Image
+	result.Created = inspect.Created
+	result.Sessions = len(inspect.ExecIDs)
+	if inspect.State != nil {
+		result.State = string(inspect.State.Status)
+	}
+	if inspect.Config != nil {
+		result.Image = inspect.Config.Image
+		for _, entry := range inspect.Config.Env {
+			key, value, _ := strings.Cut(entry, "=")
+			switch key {
+			case "HOST_UID":
+				result.HostUID = value
+			case "HTTP_PROXY":
+				result.Proxy = value
+			}
+		}
+	}
+	return result, nil
+}
+
+// MitmproxyCAInstalled reports whether the entrypoint installed the
+// mitmproxy CA in a running container
+func (c *Client) MitmproxyCAInstalled(containerID string) (bool, error) {
+	resp, err := c.cli.ContainerExecCreate(c.ctx, containerID, container.ExecOptions{
+		Cmd: []string{"test", "-s", mitmproxyCAPath},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to create exec: %w", err)
+	}
+	if err := c.cli.ContainerExecStart(c.ctx, resp.ID, container.ExecStartOptions{Detach: true}); err != nil {
+		return false, fmt.Errorf("failed to start exec: %w", err)
+	}
+
+	exitCode, err := c.waitExec(resp.ID)
+	if err != nil {
+		return false, err
+	}
+	return exitCode == 0, nil
+}
+
+// ContainerSummary describes a project container in the list of all of them
+type ContainerSummary struct {
+	Name    string `json:"name"`
+	ID      string `json:"id"`
+	Project string `json:"project"`
+	// Path is the host directory mounted as the workspace
+	Path    string    `json:"path,omitempty"`
+	State   string    `json:"state"`
+	Status  string    `json:"status"`
+	Image   string    `json:"image"`
+	Created time.Time `json:"created"`
+}
+
+// ProjectContainers returns the project containers of every workspace,
+// sorted by name
+func (c *Client) ProjectContainers() ([]ContainerSummary, error) {
+	containers, err := c.cli.ContainerList(c.ctx, container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", ProjectLabel)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	summaries := make([]ContainerSummary, 0, len(containers))
+	for _, ctr := range containers {
+		summary := ContainerSummary{
+			ID:      ctr.ID,
+			Project: ctr.Labels[ProjectLabel],
+			State:   string(ctr.State),
+			Status:  ctr.Status,
+			Image:   ctr.Image,
+			Created: time.Unix(ctr.Created, 0).UTC(),
+		}
+		if len(ctr.Names) > 0 {
+			summary.Name = strings.TrimPrefix(ctr.Names[0], "/")
+		}
+		for _, m := range ctr.Mounts {
+			if m.Type == mount.TypeBind && strings.HasPrefix(m.Destination, "/workspace/") {
+				summary.Path = m.Source
+				break
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Name < summaries[j].Name
+	})
+	return summaries, nil
+}
@@ -0,0 +1,83 @@
+package docker
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alienxp03/rize/internal/config"
+)
+
+// SharedProjectName is the service project used when services are shared by
+// all projects. It matches the compose project name earlier versions used, so
+// existing containers and volumes are picked up.
+const SharedProjectName = "rize"
+
+const composeProjectLabel = "com.docker.compose.project"
+
+// ServiceProject identifies the service containers, network and volumes used
+// by a workspace
+type ServiceProject struct {
+	// Name is the project name, used as the compose project label
+	Name string
+	// Network is the network joined by the services and the project container
+	Network string
+	// Scoped is set when services are isolated per project
+	Scoped bool
+}
+
+// ResolveServiceProject returns the service project for the workspace at cwd.
+// With service_scope "project" every workspace gets its own services, network
+// and volumes, named like the project container.
+func ResolveServiceProject(cfg *config.Config, cwd string) (ServiceProject, error) {
+	switch cfg.ServiceScope {
+	case "", config.ServiceScopeShared:
+		return ServiceProject{
+			Name:    SharedProjectName,
+			Network: cfg.Network.Name,
+		}, nil
+	case config.ServiceScopeProject:
+	default:
+		return ServiceProject{}, fmt.Errorf("invalid service_scope %q (expected %q or %q)", cfg.ServiceScope, config.ServiceScopeShared, config.ServiceScopeProject)
+	}
+
+	name := projectContainerName(cwd)
+	return ServiceProject{
+		Name:    name,
+		Network: name,
+		Scoped:  true,
+	}, nil
+}
+
+// CurrentServiceProject returns the service project for the current directory
+func CurrentServiceProject(cfg *config.Config) (ServiceProject, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return ServiceProject{}, fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	return ResolveServiceProject(cfg, cwd)
+}
+
+// scopedPorts drops the host side of published ports so that several projects
+// can run the same services; docker assigns a free host port instead.
+func scopedPorts(ports []string) []string {
+	if ports == nil {
+		return nil
+	}
+
+	scoped := make([]string, 0, len(ports))
+	for _, port := range ports {
+		parts := strings.Split(port, ":")
+		switch len(parts) {
+		case 2:
+			port = parts[1]
+		case 3:
+			// Keep the bind address: "127.0.0.1:8080:8080" -> "127.0.0.1::8080"
+			port = parts[0] + "::" + parts[2]
+		}
+		scoped = append(scoped, port)
+	}
+
+	return scoped
+}
@@ -0,0 +1,90 @@
+package docker
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alienxp03/rize/internal/config"
+)
+
+// projectBuildImage builds the image of a .rize.yml build section on top of
+// the base image
+func (c *Client) projectBuildImage(b *config.ProjectBuild, base, containerName string) (string, error) {
+	inspect, err := c.cli.ImageInspect(c.ctx, base)
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect image %s: %w", base, err)
+	}
+
+	spec, err := projectBuildSpec(b, base)
+	if err != nil {
+		return "", err
+	}
+	spec.Repository = containerName + "-build"
+	spec.BaseID = inspect.ID
+
+	return c.BuildImage(spec)
+}
+
+// projectBuildSpec returns the build of a .rize.yml build section. The
+// base image is passed as the RIZE_BASE_IMAGE build arg; a Dockerfile
+// without a FROM line and the package lists are applied to it.
+func projectBuildSpec(b *config.ProjectBuild, base string) (BuildSpec, error) {
+	var dockerfile strings.Builder
+	fmt.Fprintf(&dockerfile, "ARG RIZE_BASE_IMAGE=%s\n", base)
+
+	content := ""
+	if b.Dockerfile != "" {
+		data, err := os.ReadFile(b.Dockerfile)
+		if err != nil {
+			return BuildSpec{}, fmt.Errorf("failed to read build Dockerfile: %w", err)
+		}
+		content = string(data)
+	}
+
+	if !hasFrom(content) {
+		dockerfile.WriteString("FROM ${RIZE_BASE_IMAGE}\n")
+	}
+	if content != "" {
+		dockerfile.WriteString(content)
+		if !strings.HasSuffix(content, "\n") {
+			dockerfile.WriteString("\n")
+		}
+	}
+
+	if len(b.Apt) > 0 {
+		fmt.Fprintf(&dockerfile, "USER root\nRUN apt-get update && DEBIAN_FRONTEND=noninteractive apt-get install -y --no-install-recommends %s && rm -rf /var/lib/apt/lists/*\n",
+			strings.Join(b.Apt, " "))
+	}
+	if len(b.Mise) > 0 || len(b.Npm) > 0 {
+		dockerfile.WriteString("USER " + ContainerUser + "\n")
+		if len(b.Mise) > 0 {
+			fmt.Fprintf(&dockerfile, "RUN mise use -g %s\n", strings.Join(b.Mise, " "))
+		}
+		if len(b.Npm) > 0 {
+			fmt.Fprintf(&dockerfile, "RUN mise exec node -- npm install -g %s\n", strings.Join(b.Npm, " "))
+		}
+	}
+	// The rize entrypoint starts as root and drops to the agent user
+	if len(b.Apt) > 0 || len(b.Mise) > 0 || len(b.Npm) > 0 {
+		dockerfile.WriteString("USER root\n")
+	}
+
+	return BuildSpec{
+		Dockerfile: dockerfile.String(),
+		Context:    b.Context,
+		Args:       b.Args,
+		Target:     b.Target,
+	}, nil
+}
+
+// hasFrom reports whether a Dockerfile has a FROM instruction
+func hasFrom(dockerfile string) bool {
+	for _, line := range strings.Split(dockerfile, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && strings.EqualFold(fields[0], "FROM") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alienxp03/rize/internal/config"
+)
+
+func TestProjectBuildSpec(t *testing.T) {
+	spec, err := projectBuildSpec(&config.ProjectBuild{
+		Apt:  []string{"libvips-dev", "protobuf-compiler"},
+		Mise: []string{"protoc@29"},
+		Npm:  []string{"playwright"},
+	}, ImageName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `ARG RIZE_BASE_IMAGE=alienxp03/rize:latest
+FROM ${RIZE_BASE_IMAGE}
+USER root
+RUN apt-get update && DEBIAN_FRONTEND=noninteractive apt-get install -y --no-install-recommends libvips-dev protobuf-compiler && rm -rf /var/lib/apt/lists/*
+USER agent
+RUN mise use -g protoc@29
+RUN mise exec node -- npm install -g playwright
+USER root
+`
+	if spec.Dockerfile != want {
+		t.Errorf("Unexpected Dockerfile:\n%s\nwant:\n%s", spec.Dockerfile, want)
+	}
+}
+
+func TestProjectBuildSpecDockerfile(t *testing.T) {
+	dir := t.TempDir()
+	fragment := filepath.Join(dir, "fragment.Dockerfile")
+	if err := os.WriteFile(fragment, []byte("RUN apt-get install -y chromium"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	full := filepath.Join(dir, "full.Dockerfile")
+	if err := os.WriteFile(full, []byte("ARG RIZE_BASE_IMAGE\nFROM ${RIZE_BASE_IMAGE} AS dev\nRUN true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	spec, err := projectBuildSpec(&config.ProjectBuild{Dockerfile: fragment, Context: dir}, "base:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "ARG RIZE_BASE_IMAGE=base:1\nFROM ${RIZE_BASE_IMAGE}\nRUN apt-get install -y chromium\n"; spec.Dockerfile != want {
+		t.Errorf("Expected the fragment applied to the base image, got:\n%s", spec.Dockerfile)
+	}
+	if spec.Context != dir {
+		t.Errorf("Expected context %s, got %s", dir, spec.Context)
+	}
+
+	spec, err = projectBuildSpec(&config.ProjectBuild{Dockerfile: full, Target: "dev"}, "base:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(spec.Dockerfile, "FROM") != 1 || !strings.HasPrefix(spec.Dockerfile, "ARG RIZE_BASE_IMAGE=base:1\n") {
+		t.Errorf("Expected the Dockerfile's own FROM to be kept, got:\n%s", spec.Dockerfile)
+	}
+	if spec.Target != "dev" {
+		t.Errorf("Expected target dev, got %q", spec.Target)
+	}
+
+	if _, err := projectBuildSpec(&config.ProjectBuild{Dockerfile: filepath.Join(dir, "missing")}, "base:1"); err == nil {
+		t.Error("Expected an error for a missing Dockerfile")
+	}
+}
@@ -0,0 +1,205 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/alienxp03/rize/internal/audit"
+	"github.com/alienxp03/rize/internal/ui"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/docker/go-units"
+	"github.com/moby/term"
+)
+
+// pullSummaryInterval is how often a summary line is printed while pulling
+// without a terminal
+var pullSummaryInterval = 10 * time.Second
+
+// PullImage pulls a rize image
+func (c *Client) PullImage(ref string) error {
+	return c.pullImage(ref, true)
+}
+
+// pullImage pulls an image, showing its progress unless quiet, and records
+// the pull in the audit log
+func (c *Client) pullImage(ref string, show bool) error {
+	start := time.Now()
+	err := c.pull(ref, show)
+	audit.Log(audit.Event{
+		Type:       audit.ImagePull,
+		Image:      ref,
+		DurationMs: time.Since(start).Milliseconds(),
+		Error:      audit.ErrorString(err),
+	})
+	return err
+}
+
+// pull pulls an image with the credentials of the Docker config. Ctrl-C
+// cancels the pull.
+func (c *Client) pull(ref string, show bool) error {
+	ctx, stop := signal.NotifyContext(c.ctx, os.Interrupt)
+	defer stop()
+
+	auth, err := registryAuth(ref)
+	if err != nil {
+		ui.Warning("Pulling %s without credentials: %v", ref, err)
+	}
+
+	reader, err := c.cli.ImagePull(ctx, ref, image.PullOptions{RegistryAuth: auth})
+	if err != nil {
+		return pullError(ctx, ref, err)
+	}
+	defer reader.Close()
+
+	show = show && ui.Enabled(ui.LevelInfo)
+	if fd, isTerm := term.GetFdInfo(os.Stderr); show && isTerm {
+		err = jsonmessage.DisplayJSONMessagesStream(reader, os.Stderr, fd, true, nil)
+	} else {
+		err = summarizePull(reader, ref, show)
+	}
+	if err != nil {
+		return pullError(ctx, ref, err)
+	}
+	return nil
+}
+
+func pullError(ctx context.Context, ref string, err error) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("pull of image %s canceled", ref)
+	}
+	return fmt.Errorf("failed to pull image %s: %w", ref, err)
+}
+
+// summarizePull reads a pull stream, printing a summary line every
+// pullSummaryInterval when show is set. An error in the stream is returned.
+func summarizePull(r io.Reader, ref string, show bool) error {
+	progress := newPullProgress()
+	last := time.Now()
+
+	dec := json.NewDecoder(r)
+	for {
+		var msg jsonmessage.JSONMessage
+		if err := dec.Decode(&msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if msg.ErrorMessage != "" {
+			return errors.New(msg.ErrorMessage)
+		}
+
+		if msg.ID == "" && msg.Status != "" {
+			ui.Debug("%s", msg.Status)
+		}
+		progress.update(msg)
+
+		if show && progress.layers() > 0 && time.Since(last) >= pullSummaryInterval {
+			ui.Info("Pulling %s: %s", ref, progress)
+			last = time.Now()
+		}
+	}
+
+	if show && progress.layers() > 0 {
+		ui.Info("Pulled %s: %s", ref, progress)
+	}
+	return nil
+}
+
+// pullProgress tracks the layers of a pull
+type pullProgress struct {
+	byID  map[string]*layerProgress
+	order []string
+}
+
+type layerProgress struct {
+	// current and total are the bytes downloaded, total is 0 when unknown
+	current, total int64
+	downloaded     bool
+	done           bool
+	// existed is set for layers that were already there
+	existed bool
+}
+
+func newPullProgress() *pullProgress {
+	return &pullProgress{byID: map[string]*layerProgress{}}
+}
+
+// update records a message of the pull stream. Messages about the whole
+// image, like "Pulling from library/postgres", are ignored.
+func (p *pullProgress) update(msg jsonmessage.JSONMessage) {
+	switch msg.Status {
+	case "Pulling fs layer", "Waiting":
+		p.layer(msg.ID)
+	case "Downloading":
+		layer := p.layer(msg.ID)
+		if msg.Progress != nil {
+			layer.current = msg.Progress.Current
+			if msg.Progress.Total > 0 {
+				layer.total = msg.Progress.Total
+			}
+		}
+	case "Verifying Checksum", "Download complete", "Extracting":
+		p.layer(msg.ID).downloaded = true
+	case "Pull complete":
+		layer := p.layer(msg.ID)
+		layer.downloaded = true
+		layer.done = true
+	case "Already exists":
+		layer := p.layer(msg.ID)
+		layer.existed = true
+		layer.done = true
+	}
+}
+
+// layer returns the progress of a layer, adding it when it is new
+func (p *pullProgress) layer(id string) *layerProgress {
+	layer, ok := p.byID[id]
+	if !ok {
+		layer = &layerProgress{}
+		p.byID[id] = layer
+		p.order = append(p.order, id)
+	}
+	return layer
+}
+
+func (p *pullProgress) layers() int {
+	return len(p.order)
+}
+
+// String summarizes the progress, like "3/12 layers, 1.2GB of 4.8GB"
+func (p *pullProgress) String() string {
+	var done int
+	var current, total int64
+	for _, id := range p.order {
+		layer := p.byID[id]
+		if layer.done {
+			done++
+		}
+		if layer.existed {
+			continue
+		}
+		if layer.downloaded && layer.total > 0 {
+			current += layer.total
+		} else {
+			current += layer.current
+		}
+		total += layer.total
+	}
+
+	summary := fmt.Sprintf("%d/%d layers", done, len(p.order))
+	if total > 0 {
+		summary += fmt.Sprintf(", %s of %s", units.HumanSize(float64(current)), units.HumanSize(float64(total)))
+	}
+	return summary
+}
@@ -0,0 +1,69 @@
+package docker
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/pkg/jsonmessage"
+)
+
+const pullStream = `{"status":"Pulling from alienxp03/rize","id":"latest"}
+{"status":"Already exists","progressDetail":{},"id":"a1"}
+{"status":"Pulling fs layer","progressDetail":{},"id":"b2"}
+{"status":"Pulling fs layer","progressDetail":{},"id":"c3"}
+{"status":"Waiting","progressDetail":{},"id":"c3"}
+{"status":"Downloading","progressDetail":{"current":1000,"total":4000},"id":"b2"}
+{"status":"Downloading","progressDetail":{"current":3000,"total":4000},"id":"b2"}
+{"status":"Download complete","progressDetail":{},"id":"b2"}
+{"status":"Extracting","progressDetail":{"current":2000,"total":4000},"id":"b2"}
+{"status":"Pull complete","progressDetail":{},"id":"b2"}
+{"status":"Downloading","progressDetail":{"current":500,"total":2000},"id":"c3"}
+`
+
+func TestPullProgress(t *testing.T) {
+	progress := newPullProgress()
+	for _, line := range strings.Split(strings.TrimSpace(pullStream), "\n") {
+		var msg jsonmessage.JSONMessage
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			t.Fatal(err)
+		}
+		progress.update(msg)
+	}
+
+	if got, want := progress.String(), "2/3 layers, 4.5kB of 6kB"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestPullProgressWithoutSizes(t *testing.T) {
+	progress := newPullProgress()
+	progress.update(jsonmessage.JSONMessage{Status: "Pulling fs layer", ID: "a1"})
+	progress.update(jsonmessage.JSONMessage{Status: "Digest: sha256:0123"})
+
+	if got, want := progress.String(), "0/1 layers"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestSummarizePull(t *testing.T) {
+	stream := pullStream + `{"status":"Status: Downloaded newer image for alienxp03/rize:latest"}` + "\n"
+	if err := summarizePull(strings.NewReader(stream), ImageName, false); err != nil {
+		t.Fatalf("summarizePull() error = %v", err)
+	}
+}
+
+func TestSummarizePullError(t *testing.T) {
+	stream := pullStream + `{"errorDetail":{"message":"unauthorized: authentication required"},"error":"unauthorized: authentication required"}` + "\n"
+
+	err := summarizePull(strings.NewReader(stream), ImageName, false)
+	if err == nil || err.Error() != "unauthorized: authentication required" {
+		t.Fatalf("summarizePull() error = %v, want the stream error", err)
+	}
+}
+
+func TestSummarizePullInvalidStream(t *testing.T) {
+	if err := summarizePull(strings.NewReader(`{"status":`), ImageName, false); err == nil {
+		t.Fatal("summarizePull() succeeded on a truncated stream")
+	}
+}
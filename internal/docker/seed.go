@@ -0,0 +1,195 @@
+package docker
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alienxp03/rize/internal/audit"
+	"github.com/alienxp03/rize/internal/config"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// InitDir is where database images look for init scripts on first start
+const InitDir = "/docker-entrypoint-initdb.d"
+
+const (
+	postgresSeedScript = `exec psql -v ON_ERROR_STOP=1 -U "${POSTGRES_USER:-postgres}" -d "${POSTGRES_DB:-${POSTGRES_USER:-postgres}}"`
+	mysqlSeedScript    = `client=$(command -v mariadb || command -v mysql); password=${MYSQL_ROOT_PASSWORD:-$MARIADB_ROOT_PASSWORD}; exec "$client" -uroot ${password:+-p"$password"} ${MYSQL_DATABASE:-$MARIADB_DATABASE}`
+)
+
+// initVolumes returns read-only bind mounts for the init assets of a service.
+// A directory is mounted as the init directory itself, so it must be the only
+// entry; files are mounted into it by name.
+func initVolumes(name string, svc config.Service) ([]string, error) {
+	assets := expandVolumes(svc.Init)
+
+	var volumes []string
+	for _, asset := range assets {
+		path, err := filepath.Abs(asset)
+		if err != nil {
+			return nil, err
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("init asset %s of %s not found", asset, name)
+		}
+
+		if info.IsDir() {
+			if len(assets) > 1 {
+				return nil, fmt.Errorf("init directory %s of %s must be the only init entry", asset, name)
+			}
+			volumes = append(volumes, path+":"+InitDir+":ro")
+			continue
+		}
+		volumes = append(volumes, path+":"+InitDir+"/"+filepath.Base(path)+":ro")
+	}
+
+	return volumes, nil
+}
+
+// SeedFiles returns the SQL dumps among the init assets of a service, in the
+// order the image would run them
+func SeedFiles(svc config.Service) ([]string, error) {
+	var files []string
+	for _, asset := range expandVolumes(svc.Init) {
+		info, err := os.Stat(asset)
+		if err != nil {
+			return nil, fmt.Errorf("init asset %s not found", asset)
+		}
+
+		if !info.IsDir() {
+			if isSQLDump(asset) {
+				files = append(files, asset)
+			}
+			continue
+		}
+
+		entries, err := os.ReadDir(asset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", asset, err)
+		}
+		var dumps []string
+		for _, entry := range entries {
+			if !entry.IsDir() && isSQLDump(entry.Name()) {
+				dumps = append(dumps, filepath.Join(asset, entry.Name()))
+			}
+		}
+		sort.Strings(dumps)
+		files = append(files, dumps...)
+	}
+
+	return files, nil
+}
+
+func isSQLDump(path string) bool {
+	return strings.HasSuffix(path, ".sql") || strings.HasSuffix(path, ".sql.gz")
+}
+
+// seedCommand returns the command that reads a dump on stdin for a service:
+// its seed setting, or the database client of postgres and mysql images
+func seedCommand(svc config.Service) []string {
+	if len(svc.Seed) > 0 {
+		return svc.Seed
+	}
+
+	image := imageName(svc.Image)
+	switch {
+	case strings.HasPrefix(image, "postgres"), strings.HasPrefix(image, "postgis"):
+		return []string{"sh", "-c", postgresSeedScript}
+	case strings.HasPrefix(image, "mysql"), strings.HasPrefix(image, "mariadb"):
+		return []string{"sh", "-c", mysqlSeedScript}
+	}
+
+	return nil
+}
+
+// imageName returns the repository name of an image reference without its
+// registry, namespace, tag or digest
+func imageName(ref string) string {
+	name := ref[strings.LastIndex(ref, "/")+1:]
+	if i := strings.IndexAny(name, ":@"); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// Seed pipes dump into the running container of a service through its seed
+// command. source names the dump in the audit log.
+func (o *Orchestrator) Seed(name string, dump io.Reader, source string) error {
+	err := o.seed(name, dump)
+	audit.Log(audit.Event{
+		Type:     audit.ServiceSeed,
+		Services: []string{name},
+		Error:    audit.ErrorString(err),
+		Details: map[string]string{
+			"compose_project": o.project.Name,
+			"source":          source,
+		},
+	})
+	return err
+}
+
+func (o *Orchestrator) seed(name string, dump io.Reader) error {
+	if err := o.cfg.ValidateServices([]string{name}); err != nil {
+		return err
+	}
+
+	cmd := seedCommand(o.cfg.Services[name])
+	if cmd == nil {
+		return fmt.Errorf("no seed command for %s; set `seed` in its config", name)
+	}
+
+	containerID := o.client.findComposeServiceContainerID(o.project, name)
+	if containerID == "" {
+		return fmt.Errorf("service %s is not running; run `rize services up %s`", name, name)
+	}
+
+	resp, err := o.client.cli.ContainerExecCreate(o.client.ctx, containerID, container.ExecOptions{
+		Cmd:          cmd,
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create exec: %w", err)
+	}
+
+	attachResp, err := o.client.cli.ContainerExecAttach(o.client.ctx, resp.ID, container.ExecAttachOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to attach exec: %w", err)
+	}
+	defer attachResp.Close()
+
+	copyErr := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(attachResp.Conn, dump)
+		attachResp.CloseWrite()
+		copyErr <- err
+	}()
+
+	outputDone := make(chan struct{})
+	go func() {
+		defer close(outputDone)
+		stdcopy.StdCopy(os.Stdout, os.Stderr, attachResp.Reader)
+	}()
+
+	exitCode, err := o.client.waitExec(resp.ID)
+	<-outputDone
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("seed command exited with code %d", exitCode)
+	}
+	if err := <-copyErr; err != nil {
+		return fmt.Errorf("failed to send dump: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alienxp03/rize/internal/config"
+)
+
+func TestInitVolumes(t *testing.T) {
+	dir := t.TempDir()
+	initDir := filepath.Join(dir, "init")
+	if err := os.MkdirAll(initDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"schema.sql", "seed.sh", "init/02-data.sql.gz", "init/01-schema.sql", "init/notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	volumes, err := initVolumes("postgres", config.Service{Init: []string{filepath.Join(dir, "schema.sql"), filepath.Join(dir, "seed.sh")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		filepath.Join(dir, "schema.sql") + ":/docker-entrypoint-initdb.d/schema.sql:ro",
+		filepath.Join(dir, "seed.sh") + ":/docker-entrypoint-initdb.d/seed.sh:ro",
+	}
+	if !reflect.DeepEqual(volumes, want) {
+		t.Errorf("initVolumes(files) = %v, want %v", volumes, want)
+	}
+
+	volumes, err = initVolumes("postgres", config.Service{Init: []string{initDir}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{initDir + ":/docker-entrypoint-initdb.d:ro"}; !reflect.DeepEqual(volumes, want) {
+		t.Errorf("initVolumes(dir) = %v, want %v", volumes, want)
+	}
+
+	if _, err := initVolumes("postgres", config.Service{Init: []string{initDir, filepath.Join(dir, "schema.sql")}}); err == nil {
+		t.Error("Expected an error for a directory next to other init entries")
+	}
+	if _, err := initVolumes("postgres", config.Service{Init: []string{filepath.Join(dir, "missing.sql")}}); err == nil {
+		t.Error("Expected an error for a missing init asset")
+	}
+
+	files, err := SeedFiles(config.Service{Init: []string{filepath.Join(dir, "seed.sh"), initDir}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantFiles := []string{filepath.Join(initDir, "01-schema.sql"), filepath.Join(initDir, "02-data.sql.gz")}
+	if !reflect.DeepEqual(files, wantFiles) {
+		t.Errorf("SeedFiles() = %v, want %v", files, wantFiles)
+	}
+}
+
+func TestSeedCommand(t *testing.T) {
+	tests := []struct {
+		image string
+		seed  []string
+		want  string
+	}{
+		{image: "postgres:16-alpine", want: "psql"},
+		{image: "bitnami/postgresql:16", want: "psql"},
+		{image: "localhost:5000/postgis/postgis:16-3.4", want: "psql"},
+		{image: "mysql:8", want: "mysql"},
+		{image: "mariadb@sha256:abc", want: "mariadb"},
+		{image: "mongo:7", seed: []string{"mongorestore", "--archive"}, want: "mongorestore --archive"},
+		{image: "redis:7-alpine"},
+	}
+
+	for _, tt := range tests {
+		cmd := seedCommand(config.Service{Image: tt.image, Seed: tt.seed})
+		if tt.want == "" {
+			if cmd != nil {
+				t.Errorf("seedCommand(%s) = %v, want none", tt.image, cmd)
+			}
+			continue
+		}
+		if !strings.Contains(strings.Join(cmd, " "), tt.want) {
+			t.Errorf("seedCommand(%s) = %v, want it to run %s", tt.image, cmd, tt.want)
+		}
+	}
+}
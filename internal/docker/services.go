@@ -0,0 +1,879 @@
+package docker
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alienxp03/rize/internal/audit"
+	"github.com/alienxp03/rize/internal/config"
+	"github.com/alienxp03/rize/internal/traffic"
+	"github.com/alienxp03/rize/internal/ui"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+	dockerclient "github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/docker/go-connections/nat"
+)
+
+// Labels set on service containers and volumes. The compose labels are kept so
+// that containers created by `docker compose` in earlier versions are found
+// and replaced.
+const (
+	composeServiceLabel = "com.docker.compose.service"
+	composeVolumeLabel  = "com.docker.compose.volume"
+
+	// ConfigHashLabel holds the hash of the configuration a service container
+	// was created from
+	ConfigHashLabel = "rize.config-hash"
+)
+
+// readyTimeout bounds how long a service waits for its dependencies
+const readyTimeout = 2 * time.Minute
+
+// Orchestrator runs the services of a service project with the Docker SDK
+type Orchestrator struct {
+	client  *Client
+	cfg     *config.Config
+	project ServiceProject
+	quiet   bool
+}
+
+// NewOrchestrator creates an orchestrator for the service project
+func NewOrchestrator(client *Client, cfg *config.Config, project ServiceProject) *Orchestrator {
+	return &Orchestrator{client: client, cfg: cfg, project: project}
+}
+
+// Quiet suppresses progress output
+func (o *Orchestrator) Quiet() *Orchestrator {
+	o.quiet = true
+	return o
+}
+
+// Project returns the service project managed by the orchestrator
+func (o *Orchestrator) Project() ServiceProject {
+	return o.project
+}
+
+// serviceSpec is the container a service should run as
+type serviceSpec struct {
+	Name       string
+	Container  string
+	Config     *container.Config
+	Host       *container.HostConfig
+	Networking *network.NetworkingConfig
+	Volumes    []string
+	Hash       string
+}
+
+// Up creates, recreates and starts services in dependency order, together
+// with the services they depend on. Without names every enabled service is
+// started and containers of disabled services are removed. Services whose
+// configuration and image did not change are left running.
+func (o *Orchestrator) Up(names ...string) error {
+	order, err := o.targets(names, true)
+	if err == nil {
+		err = o.up(order, len(names) == 0)
+	}
+	logServiceEvent(audit.ServiceUp, o.project, order, err)
+	return err
+}
+
+func (o *Orchestrator) up(order []string, removeOrphans bool) error {
+	if len(order) == 0 {
+		return nil
+	}
+
+	if svc, ok := o.cfg.Services["mitmproxy"]; ok && svc.Enabled {
+		if err := traffic.WriteAddons(); err != nil {
+			return err
+		}
+	}
+
+	if err := o.client.ensureNetwork(config.NetworkConfig{Name: o.project.Network, Driver: o.cfg.Network.Driver}); err != nil {
+		return err
+	}
+
+	existing, err := o.containers()
+	if err != nil {
+		return err
+	}
+
+	// Remove containers of services that are no longer enabled
+	for name, ctr := range existing {
+		if svc, ok := o.cfg.Services[name]; !removeOrphans || (ok && svc.Enabled) {
+			continue
+		}
+		o.progress("Removing %s", name)
+		if err := o.remove(ctr.ID); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range order {
+		svc := o.cfg.Services[name]
+		for _, dep := range svc.DependsOn {
+			if err := o.waitReady(dep); err != nil {
+				return err
+			}
+		}
+
+		spec, err := o.spec(name, svc)
+		if err != nil {
+			return err
+		}
+
+		if err := o.apply(spec, existing[name]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// apply brings a service in line with its spec
+func (o *Orchestrator) apply(spec *serviceSpec, current *container.Summary) error {
+	if current != nil && current.Labels[ConfigHashLabel] == spec.Hash && !o.imageChanged(spec.Config.Image, current.ImageID) {
+		if current.State == "running" {
+			return nil
+		}
+		o.progress("Starting %s", spec.Name)
+		return o.startContainer(current.ID)
+	}
+
+	if err := o.ensureVolumes(spec.Volumes); err != nil {
+		return err
+	}
+	if err := o.ensureImage(spec.Config.Image); err != nil {
+		return err
+	}
+
+	if current != nil {
+		o.progress("Recreating %s", spec.Name)
+		if err := o.remove(current.ID); err != nil {
+			return err
+		}
+	} else {
+		o.progress("Creating %s", spec.Name)
+	}
+
+	resp, err := o.client.cli.ContainerCreate(o.client.ctx, spec.Config, spec.Host, spec.Networking, nil, spec.Container)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", spec.Name, err)
+	}
+
+	return o.startContainer(resp.ID)
+}
+
+// imageChanged reports whether ref now points at a different image than the
+// one a container was created from, e.g. after `rize services pull`
+func (o *Orchestrator) imageChanged(ref, imageID string) bool {
+	inspect, err := o.client.cli.ImageInspect(o.client.ctx, ref)
+	if err != nil {
+		return false
+	}
+	return inspect.ID != imageID
+}
+
+func (o *Orchestrator) startContainer(containerID string) error {
+	if err := o.client.cli.ContainerStart(o.client.ctx, containerID, container.StartOptions{}); err != nil {
+		return fmt.Errorf("failed to start container %s: %w", shortID(containerID), err)
+	}
+	return nil
+}
+
+func (o *Orchestrator) remove(containerID string) error {
+	if err := o.client.cli.ContainerRemove(o.client.ctx, containerID, container.RemoveOptions{Force: true}); err != nil {
+		return fmt.Errorf("failed to remove container %s: %w", shortID(containerID), err)
+	}
+	return nil
+}
+
+// Down stops and removes the service containers and the project network.
+// Volumes are kept.
+func (o *Orchestrator) Down() error {
+	err := o.down()
+	logServiceEvent(audit.ServiceDown, o.project, nil, err)
+	return err
+}
+
+func (o *Orchestrator) down() error {
+	existing, err := o.containers()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range sortedNames(existing) {
+		o.progress("Removing %s", name)
+		ctr := existing[name]
+		if err := o.client.cli.ContainerStop(o.client.ctx, ctr.ID, container.StopOptions{}); err != nil && !dockerclient.IsErrNotFound(err) {
+			return fmt.Errorf("failed to stop %s: %w", name, err)
+		}
+		if err := o.remove(ctr.ID); err != nil {
+			return err
+		}
+	}
+
+	// The network may still be used by project containers; leave it then
+	_ = o.client.cli.NetworkRemove(o.client.ctx, o.project.Network)
+
+	return nil
+}
+
+// Restart restarts the named services, or every service, in dependency order
+func (o *Orchestrator) Restart(names ...string) error {
+	order, err := o.targets(names, false)
+	if err == nil {
+		err = o.eachContainer(order, "Restarting", "restart", func(ctr *container.Summary) error {
+			return o.client.cli.ContainerRestart(o.client.ctx, ctr.ID, container.StopOptions{})
+		})
+	}
+	logServiceEvent(audit.ServiceRestart, o.project, order, err)
+	return err
+}
+
+// Start starts the stopped containers of the named services, or of every
+// service, in dependency order. Unlike Up it never creates containers.
+func (o *Orchestrator) Start(names ...string) error {
+	order, err := o.targets(names, true)
+	if err == nil {
+		err = o.startServices(order)
+	}
+	logServiceEvent(audit.ServiceStart, o.project, order, err)
+	return err
+}
+
+func (o *Orchestrator) startServices(order []string) error {
+	existing, err := o.containers()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range order {
+		ctr, ok := existing[name]
+		if !ok {
+			return fmt.Errorf("service %s has not been created; run `rize services up %s`", name, name)
+		}
+		if ctr.State == "running" {
+			continue
+		}
+		o.progress("Starting %s", name)
+		if err := o.startContainer(ctr.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Stop stops the named services, or every service, in reverse dependency
+// order without removing their containers
+func (o *Orchestrator) Stop(names ...string) error {
+	order, err := o.targets(names, false)
+	if err == nil {
+		reversed := make([]string, 0, len(order))
+		for i := len(order) - 1; i >= 0; i-- {
+			reversed = append(reversed, order[i])
+		}
+		err = o.eachContainer(reversed, "Stopping", "stop", func(ctr *container.Summary) error {
+			if ctr.State != "running" {
+				return nil
+			}
+			return o.client.cli.ContainerStop(o.client.ctx, ctr.ID, container.StopOptions{})
+		})
+	}
+	logServiceEvent(audit.ServiceStop, o.project, order, err)
+	return err
+}
+
+// Pull pulls the images of the named services, or of every enabled service.
+// Running services pick up new images on the next Up.
+func (o *Orchestrator) Pull(names ...string) error {
+	order, err := o.targets(names, false)
+	if err != nil {
+		return err
+	}
+
+	pulled := map[string]bool{}
+	for _, name := range order {
+		ref := o.cfg.Services[name].Image
+		if pulled[ref] {
+			continue
+		}
+		pulled[ref] = true
+
+		o.progress("Pulling %s (%s)", name, ref)
+		if err := o.client.pullImage(ref, !o.quiet); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// eachContainer runs fn for the existing container of every named service
+func (o *Orchestrator) eachContainer(names []string, progress, action string, fn func(*container.Summary) error) error {
+	existing, err := o.containers()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		ctr, ok := existing[name]
+		if !ok {
+			continue
+		}
+		o.progress("%s %s", progress, name)
+		if err := fn(ctr); err != nil {
+			return fmt.Errorf("failed to %s %s: %w", action, name, err)
+		}
+	}
+
+	return nil
+}
+
+// targets returns the named services in dependency order, or every enabled
+// service when no names are given. With deps set the services they depend on
+// are included.
+func (o *Orchestrator) targets(names []string, deps bool) ([]string, error) {
+	if err := o.cfg.ValidateServices(names); err != nil {
+		return nil, err
+	}
+
+	order, err := StartOrder(o.cfg.Services)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return order, nil
+	}
+
+	selected := map[string]bool{}
+	var add func(name string)
+	add = func(name string) {
+		if selected[name] {
+			return
+		}
+		selected[name] = true
+		if deps {
+			for _, dep := range o.cfg.Services[name].DependsOn {
+				add(dep)
+			}
+		}
+	}
+	for _, name := range names {
+		add(name)
+	}
+
+	var targets []string
+	for _, name := range order {
+		if selected[name] {
+			targets = append(targets, name)
+		}
+	}
+
+	return targets, nil
+}
+
+// LogOptions controls which service logs are shown
+type LogOptions struct {
+	// Follow streams new output until the containers stop
+	Follow bool
+	// Tail is the number of lines to show from the end, or "all"
+	Tail string
+	// Since shows logs since a timestamp or relative duration (e.g. 10m)
+	Since string
+}
+
+// Logs writes the logs of the named services, or of every service container,
+// to w, prefixed with the service name
+func (o *Orchestrator) Logs(w io.Writer, opts LogOptions, names ...string) error {
+	if err := o.cfg.ValidateServices(names); err != nil {
+		return err
+	}
+
+	existing, err := o.containers()
+	if err != nil {
+		return err
+	}
+
+	if len(names) == 0 {
+		names = sortedNames(existing)
+	}
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs = make(chan error, len(names))
+	)
+	for _, name := range names {
+		ctr, ok := existing[name]
+		if !ok {
+			return fmt.Errorf("service %s has not been created; run `rize services up %s`", name, name)
+		}
+
+		reader, err := o.client.cli.ContainerLogs(o.client.ctx, ctr.ID, container.LogsOptions{
+			ShowStdout: true,
+			ShowStderr: true,
+			Follow:     opts.Follow,
+			Tail:       opts.Tail,
+			Since:      opts.Since,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read logs of %s: %w", name, err)
+		}
+
+		out := &prefixWriter{w: w, mu: &mu, prefix: fmt.Sprintf("%-12s| ", name)}
+		if !opts.Follow {
+			// Print services one after another
+			_, err := stdcopy.StdCopy(out, out, reader)
+			reader.Close()
+			out.Flush()
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer reader.Close()
+			if _, err := stdcopy.StdCopy(out, out, reader); err != nil {
+				errs <- err
+			}
+			out.Flush()
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
+
+// Exec runs cmd in the running container of a service and returns its exit
+// code. With interactive set stdin is attached through a TTY.
+func (o *Orchestrator) Exec(name string, cmd []string, interactive bool) (int, error) {
+	if err := o.cfg.ValidateServices([]string{name}); err != nil {
+		return -1, err
+	}
+
+	containerID := o.client.findComposeServiceContainerID(o.project, name)
+	if containerID == "" {
+		return -1, fmt.Errorf("service %s is not running; run `rize services up %s`", name, name)
+	}
+
+	resp, err := o.client.cli.ContainerExecCreate(o.client.ctx, containerID, container.ExecOptions{
+		Cmd:          cmd,
+		AttachStdin:  interactive,
+		AttachStdout: true,
+		AttachStderr: true,
+		Tty:          interactive,
+	})
+	if err != nil {
+		return -1, fmt.Errorf("failed to create exec: %w", err)
+	}
+
+	start := time.Now()
+	exitCode, err := o.client.attachExec(resp.ID, interactive, interactive, nil)
+	audit.Log(audit.Event{
+		Type:       audit.ExecEnd,
+		Container:  shortID(containerID),
+		Image:      o.cfg.Services[name].Image,
+		Command:    cmd,
+		Services:   []string{name},
+		ExitCode:   audit.ExitCode(exitCode),
+		DurationMs: time.Since(start).Milliseconds(),
+		Error:      audit.ErrorString(err),
+	})
+
+	return exitCode, err
+}
+
+// containers returns the service containers of the project by service name
+func (o *Orchestrator) containers() (map[string]*container.Summary, error) {
+	args := filters.NewArgs()
+	args.Add("label", fmt.Sprintf("%s=%s", composeProjectLabel, o.project.Name))
+
+	list, err := o.client.cli.ContainerList(o.client.ctx, container.ListOptions{All: true, Filters: args})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service containers: %w", err)
+	}
+
+	containers := map[string]*container.Summary{}
+	for i := range list {
+		name := list[i].Labels[composeServiceLabel]
+		if name == "" {
+			continue
+		}
+		containers[name] = &list[i]
+	}
+
+	return containers, nil
+}
+
+// waitReady waits until a service is running, and healthy when it has a
+// healthcheck
+func (o *Orchestrator) waitReady(name string) error {
+	deadline := time.Now().Add(readyTimeout)
+	for {
+		containerID := o.client.findComposeServiceContainerID(o.project, name)
+		if containerID != "" {
+			inspect, err := o.client.cli.ContainerInspect(o.client.ctx, containerID)
+			if err != nil {
+				return fmt.Errorf("failed to inspect %s: %w", name, err)
+			}
+
+			ready, err := stateReady(inspect.State)
+			if err != nil {
+				return fmt.Errorf("service %s %w", name, err)
+			}
+			if ready {
+				return nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for service %s", name)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// stateReady reports whether a container is running, and healthy when it
+// has a healthcheck. A container that exited or turned unhealthy is an
+// error; one that is starting or restarting is not ready yet.
+func stateReady(state *container.State) (bool, error) {
+	if state == nil {
+		return false, nil
+	}
+	switch {
+	case state.Running && !state.Restarting:
+	case state.Status == container.StateExited, state.Status == container.StateDead:
+		return false, fmt.Errorf("exited with code %d", state.ExitCode)
+	default:
+		return false, nil
+	}
+
+	switch health := state.Health; {
+	case health == nil, health.Status == container.Healthy:
+		return true, nil
+	case health.Status == container.Unhealthy:
+		return false, fmt.Errorf("is unhealthy")
+	}
+	return false, nil
+}
+
+func (o *Orchestrator) ensureVolumes(names []string) error {
+	for _, name := range names {
+		_, err := o.client.cli.VolumeInspect(o.client.ctx, o.volumeName(name))
+		if err == nil {
+			continue
+		}
+		if !dockerclient.IsErrNotFound(err) {
+			return fmt.Errorf("failed to inspect volume %s: %w", name, err)
+		}
+
+		_, err = o.client.cli.VolumeCreate(o.client.ctx, volume.CreateOptions{
+			Name: o.volumeName(name),
+			Labels: managedLabels(map[string]string{
+				composeProjectLabel: o.project.Name,
+				composeVolumeLabel:  name,
+			}),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create volume %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// volumeName returns the docker volume for a named service volume. It matches
+// the <project>_<volume> naming of compose so existing data is kept.
+func (o *Orchestrator) volumeName(name string) string {
+	return o.project.Name + "_" + name
+}
+
+func (o *Orchestrator) ensureImage(ref string) error {
+	_, err := o.client.cli.ImageInspect(o.client.ctx, ref)
+	if err == nil {
+		return nil
+	}
+	if !dockerclient.IsErrNotFound(err) {
+		return fmt.Errorf("failed to inspect image %s: %w", ref, err)
+	}
+
+	o.progress("Pulling %s", ref)
+	return o.client.pullImage(ref, !o.quiet)
+}
+
+// spec builds the container configuration of a service
+func (o *Orchestrator) spec(name string, svc config.Service) (*serviceSpec, error) {
+	ports := svc.Ports
+	if o.project.Scoped {
+		ports = scopedPorts(ports)
+	}
+	exposed, bindings, err := nat.ParsePortSpecs(ports)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ports for %s: %w", name, err)
+	}
+
+	initVols, err := initVolumes(name, svc)
+	if err != nil {
+		return nil, err
+	}
+
+	mounts, volumes, err := o.mounts(append(expandVolumes(svc.Volumes), initVols...))
+	if err != nil {
+		return nil, fmt.Errorf("invalid volumes for %s: %w", name, err)
+	}
+
+	healthcheck, err := healthConfig(svc.HealthCheck)
+	if err != nil {
+		return nil, fmt.Errorf("invalid healthcheck for %s: %w", name, err)
+	}
+
+	spec := &serviceSpec{
+		Name:      name,
+		Container: fmt.Sprintf("%s-%s-1", o.project.Name, name),
+		Volumes:   volumes,
+		Config: &container.Config{
+			Image:        svc.Image,
+			Cmd:          svc.Command,
+			Env:          envList(svc.Environment),
+			ExposedPorts: exposed,
+			Healthcheck:  healthcheck,
+			Labels: managedLabels(map[string]string{
+				composeProjectLabel: o.project.Name,
+				composeServiceLabel: name,
+			}),
+		},
+		Host: &container.HostConfig{
+			Mounts:        mounts,
+			PortBindings:  bindings,
+			NetworkMode:   container.NetworkMode(o.project.Network),
+			RestartPolicy: container.RestartPolicy{Name: container.RestartPolicyMode(svc.Restart)},
+		},
+		Networking: &network.NetworkingConfig{
+			EndpointsConfig: map[string]*network.EndpointSettings{
+				o.project.Network: {Aliases: append([]string{name}, svc.Aliases...)},
+			},
+		},
+	}
+
+	spec.Hash = configHash(spec)
+	spec.Config.Labels[ConfigHashLabel] = spec.Hash
+
+	return spec, nil
+}
+
+// mounts converts compose-style volume strings into mounts and returns the
+// named volumes they use
+func (o *Orchestrator) mounts(volumes []string) ([]mount.Mount, []string, error) {
+	var mounts []mount.Mount
+	var named []string
+	for _, vol := range volumes {
+		parts := strings.Split(vol, ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, nil, fmt.Errorf("invalid volume %q", vol)
+		}
+
+		m := mount.Mount{Source: parts[0], Target: parts[1]}
+		if len(parts) == 3 {
+			m.ReadOnly = parts[2] == "ro"
+		}
+
+		if strings.HasPrefix(m.Source, ".") {
+			// The config loaders resolve these against the config file
+			return nil, nil, fmt.Errorf("invalid volume %q: relative bind source", vol)
+		}
+		if strings.HasPrefix(m.Source, "/") {
+			m.Type = mount.TypeBind
+			if _, err := os.Stat(m.Source); os.IsNotExist(err) {
+				if err := os.MkdirAll(m.Source, 0755); err != nil {
+					return nil, nil, fmt.Errorf("failed to create %s: %w", m.Source, err)
+				}
+			}
+		} else {
+			m.Type = mount.TypeVolume
+			named = append(named, m.Source)
+			m.Source = o.volumeName(m.Source)
+		}
+
+		mounts = append(mounts, m)
+	}
+
+	return mounts, named, nil
+}
+
+func healthConfig(hc *config.HealthCheck) (*container.HealthConfig, error) {
+	if hc == nil {
+		return nil, nil
+	}
+
+	health := &container.HealthConfig{Test: hc.Test, Retries: hc.Retries}
+	for _, d := range []struct {
+		value  string
+		target *time.Duration
+	}{
+		{hc.Interval, &health.Interval},
+		{hc.Timeout, &health.Timeout},
+	} {
+		if d.value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(d.value)
+		if err != nil {
+			return nil, err
+		}
+		*d.target = parsed
+	}
+
+	return health, nil
+}
+
+func envList(env map[string]string) []string {
+	list := make([]string, 0, len(env))
+	for key, value := range env {
+		list = append(list, fmt.Sprintf("%s=%s", key, value))
+	}
+	sort.Strings(list)
+	return list
+}
+
+// configHash hashes everything that requires recreating the container when
+// it changes
+func configHash(spec *serviceSpec) string {
+	data, _ := json.Marshal(struct {
+		Config     *container.Config
+		Host       *container.HostConfig
+		Networking *network.NetworkingConfig
+	}{spec.Config, spec.Host, spec.Networking})
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// StartOrder returns the enabled services ordered so that every service comes
+// after the services it depends on
+func StartOrder(services map[string]config.Service) ([]string, error) {
+	var names []string
+	for name, svc := range services {
+		if svc.Enabled {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := map[string]int{}
+	var order []string
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("dependency cycle: %s", strings.Join(append(path, name), " -> "))
+		}
+
+		state[name] = visiting
+		for _, dep := range services[name].DependsOn {
+			if svc, ok := services[dep]; !ok || !svc.Enabled {
+				return fmt.Errorf("service %s depends on %s, which is not enabled", name, dep)
+			}
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = done
+		order = append(order, name)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+func logServiceEvent(eventType string, project ServiceProject, services []string, err error) {
+	audit.Log(audit.Event{
+		Type:     eventType,
+		Services: services,
+		Error:    audit.ErrorString(err),
+		Details:  map[string]string{"compose_project": project.Name},
+	})
+}
+
+func (o *Orchestrator) progress(format string, args ...interface{}) {
+	if !o.quiet {
+		ui.Info(format, args...)
+	}
+}
+
+func sortedNames(containers map[string]*container.Summary) []string {
+	names := make([]string, 0, len(containers))
+	for name := range containers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// prefixWriter prefixes every line written to w
+type prefixWriter struct {
+	w       io.Writer
+	mu      *sync.Mutex
+	prefix  string
+	pending []byte
+}
+
+func (p *prefixWriter) Write(data []byte) (int, error) {
+	p.pending = append(p.pending, data...)
+	for {
+		i := bytes.IndexByte(p.pending, '\n')
+		if i < 0 {
+			break
+		}
+		if err := p.writeLine(p.pending[:i+1]); err != nil {
+			return 0, err
+		}
+		p.pending = p.pending[i+1:]
+	}
+	return len(data), nil
+}
+
+// Flush writes any incomplete last line
+func (p *prefixWriter) Flush() {
+	if len(p.pending) > 0 {
+		_ = p.writeLine(append(p.pending, '\n'))
+		p.pending = nil
+	}
+}
+
+func (p *prefixWriter) writeLine(line []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err := fmt.Fprintf(p.w, "%s%s", p.prefix, line)
+	return err
+}
@@ -0,0 +1,164 @@
+package docker
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alienxp03/rize/internal/config"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+)
+
+func TestStartOrder(t *testing.T) {
+	services := map[string]config.Service{
+		"app":      {Enabled: true, DependsOn: []string{"postgres", "redis"}},
+		"postgres": {Enabled: true},
+		"redis":    {Enabled: true},
+		"worker":   {Enabled: true, DependsOn: []string{"app"}},
+		"disabled": {Enabled: false},
+	}
+
+	order, err := StartOrder(services)
+	if err != nil {
+		t.Fatalf("Failed to compute start order: %v", err)
+	}
+
+	want := []string{"postgres", "redis", "app", "worker"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("StartOrder = %v, want %v", order, want)
+	}
+}
+
+func TestStartOrderErrors(t *testing.T) {
+	cycle := map[string]config.Service{
+		"a": {Enabled: true, DependsOn: []string{"b"}},
+		"b": {Enabled: true, DependsOn: []string{"a"}},
+	}
+	if _, err := StartOrder(cycle); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("Expected a cycle error, got %v", err)
+	}
+
+	missing := map[string]config.Service{
+		"app":      {Enabled: true, DependsOn: []string{"postgres"}},
+		"postgres": {Enabled: false},
+	}
+	if _, err := StartOrder(missing); err == nil {
+		t.Error("Expected an error for a disabled dependency")
+	}
+}
+
+func TestServiceSpec(t *testing.T) {
+	cfg := config.DefaultConfig()
+	o := NewOrchestrator(nil, cfg, sharedProject(t, cfg))
+
+	spec, err := o.spec("postgres", cfg.Services["postgres"])
+	if err != nil {
+		t.Fatalf("Failed to build spec: %v", err)
+	}
+
+	if spec.Container != "rize-postgres-1" {
+		t.Errorf("Unexpected container name %s", spec.Container)
+	}
+	if spec.Config.Labels[composeProjectLabel] != SharedProjectName || spec.Config.Labels[composeServiceLabel] != "postgres" {
+		t.Errorf("Missing project labels: %v", spec.Config.Labels)
+	}
+	if spec.Config.Labels[ConfigHashLabel] != spec.Hash {
+		t.Error("Expected config hash label")
+	}
+	if spec.Config.Healthcheck == nil || spec.Config.Healthcheck.Interval.String() != "5s" {
+		t.Errorf("Unexpected healthcheck: %+v", spec.Config.Healthcheck)
+	}
+
+	want := []mount.Mount{{Type: mount.TypeVolume, Source: "rize_rize-postgres", Target: "/var/lib/postgresql/data"}}
+	if !reflect.DeepEqual(spec.Host.Mounts, want) {
+		t.Errorf("Unexpected mounts: %+v", spec.Host.Mounts)
+	}
+
+	// The same config hashes the same; a change requires a recreate
+	again, _ := o.spec("postgres", cfg.Services["postgres"])
+	if again.Hash != spec.Hash {
+		t.Error("Expected a stable config hash")
+	}
+
+	svc := cfg.Services["postgres"]
+	svc.Environment = map[string]string{"POSTGRES_PASSWORD": "changed"}
+	changed, _ := o.spec("postgres", svc)
+	if changed.Hash == spec.Hash {
+		t.Error("Expected the config hash to change with the environment")
+	}
+}
+
+func TestServiceSpecPorts(t *testing.T) {
+	cfg := config.DefaultConfig()
+	o := NewOrchestrator(nil, cfg, sharedProject(t, cfg))
+
+	spec, err := o.spec("playwright", cfg.Services["playwright"])
+	if err != nil {
+		t.Fatalf("Failed to build spec: %v", err)
+	}
+
+	bindings := spec.Host.PortBindings["3000/tcp"]
+	if len(bindings) != 1 || bindings[0].HostPort != "8381" {
+		t.Errorf("Unexpected port bindings: %+v", spec.Host.PortBindings)
+	}
+
+	svc := cfg.Services["playwright"]
+	svc.Ports = []string{"not-a-port"}
+	if _, err := o.spec("playwright", svc); err == nil {
+		t.Error("Expected an error for an invalid port")
+	}
+}
+
+func TestTargets(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Services["app"] = config.Service{Enabled: true, Image: "app", DependsOn: []string{"postgres"}}
+	o := NewOrchestrator(nil, cfg, sharedProject(t, cfg))
+
+	targets, err := o.targets([]string{"app"}, true)
+	if err != nil {
+		t.Fatalf("Failed to resolve targets: %v", err)
+	}
+	if want := []string{"postgres", "app"}; !reflect.DeepEqual(targets, want) {
+		t.Errorf("targets with deps = %v, want %v", targets, want)
+	}
+
+	targets, _ = o.targets([]string{"app", "redis"}, false)
+	if want := []string{"app", "redis"}; !reflect.DeepEqual(targets, want) {
+		t.Errorf("targets without deps = %v, want %v", targets, want)
+	}
+
+	all, _ := o.targets(nil, false)
+	if len(all) != 5 {
+		t.Errorf("Expected every enabled service, got %v", all)
+	}
+
+	if _, err := o.targets([]string{"mysql"}, false); err == nil || !strings.Contains(err.Error(), "unknown service") {
+		t.Errorf("Expected an unknown service error, got %v", err)
+	}
+}
+
+func TestStateReady(t *testing.T) {
+	tests := []struct {
+		name    string
+		state   *container.State
+		ready   bool
+		wantErr bool
+	}{
+		{"running", &container.State{Status: container.StateRunning, Running: true}, true, false},
+		{"healthy", &container.State{Status: container.StateRunning, Running: true, Health: &container.Health{Status: container.Healthy}}, true, false},
+		{"starting", &container.State{Status: container.StateRunning, Running: true, Health: &container.Health{Status: container.Starting}}, false, false},
+		{"unhealthy", &container.State{Status: container.StateRunning, Running: true, Health: &container.Health{Status: container.Unhealthy}}, false, true},
+		{"exited", &container.State{Status: container.StateExited, ExitCode: 1}, false, true},
+		{"exited with healthcheck", &container.State{Status: container.StateExited, ExitCode: 0, Health: &container.Health{Status: container.Healthy}}, false, true},
+		{"restarting", &container.State{Status: container.StateRestarting, Running: true, Restarting: true}, false, false},
+		{"created", &container.State{Status: container.StateCreated}, false, false},
+	}
+
+	for _, tt := range tests {
+		ready, err := stateReady(tt.state)
+		if ready != tt.ready || (err != nil) != tt.wantErr {
+			t.Errorf("%s: stateReady() = %v, %v, want %v, error %v", tt.name, ready, err, tt.ready, tt.wantErr)
+		}
+	}
+}
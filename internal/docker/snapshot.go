@@ -0,0 +1,346 @@
+package docker
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alienxp03/rize/internal/audit"
+	"github.com/alienxp03/rize/internal/config"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	dockerclient "github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// SnapshotImage is the helper image used to archive and restore volumes
+const SnapshotImage = "alpine:3.20"
+
+// helperLabel marks the throwaway helper containers with what they are for
+const helperLabel = "rize.helper"
+
+const snapshotExt = ".tar.gz"
+
+var snapshotNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Snapshot is an archive of the volumes of a service
+type Snapshot struct {
+	Project string
+	Service string
+	Name    string
+	Path    string
+	Size    int64
+	Created time.Time
+}
+
+// SnapshotDir returns the directory holding the snapshots of a service
+// (~/.rize/snapshots/<project>/<service>)
+func SnapshotDir(project ServiceProject, service string) (string, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dataDir, "snapshots", project.Name, service), nil
+}
+
+// ListSnapshots returns the snapshots of the project, newest first. With a
+// service name only that service's snapshots are returned.
+func ListSnapshots(project ServiceProject, service string) ([]Snapshot, error) {
+	dir, err := SnapshotDir(project, "*")
+	if err != nil {
+		return nil, err
+	}
+	if service != "" {
+		dir = filepath.Join(filepath.Dir(dir), service)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+snapshotExt))
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []Snapshot
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, Snapshot{
+			Project: project.Name,
+			Service: filepath.Base(filepath.Dir(path)),
+			Name:    strings.TrimSuffix(filepath.Base(path), snapshotExt),
+			Path:    path,
+			Size:    info.Size(),
+			Created: info.ModTime(),
+		})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Created.After(snapshots[j].Created)
+	})
+
+	return snapshots, nil
+}
+
+// Snapshot archives the volumes of a service. The service is stopped while
+// the archive is written and started again afterwards. An empty name uses
+// the current time.
+func (o *Orchestrator) Snapshot(service, name string) (*Snapshot, error) {
+	if name == "" {
+		name = time.Now().Format("20060102-150405")
+	}
+	if !snapshotNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid snapshot name %q", name)
+	}
+	if err := o.cfg.ValidateServices([]string{service}); err != nil {
+		return nil, err
+	}
+
+	dir, err := SnapshotDir(o.project, service)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, name+snapshotExt)
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("snapshot %s already exists for %s", name, service)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	script := fmt.Sprintf("tar czf /backup/%[1]s -C /volumes . && chown %[2]d:%[3]d /backup/%[1]s",
+		filepath.Base(path), os.Getuid(), os.Getgid())
+
+	err = o.withVolumes(service, false, script, dir)
+	o.logSnapshotEvent(audit.ServiceSnapshot, service, name, err)
+	if err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot was not written: %w", err)
+	}
+
+	return &Snapshot{
+		Project: o.project.Name,
+		Service: service,
+		Name:    name,
+		Path:    path,
+		Size:    info.Size(),
+		Created: info.ModTime(),
+	}, nil
+}
+
+// Restore replaces the contents of a service's volumes with a snapshot
+func (o *Orchestrator) Restore(service, name string) error {
+	if !snapshotNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid snapshot name %q", name)
+	}
+	if err := o.cfg.ValidateServices([]string{service}); err != nil {
+		return err
+	}
+
+	dir, err := SnapshotDir(o.project, service)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, name+snapshotExt)
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("snapshot %s not found for %s", name, service)
+	}
+
+	script := fmt.Sprintf("find /volumes -mindepth 2 -maxdepth 2 -exec rm -rf {} + && tar xzf /backup/%s -C /volumes",
+		filepath.Base(path))
+
+	err = o.withVolumes(service, true, script, dir)
+	o.logSnapshotEvent(audit.ServiceRestore, service, name, err)
+	return err
+}
+
+// Reset removes the container and volumes of a service and starts it again
+// with empty volumes, so the image initialises fresh data
+func (o *Orchestrator) Reset(service string) error {
+	err := o.reset(service)
+	o.logSnapshotEvent(audit.ServiceReset, service, "", err)
+	return err
+}
+
+func (o *Orchestrator) reset(service string) error {
+	spec, err := o.serviceSpec(service)
+	if err != nil {
+		return err
+	}
+
+	if containerID := o.containerID(service); containerID != "" {
+		o.progress("Removing %s", service)
+		if err := o.remove(containerID); err != nil {
+			return err
+		}
+	}
+
+	for _, vol := range spec.Volumes {
+		o.progress("Removing volume %s", o.volumeName(vol))
+		err := o.client.cli.VolumeRemove(o.client.ctx, o.volumeName(vol), false)
+		if err != nil && !dockerclient.IsErrNotFound(err) {
+			return fmt.Errorf("failed to remove volume %s: %w", vol, err)
+		}
+	}
+
+	order, err := o.targets([]string{service}, true)
+	if err != nil {
+		return err
+	}
+	return o.up(order, false)
+}
+
+// withVolumes stops a service, runs script in a helper container with the
+// service's volumes mounted under /volumes and dir mounted at /backup, then
+// starts the service again if it was running. The volumes are only writable
+// when restoring; the backup directory only when not.
+func (o *Orchestrator) withVolumes(service string, restore bool, script, dir string) error {
+	spec, err := o.serviceSpec(service)
+	if err != nil {
+		return err
+	}
+
+	if len(spec.Volumes) == 0 {
+		return fmt.Errorf("service %s has no volumes", service)
+	}
+
+	if err := o.ensureVolumes(spec.Volumes); err != nil {
+		return err
+	}
+	if err := o.ensureImage(SnapshotImage); err != nil {
+		return err
+	}
+
+	mounts := []mount.Mount{{
+		Type:     mount.TypeBind,
+		Source:   dir,
+		Target:   "/backup",
+		ReadOnly: restore,
+	}}
+	for _, vol := range spec.Volumes {
+		mounts = append(mounts, mount.Mount{
+			Type:     mount.TypeVolume,
+			Source:   o.volumeName(vol),
+			Target:   "/volumes/" + vol,
+			ReadOnly: !restore,
+		})
+	}
+
+	containerID := o.containerID(service)
+	running := false
+	if containerID != "" {
+		inspect, err := o.client.cli.ContainerInspect(o.client.ctx, containerID)
+		if err != nil {
+			return fmt.Errorf("failed to inspect %s: %w", service, err)
+		}
+		running = inspect.State != nil && inspect.State.Running
+	}
+
+	if running {
+		o.progress("Stopping %s", service)
+		if err := o.client.cli.ContainerStop(o.client.ctx, containerID, container.StopOptions{}); err != nil {
+			return fmt.Errorf("failed to stop %s: %w", service, err)
+		}
+	}
+
+	err = o.runHelper(mounts, script)
+
+	if running {
+		o.progress("Starting %s", service)
+		if startErr := o.startContainer(containerID); startErr != nil && err == nil {
+			err = startErr
+		}
+	}
+
+	return err
+}
+
+// runHelper runs script in a throwaway helper container and returns its
+// output as the error when it fails
+func (o *Orchestrator) runHelper(mounts []mount.Mount, script string) error {
+	resp, err := o.client.cli.ContainerCreate(o.client.ctx,
+		&container.Config{
+			Image:  SnapshotImage,
+			Cmd:    []string{"sh", "-c", script},
+			Labels: managedLabels(map[string]string{helperLabel: "snapshot"}),
+		},
+		&container.HostConfig{Mounts: mounts},
+		nil, nil, "")
+	if err != nil {
+		return fmt.Errorf("failed to create helper container: %w", err)
+	}
+	defer o.client.cli.ContainerRemove(o.client.ctx, resp.ID, container.RemoveOptions{Force: true})
+
+	if err := o.startContainer(resp.ID); err != nil {
+		return err
+	}
+
+	if err := o.client.waitContainer(resp.ID); err != nil {
+		output := o.helperOutput(resp.ID)
+		if output != "" {
+			return fmt.Errorf("%w: %s", err, output)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (o *Orchestrator) helperOutput(containerID string) string {
+	reader, err := o.client.cli.ContainerLogs(o.client.ctx, containerID, container.LogsOptions{ShowStdout: true, ShowStderr: true})
+	if err != nil {
+		return ""
+	}
+	defer reader.Close()
+
+	var out strings.Builder
+	w := &prefixWriter{w: &out, mu: new(sync.Mutex)}
+	_, _ = stdcopy.StdCopy(w, w, io.LimitReader(reader, 64*1024))
+	w.Flush()
+	return strings.TrimSpace(out.String())
+}
+
+// serviceSpec validates a service name and returns its spec
+func (o *Orchestrator) serviceSpec(service string) (*serviceSpec, error) {
+	if err := o.cfg.ValidateServices([]string{service}); err != nil {
+		return nil, err
+	}
+	return o.spec(service, o.cfg.Services[service])
+}
+
+// containerID returns the container of a service in any state
+func (o *Orchestrator) containerID(service string) string {
+	existing, err := o.containers()
+	if err != nil {
+		return ""
+	}
+	if ctr, ok := existing[service]; ok {
+		return ctr.ID
+	}
+	return ""
+}
+
+func (o *Orchestrator) logSnapshotEvent(eventType, service, name string, err error) {
+	details := map[string]string{"compose_project": o.project.Name}
+	if name != "" {
+		details["snapshot"] = name
+	}
+	audit.Log(audit.Event{
+		Type:     eventType,
+		Services: []string{service},
+		Error:    audit.ErrorString(err),
+		Details:  details,
+	})
+}
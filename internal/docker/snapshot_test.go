@@ -0,0 +1,86 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestListSnapshots(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	project := ServiceProject{Name: SharedProjectName}
+	now := time.Now()
+	for i, snapshot := range []struct{ service, name string }{
+		{"postgres", "before-migration"},
+		{"postgres", "20260101-120000"},
+		{"redis", "empty"},
+	} {
+		dir, err := SnapshotDir(project, snapshot.service)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, snapshot.name+snapshotExt)
+		if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		modTime := now.Add(-time.Duration(i) * time.Hour)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all, err := ListSnapshots(project, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, snapshot := range all {
+		names = append(names, snapshot.Service+"/"+snapshot.Name)
+	}
+	want := []string{"postgres/before-migration", "postgres/20260101-120000", "redis/empty"}
+	if len(names) != len(want) {
+		t.Fatalf("ListSnapshots() = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("ListSnapshots() = %v, want %v", names, want)
+		}
+	}
+
+	redis, err := ListSnapshots(project, "redis")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(redis) != 1 || redis[0].Name != "empty" || redis[0].Size != 4 {
+		t.Errorf("ListSnapshots(redis) = %+v", redis)
+	}
+
+	other, err := ListSnapshots(ServiceProject{Name: "other"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(other) != 0 {
+		t.Errorf("ListSnapshots(other) = %+v, want none", other)
+	}
+}
+
+func TestSnapshotNamePattern(t *testing.T) {
+	for name, valid := range map[string]bool{
+		"before-migration": true,
+		"20260101-120000":  true,
+		"v1.2_rc":          true,
+		"":                 false,
+		"-flag":            false,
+		"../escape":        false,
+		"a/b":              false,
+	} {
+		if got := snapshotNamePattern.MatchString(name); got != valid {
+			t.Errorf("snapshotNamePattern(%q) = %v, want %v", name, got, valid)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package docker
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/alienxp03/rize/internal/config"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-connections/nat"
+)
+
+// uiPorts are the container ports of services that serve a web UI on the host
+var uiPorts = map[string]string{
+	"mitmproxy": "8081/tcp",
+}
+
+// ServiceStatus describes a configured service and its container
+type ServiceStatus struct {
+	Service   string        `json:"service"`
+	Enabled   bool          `json:"enabled"`
+	Container string        `json:"container,omitempty"`
+	State     string        `json:"state"`
+	Health    string        `json:"health,omitempty"`
+	StartedAt *time.Time    `json:"started_at,omitempty"`
+	Uptime    time.Duration `json:"-"`
+	Ports     []PortBinding `json:"ports,omitempty"`
+	EnvVar    string        `json:"env_var,omitempty"`
+	URL       string        `json:"url,omitempty"`
+	HostURL   string        `json:"host_url,omitempty"`
+}
+
+// PortBinding is a container port published on the host
+type PortBinding struct {
+	HostIP        string `json:"host_ip"`
+	HostPort      int    `json:"host_port"`
+	ContainerPort int    `json:"container_port"`
+	Protocol      string `json:"protocol"`
+}
+
+func (p PortBinding) String() string {
+	return fmt.Sprintf("%d->%d/%s", p.HostPort, p.ContainerPort, p.Protocol)
+}
+
+// Healthy reports whether an enabled service is running and, when it has a
+// healthcheck, passing it. Disabled services are always healthy.
+func (s ServiceStatus) Healthy() bool {
+	if !s.Enabled {
+		return true
+	}
+	return s.State == "running" && (s.Health == "" || s.Health == container.Healthy)
+}
+
+// ServiceURL returns the environment variable rize sets in the agent container
+// for a service and the URL it points to, or empty strings when there is none
+func ServiceURL(name string, svc config.Service) (string, string) {
+	switch name {
+	case "postgres":
+		user := svc.Environment["POSTGRES_USER"]
+		password := svc.Environment["POSTGRES_PASSWORD"]
+		db := svc.Environment["POSTGRES_DB"]
+		return "DATABASE_URL", fmt.Sprintf("postgresql://%s:%s@%s:5432/%s", user, password, name, db)
+	case "redis":
+		return "REDIS_URL", fmt.Sprintf("redis://%s:6379", name)
+	case "playwright":
+		return "PLAYWRIGHT_URL", fmt.Sprintf("http://%s:3000", name)
+	case "mitmproxy":
+		return "HTTP_PROXY", fmt.Sprintf("http://%s:8080", name)
+	}
+	return "", ""
+}
+
+// Status returns the status of every configured service, plus containers of
+// services that are no longer configured, sorted by name
+func (o *Orchestrator) Status() ([]ServiceStatus, error) {
+	existing, err := o.containers()
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]struct{}{}
+	for name := range o.cfg.Services {
+		names[name] = struct{}{}
+	}
+	for name := range existing {
+		names[name] = struct{}{}
+	}
+
+	var statuses []ServiceStatus
+	for name := range names {
+		svc := o.cfg.Services[name]
+		status := ServiceStatus{
+			Service: name,
+			Enabled: svc.Enabled,
+			State:   "not created",
+		}
+		status.EnvVar, status.URL = ServiceURL(name, svc)
+
+		if ctr, ok := existing[name]; ok {
+			if err := o.inspectStatus(&status, ctr.ID); err != nil {
+				return nil, err
+			}
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Service < statuses[j].Service
+	})
+
+	return statuses, nil
+}
+
+func (o *Orchestrator) inspectStatus(status *ServiceStatus, containerID string) error {
+	inspect, err := o.client.cli.ContainerInspect(o.client.ctx, containerID)
+	if err != nil {
+		return fmt.Errorf("failed to inspect %s: %w", status.Service, err)
+	}
+
+	status.Container = trimName(inspect.Name)
+	if inspect.State == nil {
+		return nil
+	}
+
+	status.State = string(inspect.State.Status)
+	if inspect.State.Health != nil {
+		status.Health = string(inspect.State.Health.Status)
+	}
+	if inspect.State.Running {
+		if started, err := time.Parse(time.RFC3339Nano, inspect.State.StartedAt); err == nil {
+			status.StartedAt = &started
+			status.Uptime = time.Since(started)
+		}
+	}
+
+	if inspect.NetworkSettings != nil {
+		status.Ports = portBindings(inspect.NetworkSettings.Ports)
+	}
+	if port, ok := uiPorts[status.Service]; ok {
+		for _, binding := range status.Ports {
+			if fmt.Sprintf("%d/%s", binding.ContainerPort, binding.Protocol) == port {
+				status.HostURL = fmt.Sprintf("http://localhost:%d", binding.HostPort)
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// portBindings flattens published ports, skipping duplicate IPv6 bindings
+func portBindings(ports nat.PortMap) []PortBinding {
+	var bindings []PortBinding
+	seen := map[string]bool{}
+	for port, hostBindings := range ports {
+		for _, hb := range hostBindings {
+			hostPort, err := strconv.Atoi(hb.HostPort)
+			if err != nil {
+				continue
+			}
+			key := fmt.Sprintf("%d/%s", hostPort, port)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			bindings = append(bindings, PortBinding{
+				HostIP:        hb.HostIP,
+				HostPort:      hostPort,
+				ContainerPort: port.Int(),
+				Protocol:      port.Proto(),
+			})
+		}
+	}
+
+	sort.Slice(bindings, func(i, j int) bool {
+		return bindings[i].ContainerPort < bindings[j].ContainerPort
+	})
+
+	return bindings
+}
+
+func trimName(name string) string {
+	if len(name) > 0 && name[0] == '/' {
+		return name[1:]
+	}
+	return name
+}
@@ -0,0 +1,217 @@
+package docker
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/alienxp03/rize/internal/config"
+	"github.com/containerd/errdefs"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+)
+
+// Kinds of docker resources, in the order they are removed
+const (
+	ResourceContainer = "container"
+	ResourceNetwork   = "network"
+	ResourceVolume    = "volume"
+	ResourceImage     = "image"
+)
+
+// AgentVolume holds the agents' configuration and credentials
+const AgentVolume = "rize-agents"
+
+// legacyVolumePrefix prefixes the vendor volumes of earlier versions
+const legacyVolumePrefix = "rize-vendor"
+
+// ManagedLabel marks the containers, volumes and networks rize creates.
+// Uninstall selects resources by it, so projects of other tools named like
+// rize's are left alone.
+const ManagedLabel = "rize.managed"
+
+// scopedProjectName matches the per-project service project names, named like
+// the project container: rize-<directory>-<6 hex digits>
+var scopedProjectName = regexp.MustCompile(`^rize-[a-z0-9_.-]+-[0-9a-f]{6}$`)
+
+// managedLabels returns labels with ManagedLabel added
+func managedLabels(labels map[string]string) map[string]string {
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[ManagedLabel] = "true"
+	return labels
+}
+
+var resourceOrder = map[string]int{
+	ResourceContainer: 0,
+	ResourceNetwork:   1,
+	ResourceVolume:    2,
+	ResourceImage:     3,
+}
+
+// Resource is a docker resource created by rize
+type Resource struct {
+	Kind string
+	Name string
+	// ID identifies the resource for removal
+	ID string
+	// Size is the size in bytes, -1 when unknown
+	Size int64
+	// Data is set for volumes, which hold service data, agent credentials
+	// and caches
+	Data bool
+}
+
+// Resources returns the containers, networks, volumes and images created by
+// rize, in the order they have to be removed
+func (c *Client) Resources(cfg *config.Config) ([]Resource, error) {
+	usage, err := c.cli.DiskUsage(c.ctx, types.DiskUsageOptions{
+		Types: []types.DiskUsageObject{types.ContainerObject, types.VolumeObject, types.ImageObject},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list docker resources: %w", err)
+	}
+
+	var resources []Resource
+	for _, ctr := range usage.Containers {
+		if !ownedContainer(ctr.Labels) {
+			continue
+		}
+		name := ctr.ID
+		if len(ctr.Names) > 0 {
+			name = strings.TrimPrefix(ctr.Names[0], "/")
+		}
+		resources = append(resources, Resource{Kind: ResourceContainer, Name: name, ID: ctr.ID, Size: ctr.SizeRw})
+	}
+
+	for _, vol := range usage.Volumes {
+		if !ownedVolume(cfg, vol.Name, vol.Labels) {
+			continue
+		}
+		size := int64(-1)
+		if vol.UsageData != nil {
+			size = vol.UsageData.Size
+		}
+		resources = append(resources, Resource{Kind: ResourceVolume, Name: vol.Name, ID: vol.Name, Size: size, Data: true})
+	}
+
+	for _, img := range usage.Images {
+		if !ownedImage(img.RepoTags, img.Labels) {
+			continue
+		}
+		name := img.ID
+		if len(img.RepoTags) > 0 {
+			name = strings.Join(img.RepoTags, ", ")
+		}
+		resources = append(resources, Resource{Kind: ResourceImage, Name: name, ID: img.ID, Size: img.Size})
+	}
+
+	networks, err := c.cli.NetworkList(c.ctx, network.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list networks: %w", err)
+	}
+	for _, net := range networks {
+		if !ownedNetwork(cfg, net.Name, net.Labels) {
+			continue
+		}
+		resources = append(resources, Resource{Kind: ResourceNetwork, Name: net.Name, ID: net.ID, Size: -1})
+	}
+
+	sortResources(resources)
+	return resources, nil
+}
+
+// RemoveResource removes a resource; resources that are already gone are
+// not an error
+func (c *Client) RemoveResource(r Resource) error {
+	var err error
+	switch r.Kind {
+	case ResourceContainer:
+		err = c.cli.ContainerRemove(c.ctx, r.ID, container.RemoveOptions{Force: true, RemoveVolumes: true})
+	case ResourceNetwork:
+		err = c.cli.NetworkRemove(c.ctx, r.ID)
+	case ResourceVolume:
+		err = c.cli.VolumeRemove(c.ctx, r.ID, true)
+	case ResourceImage:
+		_, err = c.cli.ImageRemove(c.ctx, r.ID, image.RemoveOptions{Force: true, PruneChildren: true})
+	default:
+		return fmt.Errorf("unknown resource kind %q", r.Kind)
+	}
+	if err != nil && !errdefs.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// ownedProject reports whether a compose project is a rize service project:
+// the shared project or a per-project one named like the project container.
+// It picks up the service resources of versions before ManagedLabel.
+func ownedProject(name string) bool {
+	return name == SharedProjectName || scopedProjectName.MatchString(name)
+}
+
+// ownedContainer reports whether a container is a project, service or helper
+// container
+func ownedContainer(labels map[string]string) bool {
+	for _, label := range []string{ManagedLabel, ProjectLabel, helperLabel} {
+		if _, ok := labels[label]; ok {
+			return true
+		}
+	}
+	return ownedProject(labels[composeProjectLabel])
+}
+
+// ownedVolume reports whether a volume is the agent volume, a configured
+// volume, a cache, a service volume or a vendor volume of earlier versions
+func ownedVolume(cfg *config.Config, name string, labels map[string]string) bool {
+	for _, label := range []string{ManagedLabel, CacheLabel} {
+		if _, ok := labels[label]; ok {
+			return true
+		}
+	}
+	if name == AgentVolume || strings.HasPrefix(name, legacyVolumePrefix) {
+		return true
+	}
+	for _, vol := range cfg.Volumes {
+		if name == vol {
+			return true
+		}
+	}
+	return ownedProject(labels[composeProjectLabel])
+}
+
+// ownedImage reports whether an image is a rize image or one rize built
+func ownedImage(tags []string, labels map[string]string) bool {
+	if _, ok := labels[BuildLabel]; ok {
+		return true
+	}
+	for _, tag := range tags {
+		if tag == ImageName || strings.HasPrefix(tag, ImageRepository+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// ownedNetwork reports whether a network is the rize network or the network
+// of a per-project service project
+func ownedNetwork(cfg *config.Config, name string, labels map[string]string) bool {
+	if _, ok := labels[ManagedLabel]; ok {
+		return true
+	}
+	return name == cfg.Network.Name || ownedProject(name)
+}
+
+func sortResources(resources []Resource) {
+	sort.SliceStable(resources, func(i, j int) bool {
+		a, b := resources[i], resources[j]
+		if a.Kind != b.Kind {
+			return resourceOrder[a.Kind] < resourceOrder[b.Kind]
+		}
+		return a.Name < b.Name
+	})
+}
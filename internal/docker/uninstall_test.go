@@ -0,0 +1,100 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/alienxp03/rize/internal/config"
+)
+
+func TestOwnedResources(t *testing.T) {
+	cfg := config.DefaultConfig()
+	managed := managedLabels(nil)
+
+	containers := []struct {
+		labels map[string]string
+		want   bool
+	}{
+		{map[string]string{ProjectLabel: "api"}, true},
+		{map[string]string{helperLabel: "snapshot"}, true},
+		{managedLabels(map[string]string{composeProjectLabel: "rize", composeServiceLabel: "postgres"}), true},
+		{map[string]string{composeProjectLabel: "rize", composeServiceLabel: "postgres"}, true},
+		{map[string]string{composeProjectLabel: "rize-api-1a2b3c", composeServiceLabel: "redis"}, true},
+		{map[string]string{composeProjectLabel: "rize-foo", composeServiceLabel: "db"}, false},
+		{map[string]string{composeProjectLabel: "shop", composeServiceLabel: "db"}, false},
+		{nil, false},
+	}
+	for _, tt := range containers {
+		if got := ownedContainer(tt.labels); got != tt.want {
+			t.Errorf("ownedContainer(%v) = %v, want %v", tt.labels, got, tt.want)
+		}
+	}
+
+	volumes := []struct {
+		name   string
+		labels map[string]string
+		want   bool
+	}{
+		{"rize-agents", nil, true},
+		{"rize-postgres", nil, true},
+		{"rize-vendor-node", nil, true},
+		{"rize-cache-npm", map[string]string{CacheLabel: "npm"}, true},
+		{"rize_rize-redis", map[string]string{composeProjectLabel: "rize"}, true},
+		{"rize-api-1a2b3c_rize-redis", map[string]string{composeProjectLabel: "rize-api-1a2b3c"}, true},
+		{"tools_data", managed, true},
+		{"rize-foo_data", map[string]string{composeProjectLabel: "rize-foo"}, false},
+		{"shop_data", map[string]string{composeProjectLabel: "shop"}, false},
+		{"postgres-data", nil, false},
+	}
+	for _, tt := range volumes {
+		if got := ownedVolume(cfg, tt.name, tt.labels); got != tt.want {
+			t.Errorf("ownedVolume(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if !ownedImage([]string{ImageRepository + ":v1.0.0"}, nil) {
+		t.Error("Expected old rize image tags to be owned")
+	}
+	if !ownedImage(nil, map[string]string{BuildLabel: "rize-api-1a2b3c-build"}) {
+		t.Error("Expected built images to be owned")
+	}
+	if ownedImage([]string{"alpine:3.20"}, nil) {
+		t.Error("Expected the helper image not to be owned")
+	}
+
+	networks := []struct {
+		name   string
+		labels map[string]string
+		want   bool
+	}{
+		{"rize", nil, true},
+		{"rize-api-1a2b3c", nil, true},
+		{"team-net", managed, true},
+		{"rize-foo", map[string]string{composeProjectLabel: "rize-foo"}, false},
+		{"bridge", nil, false},
+		{"shop_default", nil, false},
+	}
+	for _, tt := range networks {
+		if got := ownedNetwork(cfg, tt.name, tt.labels); got != tt.want {
+			t.Errorf("ownedNetwork(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSortResources(t *testing.T) {
+	resources := []Resource{
+		{Kind: ResourceImage, Name: "alienxp03/rize:latest"},
+		{Kind: ResourceVolume, Name: "rize-redis"},
+		{Kind: ResourceNetwork, Name: "rize"},
+		{Kind: ResourceContainer, Name: "rize-web-2"},
+		{Kind: ResourceVolume, Name: "rize-agents"},
+		{Kind: ResourceContainer, Name: "rize-api-1"},
+	}
+	sortResources(resources)
+
+	want := []string{"rize-api-1", "rize-web-2", "rize", "rize-agents", "rize-redis", "alienxp03/rize:latest"}
+	for i, r := range resources {
+		if r.Name != want[i] {
+			t.Fatalf("Expected containers, networks, volumes, then images, got %+v", resources)
+		}
+	}
+}
@@ -0,0 +1,407 @@
+package doctor
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/alienxp03/rize/internal/config"
+	"github.com/alienxp03/rize/internal/docker"
+	"github.com/alienxp03/rize/internal/ui"
+	"github.com/alienxp03/rize/internal/version"
+	"github.com/docker/docker/api/types/versions"
+)
+
+// Free disk space below which the disk check warns and fails
+const (
+	lowDisk      = 10 << 30
+	criticalDisk = 2 << 30
+)
+
+func (r *Report) checkDaemon(client *docker.Client) Check {
+	check := Check{Name: "Docker daemon"}
+
+	var err error
+	if client == nil {
+		err = fmt.Errorf("failed to create the Docker client")
+	} else {
+		r.Daemon, err = client.Daemon()
+	}
+	if err != nil {
+		check.Status = Fail
+		check.Message = err.Error()
+		check.Hint = daemonHint()
+		return check
+	}
+
+	check.Status = Pass
+	check.Message = fmt.Sprintf("Docker %s on %s (%s)", r.Daemon.Version, r.Daemon.OS, r.Daemon.Arch)
+	return check
+}
+
+func daemonHint() string {
+	if host := os.Getenv("DOCKER_HOST"); host != "" {
+		return fmt.Sprintf("DOCKER_HOST is set to %s; make sure a daemon is listening there", host)
+	}
+	if runtime.GOOS == "darwin" {
+		return "Start Docker Desktop (or Colima/OrbStack) and try again"
+	}
+	return "Start the daemon (sudo systemctl start docker) and make sure your user may use it (sudo usermod -aG docker $USER)"
+}
+
+// checkAPIVersion compares the API version of the daemon with the one rize
+// is built against
+func checkAPIVersion(info *docker.DaemonInfo) Check {
+	check := Check{Name: "Docker API version"}
+	switch {
+	case info.MinAPIVersion != "" && versions.LessThan(info.ClientAPIVersion, info.MinAPIVersion):
+		check.Status = Fail
+		check.Message = fmt.Sprintf("the daemon requires API %s or newer, rize uses %s", info.MinAPIVersion, info.ClientAPIVersion)
+		check.Hint = "Update rize with rize update"
+	case versions.LessThan(info.APIVersion, info.ClientAPIVersion):
+		check.Status = Warn
+		check.Message = fmt.Sprintf("the daemon speaks API %s, older than the %s rize is built for", info.APIVersion, info.ClientAPIVersion)
+		check.Hint = "Update Docker; older daemons may reject options rize uses"
+	default:
+		check.Status = Pass
+		check.Message = fmt.Sprintf("API %s", info.APIVersion)
+	}
+	return check
+}
+
+func checkCompose() Check {
+	check := Check{Name: "Compose plugin", Status: Warn}
+	hint := "rize runs services itself; the compose plugin is only needed to run files written by rize services export"
+
+	if _, err := exec.LookPath("docker"); err != nil {
+		check.Message = "the docker CLI is not installed"
+		check.Hint = hint
+		return check
+	}
+	cmd := exec.Command("docker", "compose", "version", "--short")
+	start := time.Now()
+	out, err := cmd.Output()
+	traceCommand(cmd, err, time.Since(start))
+	if err != nil {
+		check.Message = "docker compose is not installed"
+		check.Hint = hint
+		return check
+	}
+
+	check.Status = Pass
+	check.Message = "docker compose " + strings.TrimSpace(string(out))
+	return check
+}
+
+// traceCommand logs an external command at trace level
+func traceCommand(cmd *exec.Cmd, err error, elapsed time.Duration) {
+	result := "ok"
+	if err != nil {
+		result = err.Error()
+	}
+	ui.Trace("exec %s: %s (%s)", strings.Join(cmd.Args, " "), result, elapsed.Round(time.Microsecond))
+}
+
+func (r *Report) checkImage(client *docker.Client) Check {
+	check := Check{Name: "Image"}
+
+	image, err := client.ImageInfo(docker.ImageName)
+	switch {
+	case err != nil:
+		check.Status = Fail
+		check.Message = err.Error()
+		return check
+	case image == nil:
+		check.Status = Fail
+		check.Message = fmt.Sprintf("%s is not pulled", docker.ImageName)
+		check.Hint = fmt.Sprintf("Run rize update, or docker pull %s", docker.ImageName)
+		return check
+	}
+
+	r.Image = image
+	id := image.Digest
+	if id == "" {
+		id = image.ID
+	}
+	if image.Version != "" && version.IsRelease(version.Version) && image.Version != version.Version {
+		check.Status = Warn
+		check.Message = fmt.Sprintf("%s is version %s, the binary is %s", docker.ImageName, image.Version, version.Version)
+		check.Hint = "Run rize update to pull the image of this version"
+		return check
+	}
+
+	check.Status = Pass
+	check.Message = fmt.Sprintf("%s (%s)", docker.ImageName, id)
+	return check
+}
+
+// diskPaths returns the directories whose file systems hold rize data: the
+// home directory and, when the daemon runs on this host, its data directory
+func (r *Report) diskPaths() []string {
+	var paths []string
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, home)
+	}
+	if r.Daemon != nil && !r.Daemon.Desktop && r.Daemon.RootDir != "" {
+		if _, err := os.Stat(r.Daemon.RootDir); err == nil {
+			paths = append(paths, r.Daemon.RootDir)
+		}
+	}
+	return paths
+}
+
+func checkDisk(paths []string) Check {
+	check := Check{Name: "Disk space", Status: Pass}
+
+	var free []string
+	for _, path := range paths {
+		var st syscall.Statfs_t
+		if err := syscall.Statfs(path, &st); err != nil {
+			continue
+		}
+		available := uint64(st.Bavail) * uint64(st.Bsize)
+		free = append(free, fmt.Sprintf("%.1f GiB free in %s", float64(available)/(1<<30), path))
+		if status := diskStatus(available); worse(status, check.Status) {
+			check.Status = status
+		}
+	}
+
+	if len(free) == 0 {
+		check.Status = Skip
+		check.Message = "could not read the free disk space"
+		return check
+	}
+	check.Message = strings.Join(free, ", ")
+	if check.Status != Pass {
+		check.Hint = "Free up space; rize cache clear and docker system prune remove caches and unused images"
+	}
+	return check
+}
+
+// diskStatus rates the free space of a file system
+func diskStatus(free uint64) Status {
+	switch {
+	case free < criticalDisk:
+		return Fail
+	case free < lowDisk:
+		return Warn
+	default:
+		return Pass
+	}
+}
+
+// worse reports whether status a is worse than b
+func worse(a, b Status) bool {
+	rank := map[Status]int{Skip: 0, Pass: 1, Warn: 2, Fail: 3}
+	return rank[a] > rank[b]
+}
+
+// hostPort is a port a service publishes on the host
+type hostPort struct {
+	ip   string
+	port int
+}
+
+// hostPorts returns the host ports of port mappings like "8080:80",
+// "127.0.0.1:8080:80/tcp"; container-only ports and ranges are skipped
+func hostPorts(ports []string) []hostPort {
+	var result []hostPort
+	for _, mapping := range ports {
+		mapping, _, _ = strings.Cut(mapping, "/")
+		parts := strings.Split(mapping, ":")
+
+		var p hostPort
+		var port string
+		switch len(parts) {
+		case 2:
+			port = parts[0]
+		case 3:
+			p.ip, port = parts[0], parts[1]
+		default:
+			continue
+		}
+
+		n, err := strconv.Atoi(port)
+		if err != nil {
+			continue
+		}
+		p.port = n
+		result = append(result, p)
+	}
+	return result
+}
+
+// checkPorts checks that the host ports of the enabled services are free or
+// held by the services themselves
+func checkPorts(cfg *config.Config, statuses []docker.ServiceStatus) Check {
+	check := Check{Name: "Ports"}
+	if cfg.ServiceScope == config.ServiceScopeProject {
+		check.Status = Pass
+		check.Message = "per-project services publish on free ports"
+		return check
+	}
+
+	var names []string
+	for name, svc := range cfg.Services {
+		if svc.Enabled {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	checked := 0
+	var busy []string
+	for _, name := range names {
+		for _, p := range hostPorts(cfg.Services[name].Ports) {
+			checked++
+			if publishedBy(statuses, name, p.port) || portFree(p) {
+				continue
+			}
+			busy = append(busy, fmt.Sprintf("%d (%s)", p.port, name))
+		}
+	}
+
+	if len(busy) > 0 {
+		check.Status = Fail
+		check.Message = "in use by another process: " + strings.Join(busy, ", ")
+		check.Hint = "Stop the process using the port (lsof -i :PORT) or change the service ports in ~/.config/rize/config.yml"
+		return check
+	}
+	check.Status = Pass
+	check.Message = fmt.Sprintf("%d published ports available", checked)
+	return check
+}
+
+// publishedBy reports whether a running service publishes a host port
+func publishedBy(statuses []docker.ServiceStatus, service string, port int) bool {
+	for _, status := range statuses {
+		if status.Service != service || status.State != "running" {
+			continue
+		}
+		for _, binding := range status.Ports {
+			if binding.HostPort == port {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func portFree(p hostPort) bool {
+	l, err := net.Listen("tcp", net.JoinHostPort(p.ip, strconv.Itoa(p.port)))
+	if err != nil {
+		return false
+	}
+	l.Close()
+	return true
+}
+
+func checkSSHAgent(daemon *docker.DaemonInfo) Check {
+	desktopMac := daemon != nil && daemon.Desktop && runtime.GOOS == "darwin"
+	return sshAgentCheck(os.Getenv("SSH_AUTH_SOCK"), desktopMac)
+}
+
+// sshAgentCheck checks that the agent socket rize mounts into the container
+// exists and can be mounted
+func sshAgentCheck(sock string, desktopMac bool) Check {
+	check := Check{Name: "SSH agent"}
+	if sock == "" {
+		check.Status = Skip
+		check.Message = "SSH_AUTH_SOCK is not set, agent forwarding is off"
+		return check
+	}
+
+	info, err := os.Stat(sock)
+	switch {
+	case err != nil:
+		check.Status = Fail
+		check.Message = fmt.Sprintf("SSH_AUTH_SOCK points to %s, which does not exist", sock)
+		check.Hint = "Start an ssh-agent or unset SSH_AUTH_SOCK; rize mounts it into the container"
+	case info.Mode()&os.ModeSocket == 0:
+		check.Status = Fail
+		check.Message = fmt.Sprintf("SSH_AUTH_SOCK points to %s, which is not a socket", sock)
+		check.Hint = "Point SSH_AUTH_SOCK at your agent's socket or unset it"
+	case desktopMac:
+		check.Status = Warn
+		check.Message = fmt.Sprintf("Docker Desktop cannot mount the macOS agent socket %s", sock)
+		check.Hint = "Unset SSH_AUTH_SOCK before running rize and use the keys in ~/.ssh, which rize mounts"
+	default:
+		check.Status = Pass
+		check.Message = fmt.Sprintf("%s is forwarded into the container", sock)
+	}
+	return check
+}
+
+// checkUID checks that the agent, which runs with the host UID, can write
+// the workspace and that the project container was created for this user
+func checkUID(cwd string, container *docker.ProjectContainer) Check {
+	check := Check{Name: "User ID", Status: Warn}
+	uid := os.Getuid()
+
+	if uid == 0 {
+		check.Message = "rize runs as root"
+		check.Hint = "Run rize as your user so the files the agent writes in the workspace are owned by you"
+		return check
+	}
+
+	if info, err := os.Stat(cwd); err == nil {
+		if st, ok := info.Sys().(*syscall.Stat_t); ok && int(st.Uid) != uid {
+			check.Message = fmt.Sprintf("the workspace is owned by UID %d, rize runs as UID %d", st.Uid, uid)
+			check.Hint = "The agent writes files as your UID; chown the workspace or run rize as its owner"
+			return check
+		}
+	}
+
+	if container != nil && container.HostUID != "" && container.HostUID != strconv.Itoa(uid) {
+		check.Message = fmt.Sprintf("%s was created for UID %s, you are UID %d", container.Name, container.HostUID, uid)
+		check.Hint = fmt.Sprintf("Remove it with docker rm -f %s so rize recreates it for you", container.Name)
+		return check
+	}
+
+	check.Status = Pass
+	check.Message = fmt.Sprintf("UID %d owns the workspace", uid)
+	return check
+}
+
+// checkMitmproxyCA checks that the entrypoint installed the mitmproxy CA in
+// the project container, so the agents trust the proxy
+func (r *Report) checkMitmproxyCA(client *docker.Client, cfg *config.Config) Check {
+	check := Check{Name: "mitmproxy CA", Status: Skip}
+	container := r.Container
+
+	switch {
+	case !cfg.Services["mitmproxy"].Enabled:
+		check.Message = "mitmproxy is disabled"
+		return check
+	case container == nil || !container.Running():
+		check.Message = "the project container is not running"
+		return check
+	case !strings.Contains(container.Proxy, "mitmproxy"):
+		check.Status = Warn
+		check.Message = fmt.Sprintf("%s was created while mitmproxy was not running, its traffic is not proxied", container.Name)
+		check.Hint = "Run rize services up; the container is recreated behind the proxy once no sessions are running in it"
+		return check
+	}
+
+	installed, err := client.MitmproxyCAInstalled(container.ID)
+	switch {
+	case err != nil:
+		check.Status = Fail
+		check.Message = err.Error()
+	case !installed:
+		check.Status = Fail
+		check.Message = fmt.Sprintf("the CA certificate is not installed in %s, HTTPS requests through the proxy fail", container.Name)
+		check.Hint = fmt.Sprintf("The entrypoint fetches it from mitmproxy at startup; check rize services logs mitmproxy, then recreate the container with docker rm -f %s", container.Name)
+	default:
+		check.Status = Pass
+		check.Message = fmt.Sprintf("installed in %s", container.Name)
+	}
+	return check
+}
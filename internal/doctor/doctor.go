@@ -0,0 +1,213 @@
+// Package doctor diagnoses the environment rize runs in
+package doctor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alienxp03/rize/internal/config"
+	"github.com/alienxp03/rize/internal/docker"
+	"github.com/alienxp03/rize/internal/redact"
+	"github.com/alienxp03/rize/internal/version"
+	"gopkg.in/yaml.v3"
+)
+
+// Status is the result of a check
+type Status string
+
+const (
+	Pass Status = "pass"
+	Warn Status = "warn"
+	Fail Status = "fail"
+	// Skip is used when a check does not apply or cannot run
+	Skip Status = "skip"
+)
+
+// Check is the result of one diagnostic
+type Check struct {
+	Name    string `json:"name"`
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+	// Hint tells how to fix a warning or failure
+	Hint string `json:"hint,omitempty"`
+}
+
+// Report is the result of the checks and the state they ran against, meant
+// to be pasted into bug reports
+type Report struct {
+	Version   version.Info             `json:"version"`
+	Checks    []Check                  `json:"checks"`
+	Daemon    *docker.DaemonInfo       `json:"daemon,omitempty"`
+	ImageRef  string                   `json:"image_ref"`
+	Image     *docker.ImageInfo        `json:"image,omitempty"`
+	Container *docker.ProjectContainer `json:"container,omitempty"`
+	Services  []docker.ServiceStatus   `json:"services,omitempty"`
+	// ProjectConfig and DevContainer are the project files applied
+	ProjectConfig string `json:"project_config,omitempty"`
+	DevContainer  string `json:"devcontainer,omitempty"`
+	// Config is the effective configuration with secrets redacted
+	Config map[string]interface{} `json:"config"`
+}
+
+// Failed returns the number of failed checks
+func (r *Report) Failed() int {
+	failed := 0
+	for _, check := range r.Checks {
+		if check.Status == Fail {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Run runs the checks for the workspace at cwd. client is nil when no
+// Docker client could be created; the checks that need Docker are skipped.
+func Run(cfg *config.Config, client *docker.Client, cwd string) *Report {
+	r := &Report{Version: version.Get(), ImageRef: docker.ImageName}
+	if cfg.Project != nil {
+		r.ProjectConfig = cfg.Project.Path
+	}
+	if cfg.DevContainer != nil {
+		r.DevContainer = cfg.DevContainer.Path
+	}
+
+	// The pricing table is left out, it is long and unrelated to the
+	// environment
+	var err error
+	if r.Config, err = EffectiveConfig(cfg); err != nil {
+		r.Config = map[string]interface{}{"error": err.Error()}
+	}
+	delete(r.Config, "pricing")
+
+	r.add(r.checkDaemon(client))
+	available := r.Daemon != nil
+	if available {
+		r.add(checkAPIVersion(r.Daemon))
+	} else {
+		r.add(skipped("Docker API version"))
+	}
+	r.add(checkCompose())
+
+	if available {
+		r.inspect(client, cfg, cwd)
+		r.add(r.checkImage(client))
+	} else {
+		r.add(skipped("Image"))
+	}
+
+	r.add(checkDisk(r.diskPaths()))
+	r.add(checkPorts(cfg, r.Services))
+	r.add(checkSSHAgent(r.Daemon))
+	r.add(checkUID(cwd, r.Container))
+
+	if available {
+		r.add(r.checkMitmproxyCA(client, cfg))
+	} else {
+		r.add(skipped("mitmproxy CA"))
+	}
+
+	return r
+}
+
+func (r *Report) add(check Check) {
+	r.Checks = append(r.Checks, check)
+}
+
+// inspect records the project container and the services of the workspace
+func (r *Report) inspect(client *docker.Client, cfg *config.Config, cwd string) {
+	if container, err := client.ProjectContainer(cwd); err == nil {
+		r.Container = container
+	}
+
+	project, err := docker.ResolveServiceProject(cfg, cwd)
+	if err != nil {
+		return
+	}
+	if statuses, err := docker.NewOrchestrator(client, cfg, project).Status(); err == nil {
+		r.Services = statuses
+	}
+}
+
+func skipped(name string) Check {
+	return Check{Name: name, Status: Skip, Message: "Docker is not available"}
+}
+
+// sensitiveKeys mark environment variables whose values are redacted
+var sensitiveKeys = []string{"KEY", "TOKEN", "SECRET", "PASSWORD", "PASSWD", "CREDENTIAL", "AUTH", "PRIVATE"}
+
+// sensitiveSuffixes mark environment variables like GITLAB_PAT; PAT alone
+// would also match PATH
+var sensitiveSuffixes = []string{"_PAT", "_PWD", "_DSN"}
+
+// EffectiveConfig returns the configuration as it is written in config.yml,
+// with the values of sensitive environment variables redacted and every
+// other value run through the redaction rules
+func EffectiveConfig(cfg *config.Config) (map[string]interface{}, error) {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	var result map[string]interface{}
+	if err := yaml.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode config: %w", err)
+	}
+
+	redactEnv(result["environment"])
+	if services, ok := result["services"].(map[string]interface{}); ok {
+		for _, svc := range services {
+			if svc, ok := svc.(map[string]interface{}); ok {
+				redactEnv(svc["environment"])
+			}
+		}
+	}
+	redactValues(result, redact.Default())
+	return result, nil
+}
+
+func redactEnv(env interface{}) {
+	vars, ok := env.(map[string]interface{})
+	if !ok {
+		return
+	}
+	for key, value := range vars {
+		if value == "" || value == nil {
+			continue
+		}
+		if sensitiveKey(key) {
+			vars[key] = redact.Placeholder
+		}
+	}
+}
+
+func sensitiveKey(key string) bool {
+	upper := strings.ToUpper(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(upper, sensitive) {
+			return true
+		}
+	}
+	for _, suffix := range sensitiveSuffixes {
+		if strings.HasSuffix(upper, suffix) {
+			return true
+		}
+	}
+	return upper == "PAT"
+}
+
+// redactValues replaces the secrets the redaction rules detect in every
+// string of a decoded document, like a DATABASE_URL or a token in a command
+func redactValues(value interface{}, r *redact.Redactor) interface{} {
+	switch v := value.(type) {
+	case string:
+		return r.String(v)
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = redactValues(item, r)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValues(item, r)
+		}
+	}
+	return value
+}
@@ -0,0 +1,272 @@
+package recording
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/alienxp03/rize/internal/redact"
+	"github.com/alienxp03/rize/internal/session"
+)
+
+// Header is the first line of an asciicast v2 file. Rize stores the session
+// metadata under the "rize" key, which asciicast players ignore.
+type Header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Duration  float64           `json:"duration,omitempty"`
+	Command   string            `json:"command,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+	// Dropped counts the output events left out because the disk writer
+	// fell behind
+	Dropped int              `json:"dropped,omitempty"`
+	Rize    *session.Session `json:"rize,omitempty"`
+}
+
+type event struct {
+	at   time.Duration
+	data []byte
+}
+
+// Recorder tees terminal output into an asciicast v2 file.
+//
+// Writes only hand a copy of the data to a background goroutine, so the
+// terminal stream is never blocked on disk I/O; when the goroutine falls
+// behind, events are dropped and counted instead. Events are spooled to a
+// ".part" file and the final cast (with duration and exit code in the header)
+// is assembled on Close.
+type Recorder struct {
+	path   string
+	header Header
+	start  time.Time
+
+	mu      sync.Mutex
+	closed  bool
+	dropped int
+	events  chan event
+	done    chan error
+
+	part     *os.File
+	pending  []byte
+	redactor *redact.Redactor
+}
+
+// maxPendingBytes bounds how much output is held back waiting for a newline
+const maxPendingBytes = 4096
+
+// Start creates a recorder writing to path. Recordings are full terminal
+// transcripts, so they and the project and recordings directories above them
+// are only accessible to the user.
+func Start(path string, header Header) (*Recorder, error) {
+	projectDir := filepath.Dir(path)
+	if err := os.MkdirAll(projectDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create recordings directory: %w", err)
+	}
+	// Directories created by earlier versions are world-readable
+	for _, dir := range []string{filepath.Dir(projectDir), projectDir} {
+		if err := os.Chmod(dir, 0700); err != nil {
+			return nil, fmt.Errorf("failed to create recordings directory: %w", err)
+		}
+	}
+
+	part, err := os.OpenFile(path+".part", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording: %w", err)
+	}
+
+	header.Version = 2
+	if header.Timestamp == 0 {
+		header.Timestamp = time.Now().Unix()
+	}
+
+	r := &Recorder{
+		path:     path,
+		header:   header,
+		start:    time.Now(),
+		events:   make(chan event, 1024),
+		done:     make(chan error, 1),
+		part:     part,
+		redactor: redact.Default(),
+	}
+	go r.run()
+
+	return r, nil
+}
+
+// Path returns the final path of the recording
+func (r *Recorder) Path() string {
+	return r.path
+}
+
+// Write records an output event. It never fails so it is safe to use in an
+// io.MultiWriter next to the real terminal.
+func (r *Recorder) Write(p []byte) (int, error) {
+	data := make([]byte, len(p))
+	copy(data, p)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return len(p), nil
+	}
+	select {
+	case r.events <- event{at: time.Since(r.start), data: data}:
+	default:
+		r.dropped++
+	}
+
+	return len(p), nil
+}
+
+// Dropped returns the number of output events left out of the recording
+func (r *Recorder) Dropped() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.dropped
+}
+
+// Close flushes pending events and writes the final cast file including the
+// finished session metadata.
+func (r *Recorder) Close(s *session.Session) error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	close(r.events)
+	r.mu.Unlock()
+
+	partPath := r.part.Name()
+	defer os.Remove(partPath)
+
+	if err := <-r.done; err != nil {
+		r.part.Close()
+		return err
+	}
+
+	header := r.header
+	header.Duration = time.Since(r.start).Seconds()
+	header.Dropped = r.dropped
+	header.Rize = s
+
+	return r.assemble(header)
+}
+
+func (r *Recorder) run() {
+	w := bufio.NewWriter(r.part)
+	var writeErr error
+
+	for ev := range r.events {
+		if writeErr != nil {
+			continue
+		}
+		writeErr = r.writeEvent(w, ev, false)
+	}
+
+	if writeErr == nil && len(r.pending) > 0 {
+		writeErr = r.writeEvent(w, event{at: time.Since(r.start)}, true)
+	}
+	if writeErr == nil {
+		writeErr = w.Flush()
+	}
+
+	r.done <- writeErr
+}
+
+// writeEvent writes a single output event. Unless final is set, output after
+// the last newline is held back until more arrives, so that secrets split
+// across reads are redacted as a whole and multi-byte characters are not
+// mangled. This only delays the recording, never the terminal.
+func (r *Recorder) writeEvent(w io.Writer, ev event, final bool) error {
+	data := append(r.pending, ev.data...)
+	r.pending = nil
+
+	if !final {
+		cut := incompleteRuneStart(data)
+		if nl := bytes.LastIndexByte(data[:cut], '\n'); cut-(nl+1) < maxPendingBytes {
+			cut = nl + 1
+		}
+		r.pending = append([]byte(nil), data[cut:]...)
+		data = data[:cut]
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+
+	data = r.redactor.Bytes(data)
+	line, err := json.Marshal([]interface{}{roundSeconds(ev.at), "o", string(data)})
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "%s\n", line)
+	return err
+}
+
+func (r *Recorder) assemble(header Header) error {
+	if _, err := r.part.Seek(0, io.SeekStart); err != nil {
+		r.part.Close()
+		return err
+	}
+	defer r.part.Close()
+
+	tmpPath := r.path + ".tmp"
+	out, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write recording: %w", err)
+	}
+	defer os.Remove(tmpPath)
+
+	headerLine, err := json.Marshal(header)
+	if err != nil {
+		out.Close()
+		return err
+	}
+
+	if _, err := fmt.Fprintf(out, "%s\n", headerLine); err != nil {
+		out.Close()
+		return err
+	}
+
+	if _, err := io.Copy(out, r.part); err != nil {
+		out.Close()
+		return err
+	}
+
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, r.path)
+}
+
+// incompleteRuneStart returns the index where a trailing, incomplete UTF-8
+// sequence begins, or len(data) if the data ends on a rune boundary.
+func incompleteRuneStart(data []byte) int {
+	n := len(data)
+	for i := n - 1; i >= 0 && i >= n-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				return i
+			}
+			break
+		}
+	}
+	return n
+}
+
+func roundSeconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1e6
+}
@@ -0,0 +1,173 @@
+package recording
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alienxp03/rize/internal/config"
+	"github.com/alienxp03/rize/internal/redact"
+	"github.com/alienxp03/rize/internal/session"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "demo", "test.cast")
+
+	rec, err := Start(path, Header{Width: 100, Height: 30, Command: "claude"})
+	if err != nil {
+		t.Fatalf("Failed to start recording: %v", err)
+	}
+
+	// Split a multi-byte character across two writes
+	snowman := []byte("☃")
+	rec.Write([]byte("hello "))
+	rec.Write(snowman[:1])
+	rec.Write(snowman[1:])
+	rec.Write([]byte("\r\n"))
+
+	sess := session.New("demo", "claude", []string{"--resume"}, "alienxp03/rize:latest")
+	sess.Finish(3)
+	if err := rec.Close(sess); err != nil {
+		t.Fatalf("Failed to close recording: %v", err)
+	}
+
+	if _, err := os.Stat(path + ".part"); !os.IsNotExist(err) {
+		t.Error("Spool file should be removed after close")
+	}
+	for p, want := range map[string]os.FileMode{path: 0600, filepath.Dir(path): 0700, filepath.Dir(filepath.Dir(path)): 0700} {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != want {
+			t.Errorf("Expected %s to have mode %v, got %v", p, want, info.Mode().Perm())
+		}
+	}
+
+	header, err := ReadHeader(path)
+	if err != nil {
+		t.Fatalf("Failed to read header: %v", err)
+	}
+	if header.Version != 2 || header.Width != 100 || header.Height != 30 {
+		t.Errorf("Unexpected header: %+v", header)
+	}
+	if header.Rize == nil || header.Rize.ExitCode != 3 || header.Rize.Agent != "claude" {
+		t.Errorf("Expected session metadata in header, got %+v", header.Rize)
+	}
+
+	var out bytes.Buffer
+	if err := Replay(&out, path, ReplayOptions{Speed: 1000}); err != nil {
+		t.Fatalf("Failed to replay: %v", err)
+	}
+	if out.String() != "hello ☃\r\n" {
+		t.Errorf("Unexpected replay output: %q", out.String())
+	}
+}
+
+func TestRecordRedactsSplitSecret(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "demo", "test.cast")
+
+	rec, err := Start(path, Header{Width: 80, Height: 24})
+	if err != nil {
+		t.Fatalf("Failed to start recording: %v", err)
+	}
+
+	rec.Write([]byte("export KEY=sk-ant-api03-AbCdEfGhIj"))
+	rec.Write([]byte("KlMnOpQrStUvWxYz012345\r\n"))
+
+	if err := rec.Close(session.New("demo", "shell", nil, "")); err != nil {
+		t.Fatalf("Failed to close recording: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("sk-ant")) || !bytes.Contains(data, []byte("[REDACTED]")) {
+		t.Errorf("Expected secret to be redacted:\n%s", data)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	for _, id := range []string{"20250101-000000-aaaa", "20250102-000000-bbbb", "20250103-000000-cccc"} {
+		path, err := Path("demo", id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec, err := Start(path, Header{Width: 80, Height: 24})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := rec.Close(session.New("demo", "zsh", nil, "")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := Prune(0, 2)
+	if err != nil {
+		t.Fatalf("Failed to prune: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Expected 1 recording removed, got %d", removed)
+	}
+
+	recordings, err := List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recordings) != 2 || recordings[0].ID != "20250103-000000-cccc" {
+		t.Errorf("Expected the two newest recordings to remain, got %+v", recordings)
+	}
+
+	if _, err := Find("20250102"); err != nil {
+		t.Errorf("Expected prefix lookup to succeed: %v", err)
+	}
+}
+
+func TestRecordDropsWhenWriterFallsBehind(t *testing.T) {
+	// Nothing reads the events, like a writer stalled on disk
+	rec := &Recorder{start: time.Now(), events: make(chan event, 1)}
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			rec.Write([]byte("line\r\n"))
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Write blocked on a stalled writer")
+	}
+	if got := rec.Dropped(); got != 9 {
+		t.Errorf("Dropped() = %d, want 9", got)
+	}
+}
+
+func TestReplayAppliesConfiguredRedaction(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "old.cast")
+	cast := `{"version":2,"width":80,"height":24,"timestamp":1768471200}` + "\n" + `[0.1,"o","key corp-123456\r\n"]` + "\n"
+	if err := os.WriteFile(path, []byte(cast), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := redact.Configure(config.RedactionConfig{Patterns: []string{`corp-[0-9]{6}`}}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { redact.Configure(config.RedactionConfig{}) })
+
+	var out bytes.Buffer
+	if err := Replay(&out, path, ReplayOptions{Speed: 1000}); err != nil {
+		t.Fatalf("Failed to replay: %v", err)
+	}
+	if got := out.String(); got != "key "+redact.Placeholder+"\r\n" {
+		t.Errorf("Expected the configured pattern to be redacted, got %q", got)
+	}
+}
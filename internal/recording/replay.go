@@ -0,0 +1,76 @@
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/alienxp03/rize/internal/redact"
+)
+
+// ReplayOptions controls playback of a recording
+type ReplayOptions struct {
+	// Speed multiplies playback speed; values <= 0 mean real time
+	Speed float64
+	// MaxIdle caps the pause between two events; zero means no cap
+	MaxIdle time.Duration
+}
+
+// Replay plays the recording at path to w, honouring the recorded timing.
+// Output is passed through the default redactor again, set up with
+// redact.Configure, in case the recording predates the current rules.
+func Replay(w io.Writer, path string, opts ReplayOptions) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open recording: %w", err)
+	}
+	defer file.Close()
+
+	speed := opts.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	// Skip the header line
+	if !scanner.Scan() {
+		return fmt.Errorf("recording is empty")
+	}
+
+	redactor := redact.Default()
+
+	var previous float64
+	for scanner.Scan() {
+		var ev []interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil || len(ev) != 3 {
+			continue
+		}
+
+		at, ok := ev[0].(float64)
+		kind, _ := ev[1].(string)
+		data, _ := ev[2].(string)
+		if !ok || kind != "o" {
+			continue
+		}
+
+		delay := time.Duration((at - previous) / speed * float64(time.Second))
+		if opts.MaxIdle > 0 && delay > opts.MaxIdle {
+			delay = opts.MaxIdle
+		}
+		if delay > 0 {
+			time.Sleep(delay)
+		}
+		previous = at
+
+		if _, err := io.WriteString(w, redactor.String(data)); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
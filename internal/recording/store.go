@@ -0,0 +1,170 @@
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alienxp03/rize/internal/config"
+)
+
+const castExt = ".cast"
+
+// Recording is a finished session recording on disk
+type Recording struct {
+	ID      string
+	Project string
+	Path    string
+	Size    int64
+	Header  Header
+}
+
+// StartedAt returns the time the recording started
+func (r Recording) StartedAt() time.Time {
+	return time.Unix(r.Header.Timestamp, 0)
+}
+
+// Dir returns the directory recordings are stored in (~/.rize/recordings)
+func Dir() (string, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dataDir, "recordings"), nil
+}
+
+// Path returns the path of the recording for the given project and session ID
+func Path(project, id string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, project, id+castExt), nil
+}
+
+// List returns all recordings, newest first
+func List() ([]Recording, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*", "*"+castExt))
+	if err != nil {
+		return nil, err
+	}
+
+	var recordings []Recording
+	for _, path := range paths {
+		header, err := ReadHeader(path)
+		if err != nil {
+			continue
+		}
+
+		var size int64
+		if info, err := os.Stat(path); err == nil {
+			size = info.Size()
+		}
+
+		recordings = append(recordings, Recording{
+			ID:      strings.TrimSuffix(filepath.Base(path), castExt),
+			Project: filepath.Base(filepath.Dir(path)),
+			Path:    path,
+			Size:    size,
+			Header:  header,
+		})
+	}
+
+	sort.Slice(recordings, func(i, j int) bool {
+		return recordings[i].ID > recordings[j].ID
+	})
+
+	return recordings, nil
+}
+
+// Find returns the recording with the given ID. A unique ID prefix is accepted.
+func Find(id string) (*Recording, error) {
+	recordings, err := List()
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []Recording
+	for _, rec := range recordings {
+		if rec.ID == id {
+			return &rec, nil
+		}
+		if strings.HasPrefix(rec.ID, id) {
+			matches = append(matches, rec)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("recording not found: %s", id)
+	case 1:
+		return &matches[0], nil
+	default:
+		return nil, fmt.Errorf("recording ID %s is ambiguous (%d matches)", id, len(matches))
+	}
+}
+
+// ReadHeader reads the asciicast header of the file at path
+func ReadHeader(path string) (Header, error) {
+	var header Header
+
+	file, err := os.Open(path)
+	if err != nil {
+		return header, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	line, err := reader.ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		return header, fmt.Errorf("failed to read recording header: %w", err)
+	}
+
+	if err := json.Unmarshal(line, &header); err != nil {
+		return header, fmt.Errorf("invalid recording header: %w", err)
+	}
+
+	if header.Version != 2 {
+		return header, fmt.Errorf("unsupported asciicast version %d", header.Version)
+	}
+
+	return header, nil
+}
+
+// Prune removes recordings older than retentionDays and keeps at most
+// maxRecordings of the newest ones. Zero disables the respective limit.
+func Prune(retentionDays, maxRecordings int) (int, error) {
+	recordings, err := List()
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -retentionDays)
+	removed := 0
+	for i, rec := range recordings {
+		expired := retentionDays > 0 && rec.StartedAt().Before(cutoff)
+		overLimit := maxRecordings > 0 && i >= maxRecordings
+		if !expired && !overLimit {
+			continue
+		}
+
+		if err := os.Remove(rec.Path); err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("failed to remove recording %s: %w", rec.ID, err)
+		}
+		removed++
+	}
+
+	return removed, nil
+}
@@ -0,0 +1,55 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// Session describes a single agent or command run inside the rize container
+type Session struct {
+	ID        string    `json:"id"`
+	Project   string    `json:"project"`
+	Agent     string    `json:"agent"`
+	Args      []string  `json:"args,omitempty"`
+	Image     string    `json:"image"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at,omitempty"`
+	ExitCode  int       `json:"exit_code"`
+}
+
+// New creates a session that starts now
+func New(project, agent string, args []string, image string) *Session {
+	now := time.Now()
+	return &Session{
+		ID:        NewID(now),
+		Project:   project,
+		Agent:     agent,
+		Args:      args,
+		Image:     image,
+		StartedAt: now,
+	}
+}
+
+// NewID returns a session ID made of the start timestamp and a short random suffix
+func NewID(t time.Time) string {
+	suffix := make([]byte, 2)
+	if _, err := rand.Read(suffix); err != nil {
+		return t.Format("20060102-150405")
+	}
+	return t.Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
+
+// Finish marks the session as ended with the given exit code
+func (s *Session) Finish(exitCode int) {
+	s.EndedAt = time.Now()
+	s.ExitCode = exitCode
+}
+
+// Duration returns how long the session ran
+func (s *Session) Duration() time.Duration {
+	if s.EndedAt.IsZero() {
+		return time.Since(s.StartedAt)
+	}
+	return s.EndedAt.Sub(s.StartedAt)
+}
@@ -0,0 +1,60 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/alienxp03/rize/internal/config"
+)
+
+// Dir returns the directory session metadata is stored in (~/.rize/sessions)
+func Dir() (string, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dataDir, "sessions"), nil
+}
+
+// Save writes the session metadata so other commands (traffic, usage) can
+// attribute data captured for the session to its project and agent.
+func (s *Session) Save() error {
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create sessions directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	return os.WriteFile(filepath.Join(dir, s.ID+".json"), data, 0644)
+}
+
+// Load reads the metadata of the session with the given ID
+func Load(id string) (*Session, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, filepath.Base(id)+".json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid session %s: %w", id, err)
+	}
+
+	return &s, nil
+}
@@ -0,0 +1,79 @@
+package traffic
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/alienxp03/rize/internal/config"
+	"github.com/alienxp03/rize/internal/usage"
+)
+
+//go:embed addon/*.py
+var addonFiles embed.FS
+
+// AddonDir returns the host directory holding the rize mitmproxy addons
+// (~/.rize/mitmproxy), mounted into the mitmproxy service at /opt/rize.
+func AddonDir() (string, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dataDir, "mitmproxy"), nil
+}
+
+// WriteAddons writes the embedded mitmproxy addons to AddonDir and makes sure
+// the traffic and usage directories exist. They hold captured flows, so they
+// are private to the host user; the proxy writes to them as root in its
+// container and hands the files it creates to the directory owner.
+func WriteAddons() error {
+	addonDir, err := AddonDir()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(addonDir, 0755); err != nil {
+		return fmt.Errorf("failed to create addon directory: %w", err)
+	}
+
+	entries, err := addonFiles.ReadDir("addon")
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		data, err := addonFiles.ReadFile("addon/" + entry.Name())
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(filepath.Join(addonDir, entry.Name()), data, 0644); err != nil {
+			return fmt.Errorf("failed to write addon %s: %w", entry.Name(), err)
+		}
+	}
+
+	trafficDir, err := Dir()
+	if err != nil {
+		return err
+	}
+
+	usageDir, err := usage.Dir()
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range []string{trafficDir, usageDir} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+
+		// Earlier versions made the directories world-writable
+		if err := os.Chmod(dir, 0700); err != nil {
+			return fmt.Errorf("failed to restrict %s: %w", dir, err)
+		}
+	}
+
+	return nil
+}
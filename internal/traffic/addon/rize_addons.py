@@ -0,0 +1,15 @@
+"""Entry point for the mitmproxy addons shipped with rize.
+
+Loaded by the mitmproxy service with `-s /opt/rize/rize_addons.py`. The files
+in this directory are rewritten by rize before services start.
+"""
+import os
+import sys
+
+sys.path.insert(0, os.path.dirname(os.path.abspath(__file__)))
+
+from rize_traffic import TrafficRecorder  # noqa: E402
+from rize_usage import UsageRecorder  # noqa: E402
+
+# TrafficRecorder tags flows with the session, so it must run first
+addons = [TrafficRecorder(), UsageRecorder()]
@@ -0,0 +1,22 @@
+"""Append records to the host directories mounted into the mitmproxy service.
+
+The directories belong to the host user and are private to them (0700); the
+proxy writes to them as root inside the container. New files are created
+private too and handed to the owner of the directory, so the host user can
+read and prune them.
+"""
+import os
+
+
+def append_line(directory, name, line):
+    path = os.path.join(directory, name)
+    fd = os.open(path, os.O_WRONLY | os.O_APPEND | os.O_CREAT, 0o600)
+    try:
+        st = os.fstat(fd)
+        if st.st_size == 0 and os.geteuid() == 0:
+            owner = os.stat(directory)
+            if (st.st_uid, st.st_gid) != (owner.st_uid, owner.st_gid):
+                os.fchown(fd, owner.st_uid, owner.st_gid)
+        os.write(fd, (line + "\n").encode("utf-8"))
+    finally:
+        os.close(fd)
@@ -0,0 +1,69 @@
+"""Secret redaction shared by the rize mitmproxy addons.
+
+The rules are written by rize to redact.json next to this file, using the
+same patterns and header names as the host-side redactor. A pattern with a
+named `secret` group only has that group replaced; otherwise the whole match
+is replaced.
+"""
+import json
+import os
+import re
+
+RULES_PATH = os.path.join(os.path.dirname(os.path.abspath(__file__)), "redact.json")
+DEFAULT_PLACEHOLDER = "[REDACTED]"
+
+
+class Redactor:
+    def __init__(self, path=RULES_PATH):
+        self.path = path
+        self.mtime = None
+        self.placeholder = DEFAULT_PLACEHOLDER
+        self.patterns = []
+        self.headers = set()
+        self._load()
+
+    def _load(self):
+        try:
+            mtime = os.path.getmtime(self.path)
+        except OSError:
+            return
+        if mtime == self.mtime:
+            return
+        try:
+            with open(self.path, encoding="utf-8") as f:
+                rules = json.load(f)
+        except (OSError, ValueError):
+            return
+        patterns = []
+        for source in rules.get("patterns", []):
+            try:
+                patterns.append(re.compile(source))
+            except re.error:
+                continue
+        self.mtime = mtime
+        self.placeholder = rules.get("placeholder", DEFAULT_PLACEHOLDER)
+        self.patterns = patterns
+        self.headers = {name.lower() for name in rules.get("headers", [])}
+
+    def text(self, value):
+        """Return value with all detected secrets replaced."""
+        self._load()
+        for pattern in self.patterns:
+            value = pattern.sub(self._replacement, value)
+        return value
+
+    def header(self, name, value):
+        if name.lower() in self.headers:
+            return self.placeholder
+        return self.text(value)
+
+    def _replacement(self, match):
+        if "secret" not in match.re.groupindex or match.start("secret") < 0:
+            return self.placeholder
+        start = match.start("secret") - match.start()
+        end = match.end("secret") - match.start()
+        whole = match.group(0)
+        return whole[:start] + self.placeholder + whole[end:]
+
+
+redactor = Redactor()
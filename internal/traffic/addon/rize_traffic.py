@@ -0,0 +1,124 @@
+"""Persist mitmproxy flows per rize session.
+
+rize tags every session by putting the session ID in the proxy credentials
+(http://<session>:rize@mitmproxy:8080). Flows from tagged connections are
+appended as JSON lines to <RIZE_TRAFFIC_DIR>/<session>.jsonl with secrets
+redacted; untagged traffic is only visible in the mitmweb UI.
+"""
+import base64
+import binascii
+import json
+import os
+import re
+
+from rize_files import append_line
+from rize_redact import redactor
+
+TRAFFIC_DIR = os.environ.get("RIZE_TRAFFIC_DIR", "/rize/traffic")
+MAX_BODY_SIZE = 1024 * 1024
+SESSION_PATTERN = re.compile(r"^[A-Za-z0-9._-]{1,128}$")
+
+
+def session_from_auth(value):
+    """Extract the rize session ID from a Proxy-Authorization header."""
+    if not value:
+        return None
+    scheme, _, token = value.partition(" ")
+    if scheme.lower() != "basic":
+        return None
+    try:
+        decoded = base64.b64decode(token.strip()).decode("utf-8")
+    except (binascii.Error, UnicodeDecodeError):
+        return None
+    user = decoded.split(":", 1)[0]
+    return user if SESSION_PATTERN.match(user) else None
+
+
+def encode_body(message):
+    """Return the decoded body as text, or base64 when it is binary."""
+    content = message.get_content(strict=False) or b""
+    body = {"size": len(content)}
+    if len(content) > MAX_BODY_SIZE:
+        content = content[:MAX_BODY_SIZE]
+        body["truncated"] = True
+    try:
+        body["text"] = redactor.text(content.decode("utf-8"))
+    except UnicodeDecodeError:
+        body["text"] = base64.b64encode(content).decode("ascii")
+        body["encoding"] = "base64"
+    return body
+
+
+def encode_headers(headers):
+    return [[name, redactor.header(name, value)] for name, value in headers.items(multi=True)]
+
+
+class TrafficRecorder:
+    def __init__(self):
+        # Client connection ID -> session ID, learned from CONNECT requests
+        self.connections = {}
+
+    def _tag(self, flow):
+        headers = flow.request.headers
+        session = session_from_auth(headers.get("Proxy-Authorization"))
+        if "Proxy-Authorization" in headers:
+            # Never forward the session credentials upstream
+            del headers["Proxy-Authorization"]
+        if session:
+            self.connections[flow.client_conn.id] = session
+        else:
+            session = self.connections.get(flow.client_conn.id)
+        if session:
+            flow.metadata["rize_session"] = session
+        return session
+
+    def http_connect(self, flow):
+        self._tag(flow)
+
+    def requestheaders(self, flow):
+        self._tag(flow)
+
+    def client_disconnected(self, client):
+        self.connections.pop(client.id, None)
+
+    def response(self, flow):
+        self._write(flow)
+
+    def error(self, flow):
+        self._write(flow)
+
+    def record(self, flow):
+        request = flow.request
+        record = {
+            "id": flow.id,
+            "session": flow.metadata.get("rize_session"),
+            "started": request.timestamp_start,
+            "request": {
+                "method": request.method,
+                "url": redactor.text(request.pretty_url),
+                "http_version": request.http_version,
+                "headers": encode_headers(request.headers),
+                "body": encode_body(request),
+            },
+        }
+        response = flow.response
+        if response is not None:
+            record["response"] = {
+                "status": response.status_code,
+                "reason": response.reason,
+                "http_version": response.http_version,
+                "headers": encode_headers(response.headers),
+                "body": encode_body(response),
+            }
+            if response.timestamp_end and request.timestamp_start:
+                record["duration_ms"] = int((response.timestamp_end - request.timestamp_start) * 1000)
+        if flow.error is not None:
+            record["error"] = redactor.text(flow.error.msg)
+        return record
+
+    def _write(self, flow):
+        session = flow.metadata.get("rize_session")
+        if not session:
+            return
+        line = json.dumps(self.record(flow), ensure_ascii=False)
+        append_line(TRAFFIC_DIR, session + ".jsonl", line)
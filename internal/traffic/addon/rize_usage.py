@@ -0,0 +1,220 @@
+"""Record the token usage of LLM API responses for `rize usage`.
+
+Responses from the Anthropic, OpenAI and Google Gemini APIs made by tagged
+rize sessions are parsed here (plain JSON and streamed SSE bodies), and only
+the model and token counts are appended as compact JSON lines to
+<RIZE_USAGE_DIR>/<session>.jsonl. Request and response bodies are never
+stored; this mirrors the parsing in internal/usage/extract.go.
+"""
+import json
+import os
+import time
+
+from rize_files import append_line
+from rize_redact import redactor
+
+USAGE_DIR = os.environ.get("RIZE_USAGE_DIR", "/rize/usage")
+MAX_BODY_SIZE = 16 * 1024 * 1024
+
+PROVIDERS = {
+    "api.anthropic.com": "anthropic",
+    "api.openai.com": "openai",
+    "generativelanguage.googleapis.com": "google",
+}
+
+PATH_MARKERS = {
+    "anthropic": ("/v1/messages",),
+    "openai": ("/v1/chat/completions", "/v1/responses", "/v1/completions"),
+    "google": (":generateContent", ":streamGenerateContent"),
+}
+
+
+def provider_for(host, path):
+    provider = PROVIDERS.get(host)
+    if provider is None:
+        return None
+    if "count_tokens" in path:
+        return None
+    if not any(marker in path for marker in PATH_MARKERS[provider]):
+        return None
+    return provider
+
+
+def request_model(flow):
+    try:
+        payload = json.loads(flow.request.get_content(strict=False) or b"{}")
+    except ValueError:
+        return ""
+    model = payload.get("model") if isinstance(payload, dict) else None
+    return model if isinstance(model, str) else ""
+
+
+def payloads(body):
+    """Split a response body into JSON documents: the data lines of an SSE
+    stream, the elements of a JSON array, or the body itself."""
+    trimmed = body.strip()
+    if trimmed.startswith("[") or trimmed.startswith("{"):
+        try:
+            doc = json.loads(trimmed)
+        except ValueError:
+            doc = None
+        if isinstance(doc, list):
+            return doc
+        if doc is not None or trimmed.startswith("{"):
+            return [doc]
+
+    docs = []
+    for line in body.splitlines():
+        if not line.startswith("data:"):
+            continue
+        data = line[len("data:"):].strip()
+        if not data or data == "[DONE]":
+            continue
+        try:
+            docs.append(json.loads(data))
+        except ValueError:
+            continue
+    return docs
+
+
+def count(value):
+    return value if isinstance(value, int) and not isinstance(value, bool) else 0
+
+
+def text(value):
+    return value if isinstance(value, str) else ""
+
+
+class Tokens:
+    def __init__(self):
+        self.model = ""
+        self.input = 0
+        self.output = 0
+        self.cache_read = 0
+        self.cache_write = 0
+        self.found = False
+
+    def apply_anthropic(self, p):
+        """Messages API. Streams report input and cache tokens in
+        message_start and the cumulative output in message_delta."""
+        usage = p.get("usage")
+        message = p.get("message")
+        if isinstance(message, dict):
+            if text(message.get("model")):
+                self.model = message["model"]
+            if message.get("usage") is not None:
+                usage = message["usage"]
+        if text(p.get("model")):
+            self.model = p["model"]
+        if not isinstance(usage, dict):
+            return
+
+        self.found = True
+        if count(usage.get("input_tokens")) > 0:
+            self.input = usage["input_tokens"]
+        if count(usage.get("cache_creation_input_tokens")) > 0:
+            self.cache_write = usage["cache_creation_input_tokens"]
+        if count(usage.get("cache_read_input_tokens")) > 0:
+            self.cache_read = usage["cache_read_input_tokens"]
+        if count(usage.get("output_tokens")) > 0:
+            self.output = usage["output_tokens"]
+
+    def apply_openai(self, p):
+        """Chat Completions and Responses API. OpenAI counts cached tokens as
+        part of the prompt, so they are split out here."""
+        usage = p.get("usage")
+        response = p.get("response")
+        if isinstance(response, dict):
+            if text(response.get("model")):
+                self.model = response["model"]
+            if response.get("usage") is not None:
+                usage = response["usage"]
+        if text(p.get("model")):
+            self.model = p["model"]
+        if not isinstance(usage, dict):
+            return
+
+        cached = 0
+        for key in ("prompt_tokens_details", "input_tokens_details"):
+            details = usage.get(key)
+            if isinstance(details, dict):
+                cached += count(details.get("cached_tokens"))
+
+        self.found = True
+        self.input = count(usage.get("prompt_tokens")) + count(usage.get("input_tokens")) - cached
+        self.cache_read = cached
+        self.output = count(usage.get("completion_tokens")) + count(usage.get("output_tokens"))
+
+    def apply_google(self, p):
+        """Gemini generateContent. Streamed chunks carry cumulative usage, so
+        the last one wins."""
+        if text(p.get("modelVersion")):
+            self.model = p["modelVersion"]
+        m = p.get("usageMetadata")
+        if not isinstance(m, dict):
+            return
+
+        cached = count(m.get("cachedContentTokenCount"))
+        self.found = True
+        self.input = count(m.get("promptTokenCount")) - cached
+        self.cache_read = cached
+        self.output = count(m.get("candidatesTokenCount")) + count(m.get("thoughtsTokenCount"))
+
+
+def gemini_model_from_path(path):
+    """Extract "gemini-2.5-pro" from
+    "/v1beta/models/gemini-2.5-pro:streamGenerateContent?alt=sse"."""
+    _, found, model = path.partition("/models/")
+    if not found:
+        return ""
+    for sep in ":?/":
+        model = model.split(sep, 1)[0]
+    return model
+
+
+def extract(provider, body):
+    tokens = Tokens()
+    apply = getattr(tokens, "apply_" + provider)
+    for payload in payloads(body):
+        if isinstance(payload, dict):
+            apply(payload)
+    return tokens if tokens.found else None
+
+
+class UsageRecorder:
+    def response(self, flow):
+        session = flow.metadata.get("rize_session")
+        if not session or flow.response is None or flow.response.status_code >= 400:
+            return
+
+        provider = provider_for(flow.request.pretty_host, flow.request.path)
+        if provider is None:
+            return
+
+        body = flow.response.get_content(strict=False) or b""
+        if len(body) > MAX_BODY_SIZE:
+            return
+
+        tokens = extract(provider, body.decode("utf-8", errors="replace"))
+        if tokens is None:
+            return
+
+        model = tokens.model or request_model(flow)
+        if not model and provider == "google":
+            model = gemini_model_from_path(flow.request.path)
+
+        record = {
+            "time": flow.request.timestamp_start or time.time(),
+            "session": session,
+            "provider": provider,
+            "host": flow.request.pretty_host,
+            "path": redactor.text(flow.request.path),
+            "status": flow.response.status_code,
+            "model": model,
+            "input_tokens": tokens.input,
+            "output_tokens": tokens.output,
+            "cache_read_tokens": tokens.cache_read,
+            "cache_write_tokens": tokens.cache_write,
+        }
+
+        append_line(USAGE_DIR, session + ".jsonl", json.dumps(record, ensure_ascii=False))
@@ -0,0 +1,199 @@
+package traffic
+
+import (
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// HAR is an HTTP Archive 1.2 document
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Comment string     `json:"comment,omitempty"`
+	Entries []HAREntry `json:"entries"`
+}
+
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type HAREntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARPostData is the request body. Binary bodies are base64 encoded with
+// encoding set, as HAR allows for response content.
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type HARTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// ToHAR converts captured flows into a HAR document
+func ToHAR(sessionID string, flows []Flow) *HAR {
+	har := &HAR{
+		Log: HARLog{
+			Version: "1.2",
+			Creator: HARCreator{Name: "rize", Version: "1.0"},
+			Comment: "rize session " + sessionID,
+			Entries: []HAREntry{},
+		},
+	}
+
+	for _, flow := range flows {
+		har.Log.Entries = append(har.Log.Entries, toHAREntry(flow))
+	}
+
+	return har
+}
+
+func toHAREntry(flow Flow) HAREntry {
+	entry := HAREntry{
+		StartedDateTime: flow.StartedAt().UTC().Format(time.RFC3339Nano),
+		Time:            float64(flow.DurationMs),
+		Request: HARRequest{
+			Method:      flow.Request.Method,
+			URL:         flow.Request.URL,
+			HTTPVersion: flow.Request.HTTPVersion,
+			Cookies:     []HARNameValue{},
+			Headers:     toNameValues(flow.Request.Headers),
+			QueryString: queryString(flow.Request.URL),
+			HeadersSize: -1,
+			BodySize:    flow.Request.Body.Size,
+		},
+		Response: HARResponse{
+			Cookies:     []HARNameValue{},
+			Headers:     []HARNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Timings: HARTimings{Wait: float64(flow.DurationMs)},
+		Comment: flow.Error,
+	}
+
+	if flow.Request.Body.Size > 0 {
+		entry.Request.PostData = postData(flow.Request.Body, Header(flow.Request.Headers, "Content-Type"))
+	}
+
+	if resp := flow.Response; resp != nil {
+		entry.Response.Status = resp.Status
+		entry.Response.StatusText = resp.Reason
+		entry.Response.HTTPVersion = resp.HTTPVersion
+		entry.Response.Headers = toNameValues(resp.Headers)
+		entry.Response.RedirectURL = Header(resp.Headers, "Location")
+		entry.Response.BodySize = resp.Body.Size
+		entry.Response.Content = HARContent{
+			Size:     resp.Body.Size,
+			MimeType: Header(resp.Headers, "Content-Type"),
+			Text:     resp.Body.Text,
+			Encoding: resp.Body.Encoding,
+		}
+	}
+
+	return entry
+}
+
+func postData(body Body, mimeType string) *HARPostData {
+	data := &HARPostData{MimeType: mimeType, Text: body.Text, Encoding: body.Encoding}
+	if data.Encoding == "" && !isTextMime(mimeType) {
+		data.Text = base64.StdEncoding.EncodeToString([]byte(body.Text))
+		data.Encoding = "base64"
+	}
+	return data
+}
+
+// isTextMime reports whether a content type is text; an unknown type counts
+// as text
+func isTextMime(mimeType string) bool {
+	mimeType, _, _ = strings.Cut(strings.ToLower(mimeType), ";")
+	mimeType = strings.TrimSpace(mimeType)
+	if mimeType == "" || strings.HasPrefix(mimeType, "text/") {
+		return true
+	}
+	for _, suffix := range []string{"json", "xml", "javascript", "x-www-form-urlencoded", "graphql", "yaml"} {
+		if strings.HasSuffix(mimeType, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func toNameValues(headers [][]string) []HARNameValue {
+	values := []HARNameValue{}
+	for _, h := range headers {
+		if len(h) == 2 {
+			values = append(values, HARNameValue{Name: h[0], Value: h[1]})
+		}
+	}
+	return values
+}
+
+func queryString(rawURL string) []HARNameValue {
+	values := []HARNameValue{}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return values
+	}
+
+	for name, list := range u.Query() {
+		for _, value := range list {
+			values = append(values, HARNameValue{Name: name, Value: value})
+		}
+	}
+	return values
+}
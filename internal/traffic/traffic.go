@@ -0,0 +1,272 @@
+package traffic
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alienxp03/rize/internal/config"
+	"github.com/alienxp03/rize/internal/redact"
+)
+
+const flowExt = ".jsonl"
+
+// Flow is a single HTTP exchange captured by the rize mitmproxy addon
+type Flow struct {
+	ID         string    `json:"id"`
+	Session    string    `json:"session"`
+	Started    float64   `json:"started"`
+	DurationMs int64     `json:"duration_ms,omitempty"`
+	Request    Request   `json:"request"`
+	Response   *Response `json:"response,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Request is the captured request half of a flow
+type Request struct {
+	Method      string     `json:"method"`
+	URL         string     `json:"url"`
+	HTTPVersion string     `json:"http_version"`
+	Headers     [][]string `json:"headers"`
+	Body        Body       `json:"body"`
+}
+
+// Response is the captured response half of a flow
+type Response struct {
+	Status      int        `json:"status"`
+	Reason      string     `json:"reason"`
+	HTTPVersion string     `json:"http_version"`
+	Headers     [][]string `json:"headers"`
+	Body        Body       `json:"body"`
+}
+
+// Body is a captured message body. Binary bodies are base64 encoded.
+type Body struct {
+	Size      int    `json:"size"`
+	Text      string `json:"text"`
+	Encoding  string `json:"encoding,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// Bytes returns the decoded body content
+func (b Body) Bytes() []byte {
+	if b.Encoding == "base64" {
+		data, err := base64.StdEncoding.DecodeString(b.Text)
+		if err == nil {
+			return data
+		}
+	}
+	return []byte(b.Text)
+}
+
+// StartedAt returns the time the request started
+func (f Flow) StartedAt() time.Time {
+	sec := int64(f.Started)
+	nsec := int64((f.Started - float64(sec)) * 1e9)
+	return time.Unix(sec, nsec)
+}
+
+// Host returns the host the request was sent to
+func (f Flow) Host() string {
+	u, err := url.Parse(f.Request.URL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// Header returns the first value of the named header
+func Header(headers [][]string, name string) string {
+	for _, h := range headers {
+		if len(h) == 2 && strings.EqualFold(h[0], name) {
+			return h[1]
+		}
+	}
+	return ""
+}
+
+// SessionSummary describes the captured traffic of one session
+type SessionSummary struct {
+	ID    string
+	Path  string
+	Size  int64
+	Flows int
+	First time.Time
+	Last  time.Time
+	Hosts []string
+}
+
+// Dir returns the directory flows are persisted in (~/.rize/traffic)
+func Dir() (string, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dataDir, "traffic"), nil
+}
+
+// List returns a summary of every session with captured traffic, newest first
+func List() ([]SessionSummary, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+flowExt))
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []SessionSummary
+	for _, path := range paths {
+		flows, err := readFile(path)
+		if err != nil {
+			continue
+		}
+
+		summary := SessionSummary{
+			ID:    strings.TrimSuffix(filepath.Base(path), flowExt),
+			Path:  path,
+			Flows: len(flows),
+		}
+		if info, err := os.Stat(path); err == nil {
+			summary.Size = info.Size()
+		}
+
+		hosts := map[string]struct{}{}
+		for _, flow := range flows {
+			started := flow.StartedAt()
+			if summary.First.IsZero() || started.Before(summary.First) {
+				summary.First = started
+			}
+			if started.After(summary.Last) {
+				summary.Last = started
+			}
+			if host := flow.Host(); host != "" {
+				hosts[host] = struct{}{}
+			}
+		}
+		for host := range hosts {
+			summary.Hosts = append(summary.Hosts, host)
+		}
+		sort.Strings(summary.Hosts)
+
+		sessions = append(sessions, summary)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].ID > sessions[j].ID
+	})
+
+	return sessions, nil
+}
+
+// Read returns the flows captured for a session. A unique ID prefix is accepted.
+func Read(id string) (string, []Flow, error) {
+	sessionID, err := resolve(id)
+	if err != nil {
+		return "", nil, err
+	}
+
+	dir, err := Dir()
+	if err != nil {
+		return "", nil, err
+	}
+
+	flows, err := readFile(filepath.Join(dir, sessionID+flowExt))
+
+	// Flows are redacted by the addon when captured; redact again so rules
+	// added since then also apply. Callers set the configured rules with
+	// redact.Configure first.
+	redactor := redact.Default()
+	for i := range flows {
+		flows[i].Redact(redactor)
+	}
+
+	return sessionID, flows, err
+}
+
+// Redact replaces secrets in the URL, headers and text bodies of the flow
+func (f *Flow) Redact(r *redact.Redactor) {
+	f.Request.URL = r.String(f.Request.URL)
+	redactHeaders(r, f.Request.Headers)
+	f.Request.Body.redact(r)
+	if f.Response != nil {
+		redactHeaders(r, f.Response.Headers)
+		f.Response.Body.redact(r)
+	}
+	f.Error = r.String(f.Error)
+}
+
+func redactHeaders(r *redact.Redactor, headers [][]string) {
+	for _, h := range headers {
+		if len(h) == 2 {
+			h[1] = r.Header(h[0], h[1])
+		}
+	}
+}
+
+func (b *Body) redact(r *redact.Redactor) {
+	if b.Encoding == "" {
+		b.Text = r.String(b.Text)
+	}
+}
+
+func resolve(id string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, filepath.Base(id)+flowExt)); err == nil {
+		return filepath.Base(id), nil
+	}
+
+	paths, _ := filepath.Glob(filepath.Join(dir, "*"+flowExt))
+	var matches []string
+	for _, path := range paths {
+		candidate := strings.TrimSuffix(filepath.Base(path), flowExt)
+		if strings.HasPrefix(candidate, id) {
+			matches = append(matches, candidate)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no traffic captured for session %s", id)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("session ID %s is ambiguous (%d matches)", id, len(matches))
+	}
+}
+
+func readFile(path string) ([]Flow, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+	var flows []Flow
+	for scanner.Scan() {
+		var flow Flow
+		if err := json.Unmarshal(scanner.Bytes(), &flow); err != nil {
+			continue
+		}
+		flows = append(flows, flow)
+	}
+
+	return flows, scanner.Err()
+}
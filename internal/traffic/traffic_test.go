@@ -0,0 +1,146 @@
+package traffic
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alienxp03/rize/internal/config"
+	"github.com/alienxp03/rize/internal/redact"
+)
+
+const fixtureFlows = `{"id":"f1","session":"20260115-101500-ab12","started":1768471200.5,"duration_ms":120,"request":{"method":"POST","url":"https://api.anthropic.com/v1/messages?beta=true","http_version":"HTTP/1.1","headers":[["content-type","application/json"]],"body":{"size":15,"text":"{\"model\":\"x\"}"}},"response":{"status":200,"reason":"OK","http_version":"HTTP/1.1","headers":[["content-type","application/json"]],"body":{"size":2,"text":"{}"}}}
+not json
+{"id":"f2","session":"20260115-101500-ab12","started":1768471201,"request":{"method":"GET","url":"https://example.com/logo.png","http_version":"HTTP/2.0","headers":[],"body":{"size":0,"text":""}},"response":{"status":200,"reason":"","http_version":"HTTP/2.0","headers":[["Content-Type","image/png"]],"body":{"size":4,"text":"iVBORw==","encoding":"base64"}}}
+`
+
+func TestReadAndExportHAR(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	dir, err := Dir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "20260115-101500-ab12.jsonl"), []byte(fixtureFlows), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err := List()
+	if err != nil {
+		t.Fatalf("Failed to list sessions: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].Flows != 2 || len(sessions[0].Hosts) != 2 {
+		t.Fatalf("Unexpected session summary: %+v", sessions)
+	}
+
+	id, flows, err := Read("20260115")
+	if err != nil {
+		t.Fatalf("Failed to read flows by prefix: %v", err)
+	}
+	if id != "20260115-101500-ab12" || len(flows) != 2 {
+		t.Fatalf("Expected 2 flows for the session, got %d (%s)", len(flows), id)
+	}
+
+	har := ToHAR(id, flows)
+	if har.Log.Version != "1.2" || len(har.Log.Entries) != 2 {
+		t.Fatalf("Unexpected HAR: %+v", har.Log)
+	}
+
+	post := har.Log.Entries[0]
+	if post.Request.PostData == nil || post.Request.PostData.MimeType != "application/json" {
+		t.Errorf("Expected JSON post data, got %+v", post.Request.PostData)
+	}
+	if len(post.Request.QueryString) != 1 || post.Request.QueryString[0].Name != "beta" {
+		t.Errorf("Expected query string to be parsed, got %+v", post.Request.QueryString)
+	}
+
+	image := har.Log.Entries[1]
+	if image.Response.Content.Encoding != "base64" || image.Response.Content.MimeType != "image/png" {
+		t.Errorf("Expected base64 image content, got %+v", image.Response.Content)
+	}
+}
+
+func TestHARBinaryPostData(t *testing.T) {
+	tests := []struct {
+		contentType string
+		body        Body
+		text        string
+		encoding    string
+	}{
+		{"application/json", Body{Size: 2, Text: "{}"}, "{}", ""},
+		{"application/x-protobuf", Body{Size: 3, Text: "AP8B", Encoding: "base64"}, "AP8B", "base64"},
+		{"application/octet-stream", Body{Size: 3, Text: "abc"}, "YWJj", "base64"},
+		{"", Body{Size: 3, Text: "abc"}, "abc", ""},
+	}
+
+	for _, tt := range tests {
+		flow := Flow{Request: Request{
+			Method:  "POST",
+			URL:     "https://example.com/upload",
+			Headers: [][]string{{"Content-Type", tt.contentType}},
+			Body:    tt.body,
+		}}
+		data := toHAREntry(flow).Request.PostData
+		if data == nil || data.Text != tt.text || data.Encoding != tt.encoding {
+			t.Errorf("postData(%q) = %+v, want text %q encoding %q", tt.contentType, data, tt.text, tt.encoding)
+		}
+	}
+}
+
+func TestWriteAddons(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if err := WriteAddons(); err != nil {
+		t.Fatalf("Failed to write addons: %v", err)
+	}
+
+	addonDir, _ := AddonDir()
+	for _, name := range []string{"rize_addons.py", "rize_traffic.py", "rize_files.py"} {
+		if _, err := os.Stat(filepath.Join(addonDir, name)); err != nil {
+			t.Errorf("Expected addon %s to be written: %v", name, err)
+		}
+	}
+
+	trafficDir, _ := Dir()
+	if err := os.Chmod(trafficDir, 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteAddons(); err != nil {
+		t.Fatalf("Failed to write addons again: %v", err)
+	}
+	info, err := os.Stat(trafficDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0700 {
+		t.Errorf("Expected the traffic directory to be private, got %v", info.Mode().Perm())
+	}
+}
+
+func TestReadAppliesConfiguredRedaction(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if err := redact.Configure(config.RedactionConfig{Patterns: []string{`corp-[0-9]{6}`}}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { redact.Configure(config.RedactionConfig{}) })
+
+	dir, _ := Dir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	flow := `{"id":"f1","session":"s1","started":1768471200,"request":{"method":"POST","url":"https://example.com/","http_version":"HTTP/1.1","headers":[],"body":{"size":18,"text":"token=corp-123456"}}}` + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "s1.jsonl"), []byte(flow), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, flows, err := Read("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := flows[0].Request.Body.Text; got != "token="+redact.Placeholder {
+		t.Errorf("Expected the configured pattern to be redacted, got %q", got)
+	}
+}
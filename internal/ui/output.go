@@ -2,8 +2,13 @@ package ui
 
 import (
 	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/fatih/color"
+	"github.com/moby/term"
 )
 
 var (
@@ -11,20 +16,179 @@ var (
 	Blue   = color.New(color.FgBlue).SprintFunc()
 	Yellow = color.New(color.FgYellow).SprintFunc()
 	Red    = color.New(color.FgRed).SprintFunc()
+	Faint  = color.New(color.Faint).SprintFunc()
 )
 
+// Level is the verbosity of the messages
+type Level int
+
+const (
+	LevelError Level = iota
+	LevelWarn
+	LevelInfo
+	LevelDebug
+	// LevelTrace adds every Docker API call and external command
+	LevelTrace
+)
+
+var levelNames = []string{"error", "warn", "info", "debug", "trace"}
+
+func (l Level) String() string {
+	if l < LevelError || l > LevelTrace {
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// ParseLevel returns the level with a name: error, warn, info, debug or trace
+func ParseLevel(name string) (Level, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "warning" {
+		name = "warn"
+	}
+	for i, levelName := range levelNames {
+		if name == levelName {
+			return Level(i), nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q (use %s)", name, strings.Join(levelNames, ", "))
+}
+
+var (
+	level = LevelInfo
+
+	// stderrColor is set when messages are colored: stderr is a terminal
+	// and NO_COLOR is not set
+	stderrColor = colorTerminal(os.Stderr)
+
+	logMu   sync.Mutex
+	logFile *os.File
+	// attached counts the interactive sessions that own the terminal
+	attached int
+)
+
+func init() {
+	// Output on stdout is only colored when it is a terminal too
+	color.NoColor = !colorTerminal(os.Stdout)
+}
+
+// colorTerminal reports whether colors can be written to f
+func colorTerminal(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return term.IsTerminal(f.Fd())
+}
+
+// SetLevel sets the most verbose level printed
+func SetLevel(l Level) {
+	level = l
+}
+
+// Enabled reports whether messages of a level are printed
+func Enabled(l Level) bool {
+	return l <= level
+}
+
+// DisableColor disables colored output
+func DisableColor() {
+	color.NoColor = true
+	stderrColor = false
+}
+
+// SetLogFile appends the messages to a file, with timestamps. Debug and
+// trace messages then only go to the file.
+func SetLogFile(path string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	logMu.Lock()
+	defer logMu.Unlock()
+	if logFile != nil {
+		logFile.Close()
+	}
+	logFile = f
+	return nil
+}
+
+// Attach keeps debug and trace messages off the terminal while an
+// interactive session has it in raw mode; they still go to the log file.
+// The returned function detaches again.
+func Attach() (detach func()) {
+	logMu.Lock()
+	attached++
+	logMu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			logMu.Lock()
+			attached--
+			logMu.Unlock()
+		})
+	}
+}
+
+// Messages go to stderr, leaving stdout to the output of commands
+
 func Success(format string, a ...interface{}) {
-	fmt.Printf("%s %s\n", Green("✓"), fmt.Sprintf(format, a...))
+	message(LevelInfo, color.FgGreen, "✓", format, a...)
 }
 
 func Info(format string, a ...interface{}) {
-	fmt.Printf("%s %s\n", Blue("→"), fmt.Sprintf(format, a...))
+	message(LevelInfo, color.FgBlue, "→", format, a...)
 }
 
 func Warning(format string, a ...interface{}) {
-	fmt.Printf("%s %s\n", Yellow("!"), fmt.Sprintf(format, a...))
+	message(LevelWarn, color.FgYellow, "!", format, a...)
 }
 
 func Error(format string, a ...interface{}) {
-	fmt.Fprintf(color.Output, "%s %s\n", Red("✗"), fmt.Sprintf(format, a...))
+	message(LevelError, color.FgRed, "✗", format, a...)
+}
+
+// Debug prints what rize is doing, with -v
+func Debug(format string, a ...interface{}) {
+	message(LevelDebug, color.Faint, "·", format, a...)
+}
+
+// Trace prints Docker API calls and external commands, with -vv
+func Trace(format string, a ...interface{}) {
+	message(LevelTrace, color.Faint, "»", format, a...)
+}
+
+func message(l Level, attr color.Attribute, mark, format string, a ...interface{}) {
+	if !Enabled(l) {
+		return
+	}
+	text := fmt.Sprintf(format, a...)
+
+	logMu.Lock()
+	toFile := logFile != nil
+	if toFile {
+		fmt.Fprintf(logFile, "%s %-5s %s\n", time.Now().Format(time.RFC3339Nano), strings.ToUpper(l.String()), text)
+	}
+	quiet := attached > 0
+	logMu.Unlock()
+	if (toFile || quiet) && l >= LevelDebug {
+		return
+	}
+
+	line := mark + " " + text
+	switch {
+	case !stderrColor:
+	case attr == color.Faint:
+		line = colored(attr, line)
+	default:
+		line = colored(attr, mark) + " " + text
+	}
+	fmt.Fprintln(color.Error, line)
+}
+
+func colored(attr color.Attribute, s string) string {
+	c := color.New(attr)
+	c.EnableColor()
+	return c.Sprint(s)
 }
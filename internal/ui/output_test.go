@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name string
+		want Level
+	}{
+		{"error", LevelError},
+		{"WARN", LevelWarn},
+		{"warning", LevelWarn},
+		{" info ", LevelInfo},
+		{"debug", LevelDebug},
+		{"trace", LevelTrace},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.name)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, %v, want %v", tt.name, got, err, tt.want)
+		}
+	}
+	if _, err := ParseLevel("loud"); err == nil {
+		t.Error("Expected an unknown level to be rejected")
+	}
+}
+
+func TestLogFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rize.log")
+	if err := SetLogFile(path); err != nil {
+		t.Fatal(err)
+	}
+	SetLevel(LevelDebug)
+	t.Cleanup(func() {
+		SetLevel(LevelInfo)
+		logFile.Close()
+		logFile = nil
+	})
+
+	Debug("resolving %s", "project")
+	Trace("not traced")
+	Warning("disk is low")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], "DEBUG resolving project") || !strings.HasSuffix(lines[1], "WARN  disk is low") {
+		t.Errorf("Unexpected log file:\n%s", data)
+	}
+}
+
+func TestAttachKeepsTraceOffTheTerminal(t *testing.T) {
+	var buf strings.Builder
+	saved := color.Error
+	color.Error = &buf
+	SetLevel(LevelTrace)
+	t.Cleanup(func() {
+		color.Error = saved
+		SetLevel(LevelInfo)
+	})
+
+	detach := Attach()
+	Trace("docker GET /exec/1/json")
+	Debug("polling")
+	Warning("still shown")
+	detach()
+	detach()
+	Trace("after the session")
+
+	if got, want := buf.String(), "! still shown\n» after the session\n"; got != want {
+		t.Errorf("stderr = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,200 @@
+package update
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/alienxp03/rize/internal/config"
+)
+
+// BinaryName is the name of the installed binary
+const BinaryName = "rize"
+
+// SystemInstallDir is where rize is installed when it is writable
+const SystemInstallDir = "/usr/local/bin"
+
+// installRecordFile records where rize was installed, under ~/.rize
+const installRecordFile = "install.json"
+
+// InstallRecord records an installation so update and uninstall find it
+type InstallRecord struct {
+	Path        string    `json:"path"`
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// UserInstallDir returns the per-user install directory, ~/.local/bin
+func UserInstallDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "bin"), nil
+}
+
+// DefaultInstallDir returns SystemInstallDir when it is writable, and the
+// user install directory otherwise, so installing never needs sudo
+func DefaultInstallDir() (string, error) {
+	if Writable(SystemInstallDir) {
+		return SystemInstallDir, nil
+	}
+	return UserInstallDir()
+}
+
+// InstalledPath returns the installed binary: the recorded installation, or
+// the first binary found in the system and user install directories. It
+// returns an empty string when rize is not installed.
+func InstalledPath() string {
+	if record, err := readInstallRecord(); err == nil && record.Path != "" {
+		if _, err := os.Stat(record.Path); err == nil {
+			return record.Path
+		}
+	}
+
+	candidates := []string{filepath.Join(SystemInstallDir, BinaryName)}
+	if dir, err := UserInstallDir(); err == nil {
+		candidates = append(candidates, filepath.Join(dir, BinaryName))
+	}
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// RecordInstall records the installed binary
+func RecordInstall(path string) error {
+	recordPath, err := installRecordPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(recordPath), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(recordPath), err)
+	}
+
+	data, err := json.MarshalIndent(InstallRecord{Path: path, InstalledAt: time.Now().UTC()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(recordPath, append(data, '\n'), 0644)
+}
+
+// ForgetInstall removes the install record
+func ForgetInstall() error {
+	recordPath, err := installRecordPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(recordPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func readInstallRecord() (*InstallRecord, error) {
+	recordPath, err := installRecordPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(recordPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var record InstallRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", recordPath, err)
+	}
+	return &record, nil
+}
+
+func installRecordPath() (string, error) {
+	dir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, installRecordFile), nil
+}
+
+// OnPath reports whether dir is in $PATH
+func OnPath(dir string) bool {
+	dir = filepath.Clean(dir)
+	for _, entry := range filepath.SplitList(os.Getenv("PATH")) {
+		if entry != "" && filepath.Clean(entry) == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// PathInstructions returns the line that adds dir to $PATH for the user's
+// shell and the file it goes in
+func PathInstructions(dir string) (line, file string) {
+	home, _ := os.UserHomeDir()
+	display := dir
+	if home != "" && strings.HasPrefix(dir, home+string(filepath.Separator)) {
+		display = "$HOME" + strings.TrimPrefix(dir, home)
+	}
+
+	switch filepath.Base(os.Getenv("SHELL")) {
+	case "fish":
+		return fmt.Sprintf("fish_add_path %s", display), "~/.config/fish/config.fish"
+	case "zsh":
+		return fmt.Sprintf(`export PATH="%s:$PATH"`, display), "~/.zshrc"
+	default:
+		return fmt.Sprintf(`export PATH="%s:$PATH"`, display), "~/.bashrc"
+	}
+}
+
+// InstallBinary copies the binary src to path, replacing an existing binary
+// atomically. The directory of path must be writable.
+func InstallBinary(src, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+
+	source, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".rize-install-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, source); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to copy %s: %w", src, err)
+	}
+	if err := tmp.Chmod(0755); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to install %s: %w", path, err)
+	}
+	return nil
+}
+
+// Writable reports whether files can be created in dir
+func Writable(dir string) bool {
+	f, err := os.CreateTemp(dir, ".rize-write-test-*")
+	if err != nil {
+		return false
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return true
+}
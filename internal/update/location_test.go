@@ -0,0 +1,88 @@
+package update
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInstalledPath(t *testing.T) {
+	if _, err := os.Stat(filepath.Join(SystemInstallDir, BinaryName)); err == nil {
+		t.Skip("rize is installed system-wide")
+	}
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	if path := InstalledPath(); path != "" {
+		t.Errorf("Expected no installation, got %s", path)
+	}
+
+	// A binary in the user install directory is found without a record
+	userBinary := filepath.Join(home, ".local", "bin", BinaryName)
+	src := filepath.Join(t.TempDir(), "rize-build")
+	if err := os.WriteFile(src, []byte("binary"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := InstallBinary(src, userBinary); err != nil {
+		t.Fatalf("InstallBinary() failed: %v", err)
+	}
+	if info, err := os.Stat(userBinary); err != nil || info.Mode().Perm() != 0755 {
+		t.Fatalf("Expected an executable binary, got %v, %v", info, err)
+	}
+	if path := InstalledPath(); path != userBinary {
+		t.Errorf("Expected %s, got %s", userBinary, path)
+	}
+
+	// The recorded installation wins
+	custom := filepath.Join(home, "tools", BinaryName)
+	if err := InstallBinary(src, custom); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordInstall(custom); err != nil {
+		t.Fatalf("RecordInstall() failed: %v", err)
+	}
+	if path := InstalledPath(); path != custom {
+		t.Errorf("Expected the recorded %s, got %s", custom, path)
+	}
+
+	// A record of a removed binary is ignored
+	os.Remove(custom)
+	if path := InstalledPath(); path != userBinary {
+		t.Errorf("Expected %s after the recorded binary was removed, got %s", userBinary, path)
+	}
+
+	if err := ForgetInstall(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".rize", installRecordFile)); !os.IsNotExist(err) {
+		t.Errorf("Expected the install record to be removed, got %v", err)
+	}
+}
+
+func TestOnPath(t *testing.T) {
+	t.Setenv("PATH", "/usr/bin:/home/dev/.local/bin/:/bin")
+
+	if !OnPath("/home/dev/.local/bin") {
+		t.Error("Expected ~/.local/bin to be on PATH")
+	}
+	if OnPath("/opt/rize/bin") {
+		t.Error("Expected /opt/rize/bin not to be on PATH")
+	}
+}
+
+func TestPathInstructions(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".local", "bin")
+
+	t.Setenv("SHELL", "/bin/zsh")
+	if line, file := PathInstructions(dir); line != `export PATH="$HOME/.local/bin:$PATH"` || file != "~/.zshrc" {
+		t.Errorf("PathInstructions() for zsh = %q, %q", line, file)
+	}
+
+	t.Setenv("SHELL", "/usr/bin/fish")
+	if line, _ := PathInstructions("/opt/bin"); line != "fish_add_path /opt/bin" {
+		t.Errorf("PathInstructions() for fish = %q", line)
+	}
+}
@@ -0,0 +1,379 @@
+package update
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/alienxp03/rize/internal/version"
+)
+
+// releasesURL is the base URL of the release assets
+const releasesURL = "https://github.com/alienxp03/rize/releases"
+
+// Update channels; a release version like v1.2.3 pins that release
+const (
+	ChannelStable  = "stable"
+	ChannelNightly = "nightly"
+)
+
+// ChannelURL returns the URL the release assets of a channel are downloaded
+// from
+func ChannelURL(channel string) (string, error) {
+	switch {
+	case channel == ChannelStable:
+		return releasesURL + "/latest/download", nil
+	case channel == ChannelNightly:
+		return releasesURL + "/download/nightly", nil
+	case version.IsRelease(channel):
+		return releasesURL + "/download/" + channel, nil
+	default:
+		return "", fmt.Errorf("unknown channel %q (stable, nightly or a version like v1.2.3)", channel)
+	}
+}
+
+// DefaultChannel returns the channel of the running binary: nightly builds
+// stay on nightly, everything else follows stable
+func DefaultChannel() string {
+	if version.Nightly(version.Version) {
+		return ChannelNightly
+	}
+	return ChannelStable
+}
+
+// Release assets besides the binaries
+const (
+	// ManifestAsset lists the SHA-256 checksums of the release binaries, in
+	// sha256sum format, after a "# rize <version>" line
+	ManifestAsset = "checksums.txt"
+	// SignatureAsset is the base64 ed25519 signature of the manifest
+	SignatureAsset = "checksums.txt.sig"
+)
+
+// PublicKey is the base64 ed25519 key release manifests are signed with, set
+// when building a release with
+// -ldflags "-X github.com/alienxp03/rize/internal/update.PublicKey=..."
+var PublicKey = ""
+
+// previousSuffix names the copy of the binary kept for rollback
+const previousSuffix = ".previous"
+
+// ErrDowngrade is returned when the release is older than the running binary
+var ErrDowngrade = errors.New("release is older than the installed version")
+
+// ErrNoPrevious is returned when there is no previous binary to roll back to
+var ErrNoPrevious = errors.New("no previous version to roll back to")
+
+// Updater replaces the installed binary with the latest release
+type Updater struct {
+	// BaseURL is the URL the release assets are downloaded from
+	BaseURL string
+	// PublicKey verifies the release manifest
+	PublicKey ed25519.PublicKey
+	// Path is the installed binary
+	Path string
+	// Current is the version of the installed binary
+	Current string
+	// Pinned is the release a channel like v1.4.0 pins, empty for stable
+	// and nightly
+	Pinned string
+	// OS and Arch select the binary to download
+	OS   string
+	Arch string
+
+	HTTPClient *http.Client
+}
+
+// New returns an updater for the binary at path following a channel, using
+// the embedded release key
+func New(path, channel string) (*Updater, error) {
+	baseURL, err := ChannelURL(channel)
+	if err != nil {
+		return nil, err
+	}
+
+	if PublicKey == "" {
+		return nil, fmt.Errorf("this build has no release signing key; reinstall rize from a release to get verified updates")
+	}
+	key, err := base64.StdEncoding.DecodeString(PublicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid release signing key")
+	}
+
+	pinned := ""
+	if version.IsRelease(channel) {
+		pinned = channel
+	}
+
+	return &Updater{
+		BaseURL:    baseURL,
+		PublicKey:  key,
+		Path:       path,
+		Current:    version.Version,
+		Pinned:     pinned,
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		HTTPClient: &http.Client{Timeout: 5 * time.Minute},
+	}, nil
+}
+
+// Release is a verified release manifest
+type Release struct {
+	Version string
+	// Checksums maps asset names to their hex SHA-256
+	Checksums map[string]string
+}
+
+// Result describes an update
+type Result struct {
+	From string
+	To   string
+	// UpToDate is set when the installed binary already is the release
+	UpToDate bool
+}
+
+// Asset returns the name of the binary for the updater's platform
+func (u *Updater) Asset() string {
+	return fmt.Sprintf("rize-%s-%s", u.OS, u.Arch)
+}
+
+// Release downloads and verifies the release manifest
+func (u *Updater) Release() (*Release, error) {
+	manifest, err := u.download(ManifestAsset)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := u.download(SignatureAsset)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil || !ed25519.Verify(u.PublicKey, manifest, sig) {
+		return nil, fmt.Errorf("release manifest signature is invalid")
+	}
+
+	release, err := parseManifest(manifest)
+	if err != nil {
+		return nil, err
+	}
+	// A manifest signed for another release must not be installed in place
+	// of the pinned one
+	if u.Pinned != "" && release.Version != u.Pinned {
+		return nil, fmt.Errorf("release manifest is for %s, not the requested %s", release.Version, u.Pinned)
+	}
+	return release, nil
+}
+
+// Update installs the release. Releases older than the installed version
+// are refused and the installed version is not reinstalled, unless force is
+// set.
+func (u *Updater) Update(force bool) (*Result, error) {
+	release, err := u.Release()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := u.Check(release, force)
+	if err != nil || result.UpToDate {
+		return result, err
+	}
+
+	return result, u.Install(release)
+}
+
+// Check compares a release with the installed version. It fails for older
+// releases and reports the installed version as up to date, unless force
+// is set.
+func (u *Updater) Check(release *Release, force bool) (*Result, error) {
+	result := &Result{From: u.Current, To: release.Version}
+	if cmp, ok := version.Compare(release.Version, u.Current); ok && !force {
+		if cmp < 0 {
+			return nil, fmt.Errorf("%w: %s is installed, the release is %s (use --force to downgrade)", ErrDowngrade, u.Current, release.Version)
+		}
+		result.UpToDate = cmp == 0
+	}
+	return result, nil
+}
+
+// Install downloads the release binary, checks it against the manifest and
+// installs it
+func (u *Updater) Install(release *Release) error {
+	checksum, ok := release.Checksums[u.Asset()]
+	if !ok {
+		return fmt.Errorf("release %s has no binary for %s/%s", release.Version, u.OS, u.Arch)
+	}
+
+	tmp, err := u.downloadBinary(checksum)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	return u.install(tmp)
+}
+
+// Rollback swaps the binary at path with the one the last update replaced,
+// so rolling back twice undoes the rollback
+func Rollback(path string) error {
+	previous := path + previousSuffix
+	if _, err := os.Stat(previous); os.IsNotExist(err) {
+		return ErrNoPrevious
+	}
+
+	// Rename over the installed binary so it is replaced atomically
+	swap := path + ".swap"
+	if Writable(filepath.Dir(path)) {
+		if err := os.Link(path, swap); err != nil {
+			return fmt.Errorf("failed to keep the installed binary: %w", err)
+		}
+		if err := os.Rename(previous, path); err != nil {
+			os.Remove(swap)
+			return fmt.Errorf("failed to restore the previous binary: %w", err)
+		}
+		return os.Rename(swap, previous)
+	}
+
+	return sudo(`ln -f "$1" "$1.swap" && mv -f "$1.previous" "$1" && mv -f "$1.swap" "$1.previous"`, path)
+}
+
+func (u *Updater) download(asset string) ([]byte, error) {
+	resp, err := u.get(asset)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", asset, err)
+	}
+	return data, nil
+}
+
+func (u *Updater) get(asset string) (*http.Response, error) {
+	url := strings.TrimSuffix(u.BaseURL, "/") + "/" + asset
+	resp, err := u.HTTPClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", asset, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to download %s: %s", asset, resp.Status)
+	}
+	return resp, nil
+}
+
+// downloadBinary downloads the binary to a temporary file, next to the
+// installed binary when possible so it can be renamed into place, and
+// checks it against the manifest checksum
+func (u *Updater) downloadBinary(checksum string) (string, error) {
+	resp, err := u.get(u.Asset())
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	dir := ""
+	if Writable(filepath.Dir(u.Path)) {
+		dir = filepath.Dir(u.Path)
+	}
+	f, err := os.CreateTemp(dir, ".rize-update-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, hash), resp.Body); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to download %s: %w", u.Asset(), err)
+	}
+
+	if got := hex.EncodeToString(hash.Sum(nil)); got != checksum {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("checksum mismatch for %s: expected %s, got %s", u.Asset(), checksum, got)
+	}
+
+	if err := f.Chmod(0755); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// install keeps the installed binary for rollback and renames the new one
+// over it
+func (u *Updater) install(tmp string) error {
+	if !Writable(filepath.Dir(u.Path)) {
+		return sudo(`if [ -e "$1" ]; then cp -p "$1" "$1.previous.tmp" && mv -f "$1.previous.tmp" "$1.previous"; fi && install -m 755 "$2" "$1.new" && mv -f "$1.new" "$1"`, u.Path, tmp)
+	}
+
+	if _, err := os.Stat(u.Path); err == nil {
+		previous := u.Path + previousSuffix
+		os.Remove(previous)
+		if err := os.Link(u.Path, previous); err != nil {
+			return fmt.Errorf("failed to keep the installed binary: %w", err)
+		}
+	}
+
+	if err := os.Rename(tmp, u.Path); err != nil {
+		return fmt.Errorf("failed to install %s: %w", u.Path, err)
+	}
+	return nil
+}
+
+// parseManifest parses a release manifest
+func parseManifest(data []byte) (*Release, error) {
+	release := &Release{Checksums: map[string]string{}}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if comment, ok := strings.CutPrefix(line, "#"); ok {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(comment), "rize "); ok {
+				release.Version = strings.TrimSpace(v)
+			}
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 || len(fields[0]) != sha256.Size*2 {
+			return nil, fmt.Errorf("invalid release manifest line: %s", line)
+		}
+		// sha256sum marks binary mode with a leading *
+		release.Checksums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+
+	if release.Version == "" {
+		return nil, fmt.Errorf("release manifest has no version")
+	}
+	return release, scanner.Err()
+}
+
+func sudo(script string, args ...string) error {
+	cmd := exec.Command("sudo", append([]string{"sh", "-c", script, "sh"}, args...)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("sudo failed: %w", err)
+	}
+	return nil
+}
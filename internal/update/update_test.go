@@ -0,0 +1,252 @@
+package update
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeRelease serves the assets of a release signed with key
+type fakeRelease struct {
+	version string
+	binary  string
+	key     ed25519.PrivateKey
+	// tamper replaces the served binary after the manifest is signed
+	tamper string
+}
+
+func (r *fakeRelease) server(t *testing.T) *httptest.Server {
+	sum := sha256.Sum256([]byte(r.binary))
+	manifest := fmt.Sprintf("# rize %s\n%s  rize-linux-amd64\n%s  rize-darwin-arm64\n",
+		r.version, hex.EncodeToString(sum[:]), strings.Repeat("0", 64))
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(r.key, []byte(manifest)))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/" + ManifestAsset:
+			fmt.Fprint(w, manifest)
+		case "/" + SignatureAsset:
+			fmt.Fprintln(w, signature)
+		case "/rize-linux-amd64":
+			if r.tamper != "" {
+				fmt.Fprint(w, r.tamper)
+				return
+			}
+			fmt.Fprint(w, r.binary)
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestUpdater(t *testing.T, release *fakeRelease, current string) *Updater {
+	t.Helper()
+
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if release.key == nil {
+		release.key = private
+	}
+
+	path := filepath.Join(t.TempDir(), "rize")
+	if err := os.WriteFile(path, []byte("old binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	server := release.server(t)
+	return &Updater{
+		BaseURL:    server.URL,
+		PublicKey:  public,
+		Path:       path,
+		Current:    current,
+		OS:         "linux",
+		Arch:       "amd64",
+		HTTPClient: server.Client(),
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestUpdate(t *testing.T) {
+	u := newTestUpdater(t, &fakeRelease{version: "v1.3.0", binary: "new binary"}, "v1.2.0")
+
+	result, err := u.Update(false)
+	if err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	if result.From != "v1.2.0" || result.To != "v1.3.0" || result.UpToDate {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	if got := readFile(t, u.Path); got != "new binary" {
+		t.Errorf("Expected the new binary to be installed, got %q", got)
+	}
+	if got := readFile(t, u.Path+previousSuffix); got != "old binary" {
+		t.Errorf("Expected the old binary to be kept, got %q", got)
+	}
+	if info, err := os.Stat(u.Path); err != nil || info.Mode().Perm() != 0755 {
+		t.Errorf("Expected an executable binary, got %v, %v", info.Mode(), err)
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(u.Path))
+	if len(entries) != 2 {
+		t.Errorf("Expected only the binary and its previous version, got %d files", len(entries))
+	}
+
+	// Rolling back restores the old binary, and rolling back again undoes it
+	if err := Rollback(u.Path); err != nil {
+		t.Fatalf("Rollback() failed: %v", err)
+	}
+	if got := readFile(t, u.Path); got != "old binary" {
+		t.Errorf("Expected the old binary after rollback, got %q", got)
+	}
+	if err := Rollback(u.Path); err != nil {
+		t.Fatalf("Rollback() failed: %v", err)
+	}
+	if got := readFile(t, u.Path); got != "new binary" {
+		t.Errorf("Expected the new binary after a second rollback, got %q", got)
+	}
+}
+
+func TestUpdateRejectsTampering(t *testing.T) {
+	u := newTestUpdater(t, &fakeRelease{version: "v1.3.0", binary: "new binary", tamper: "evil binary"}, "v1.2.0")
+	if _, err := u.Update(false); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("Expected a checksum mismatch, got %v", err)
+	}
+
+	_, otherKey, _ := ed25519.GenerateKey(nil)
+	u = newTestUpdater(t, &fakeRelease{version: "v1.3.0", binary: "new binary", key: otherKey}, "v1.2.0")
+	if _, err := u.Update(false); err == nil || !strings.Contains(err.Error(), "signature") {
+		t.Errorf("Expected an invalid signature, got %v", err)
+	}
+
+	if got := readFile(t, u.Path); got != "old binary" {
+		t.Errorf("Expected the installed binary to be untouched, got %q", got)
+	}
+}
+
+func TestUpdateVersions(t *testing.T) {
+	u := newTestUpdater(t, &fakeRelease{version: "v1.1.0", binary: "older binary"}, "v1.2.0")
+	if _, err := u.Update(false); !errors.Is(err, ErrDowngrade) {
+		t.Errorf("Expected a downgrade to be refused, got %v", err)
+	}
+	if _, err := u.Update(true); err != nil {
+		t.Errorf("Expected a forced downgrade to succeed, got %v", err)
+	}
+	if got := readFile(t, u.Path); got != "older binary" {
+		t.Errorf("Expected the older binary, got %q", got)
+	}
+
+	u = newTestUpdater(t, &fakeRelease{version: "v1.2.0", binary: "same binary"}, "v1.2.0")
+	result, err := u.Update(false)
+	if err != nil || !result.UpToDate {
+		t.Errorf("Expected up to date, got %+v, %v", result, err)
+	}
+	if got := readFile(t, u.Path); got != "old binary" {
+		t.Errorf("Expected no reinstall when up to date, got %q", got)
+	}
+
+	// Nightlies compare by date
+	u = newTestUpdater(t, &fakeRelease{version: "nightly-20260110", binary: "older nightly"}, "nightly-20260115")
+	if _, err := u.Update(false); !errors.Is(err, ErrDowngrade) {
+		t.Errorf("Expected an older nightly to be refused, got %v", err)
+	}
+	u = newTestUpdater(t, &fakeRelease{version: "nightly-20260115", binary: "same nightly"}, "nightly-20260115")
+	if result, err := u.Update(false); err != nil || !result.UpToDate {
+		t.Errorf("Expected the same nightly to be up to date, got %+v, %v", result, err)
+	}
+
+	// Development builds always update
+	u = newTestUpdater(t, &fakeRelease{version: "v1.0.0", binary: "release binary"}, "dev")
+	if _, err := u.Update(false); err != nil {
+		t.Errorf("Expected a development build to update, got %v", err)
+	}
+}
+
+func TestUpdatePinnedVersion(t *testing.T) {
+	u := newTestUpdater(t, &fakeRelease{version: "v1.2.9", binary: "other binary"}, "v1.2.0")
+	u.Pinned = "v1.3.0"
+	if _, err := u.Update(true); err == nil || !strings.Contains(err.Error(), "not the requested v1.3.0") {
+		t.Errorf("Expected a manifest for another version to be refused, got %v", err)
+	}
+	if got := readFile(t, u.Path); got != "old binary" {
+		t.Errorf("Expected the installed binary to be untouched, got %q", got)
+	}
+
+	u = newTestUpdater(t, &fakeRelease{version: "v1.3.0", binary: "pinned binary"}, "v1.2.0")
+	u.Pinned = "v1.3.0"
+	if _, err := u.Update(false); err != nil {
+		t.Errorf("Expected the pinned release to install, got %v", err)
+	}
+}
+
+func TestUpdateMissingPlatform(t *testing.T) {
+	u := newTestUpdater(t, &fakeRelease{version: "v1.3.0", binary: "new binary"}, "v1.2.0")
+	u.OS, u.Arch = "windows", "amd64"
+	if _, err := u.Update(false); err == nil || !strings.Contains(err.Error(), "no binary") {
+		t.Errorf("Expected a missing platform error, got %v", err)
+	}
+}
+
+func TestRollbackWithoutPrevious(t *testing.T) {
+	u := newTestUpdater(t, &fakeRelease{version: "v1.3.0", binary: "new binary"}, "v1.2.0")
+	if err := Rollback(u.Path); !errors.Is(err, ErrNoPrevious) {
+		t.Errorf("Expected ErrNoPrevious, got %v", err)
+	}
+}
+
+func TestParseManifest(t *testing.T) {
+	if _, err := parseManifest([]byte("abc  rize-linux-amd64\n")); err == nil {
+		t.Error("Expected an error for an invalid checksum line")
+	}
+	if _, err := parseManifest([]byte(strings.Repeat("a", 64) + "  rize-linux-amd64\n")); err == nil {
+		t.Error("Expected an error for a manifest without a version")
+	}
+
+	release, err := parseManifest([]byte("# rize v2.0.0\n" + strings.Repeat("A", 64) + " *rize-linux-arm64\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if release.Version != "v2.0.0" || release.Checksums["rize-linux-arm64"] != strings.Repeat("a", 64) {
+		t.Errorf("Unexpected release %+v", release)
+	}
+}
+
+func TestChannelURL(t *testing.T) {
+	tests := map[string]string{
+		"stable":  "https://github.com/alienxp03/rize/releases/latest/download",
+		"nightly": "https://github.com/alienxp03/rize/releases/download/nightly",
+		"v1.2.3":  "https://github.com/alienxp03/rize/releases/download/v1.2.3",
+	}
+	for channel, want := range tests {
+		if got, err := ChannelURL(channel); err != nil || got != want {
+			t.Errorf("ChannelURL(%q) = %q, %v, want %q", channel, got, err, want)
+		}
+	}
+
+	for _, channel := range []string{"beta", "1.2.3", "v1.2"} {
+		if _, err := ChannelURL(channel); err == nil {
+			t.Errorf("Expected an error for channel %q", channel)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"math"
+	"strings"
+	"time"
+)
+
+type tokens struct {
+	model      string
+	input      int64
+	output     int64
+	cacheRead  int64
+	cacheWrite int64
+	found      bool
+}
+
+// Extract returns the model and token counts of a captured response, parsed
+// out of the body for records of earlier versions. Both plain JSON and
+// streamed (SSE) responses are supported.
+func Extract(rec Record) (Usage, bool) {
+	if rec.Status >= 400 {
+		return Usage{}, false
+	}
+
+	sec, frac := math.Modf(rec.Time)
+	if rec.Body == "" {
+		if rec.Model == "" && rec.Input+rec.Output+rec.CacheRead+rec.CacheWrite == 0 {
+			return Usage{}, false
+		}
+		return Usage{
+			Time:       time.Unix(int64(sec), int64(frac*1e9)),
+			Session:    rec.Session,
+			Provider:   rec.Provider,
+			Model:      rec.Model,
+			Input:      rec.Input,
+			Output:     rec.Output,
+			CacheRead:  rec.CacheRead,
+			CacheWrite: rec.CacheWrite,
+		}, true
+	}
+
+	var t tokens
+	for _, payload := range payloads(rec.Body) {
+		switch rec.Provider {
+		case "anthropic":
+			t.applyAnthropic(payload)
+		case "openai":
+			t.applyOpenAI(payload)
+		case "google":
+			t.applyGoogle(payload)
+		}
+	}
+
+	if !t.found {
+		return Usage{}, false
+	}
+
+	model := t.model
+	if model == "" {
+		model = rec.RequestModel
+	}
+	if model == "" && rec.Provider == "google" {
+		model = geminiModelFromPath(rec.Path)
+	}
+
+	return Usage{
+		Time:       time.Unix(int64(sec), int64(frac*1e9)),
+		Session:    rec.Session,
+		Provider:   rec.Provider,
+		Model:      model,
+		Input:      t.input,
+		Output:     t.output,
+		CacheRead:  t.cacheRead,
+		CacheWrite: t.cacheWrite,
+	}, true
+}
+
+// payloads splits a response body into JSON documents: the data lines of an
+// SSE stream, the elements of a JSON array, or the body itself.
+func payloads(body string) []json.RawMessage {
+	trimmed := strings.TrimSpace(body)
+
+	if strings.HasPrefix(trimmed, "[") {
+		var items []json.RawMessage
+		if err := json.Unmarshal([]byte(trimmed), &items); err == nil {
+			return items
+		}
+	}
+
+	if strings.HasPrefix(trimmed, "{") {
+		return []json.RawMessage{json.RawMessage(trimmed)}
+	}
+
+	var items []json.RawMessage
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "" || data == "[DONE]" {
+			continue
+		}
+		items = append(items, json.RawMessage(data))
+	}
+
+	return items
+}
+
+type anthropicUsage struct {
+	InputTokens              int64 `json:"input_tokens"`
+	OutputTokens             int64 `json:"output_tokens"`
+	CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+}
+
+type anthropicPayload struct {
+	Type    string          `json:"type"`
+	Model   string          `json:"model"`
+	Usage   *anthropicUsage `json:"usage"`
+	Message *struct {
+		Model string          `json:"model"`
+		Usage *anthropicUsage `json:"usage"`
+	} `json:"message"`
+}
+
+// applyAnthropic handles Messages API responses. Streams report input and
+// cache tokens in message_start and the cumulative output in message_delta.
+func (t *tokens) applyAnthropic(raw json.RawMessage) {
+	var p anthropicPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return
+	}
+
+	usage := p.Usage
+	if p.Message != nil {
+		if p.Message.Model != "" {
+			t.model = p.Message.Model
+		}
+		if p.Message.Usage != nil {
+			usage = p.Message.Usage
+		}
+	}
+	if p.Model != "" {
+		t.model = p.Model
+	}
+	if usage == nil {
+		return
+	}
+
+	t.found = true
+	if usage.InputTokens > 0 {
+		t.input = usage.InputTokens
+	}
+	if usage.CacheCreationInputTokens > 0 {
+		t.cacheWrite = usage.CacheCreationInputTokens
+	}
+	if usage.CacheReadInputTokens > 0 {
+		t.cacheRead = usage.CacheReadInputTokens
+	}
+	if usage.OutputTokens > 0 {
+		t.output = usage.OutputTokens
+	}
+}
+
+type openAIUsage struct {
+	PromptTokens        int64 `json:"prompt_tokens"`
+	CompletionTokens    int64 `json:"completion_tokens"`
+	InputTokens         int64 `json:"input_tokens"`
+	OutputTokens        int64 `json:"output_tokens"`
+	PromptTokensDetails *struct {
+		CachedTokens int64 `json:"cached_tokens"`
+	} `json:"prompt_tokens_details"`
+	InputTokensDetails *struct {
+		CachedTokens int64 `json:"cached_tokens"`
+	} `json:"input_tokens_details"`
+}
+
+type openAIPayload struct {
+	Model    string       `json:"model"`
+	Usage    *openAIUsage `json:"usage"`
+	Response *struct {
+		Model string       `json:"model"`
+		Usage *openAIUsage `json:"usage"`
+	} `json:"response"`
+}
+
+// applyOpenAI handles Chat Completions and Responses API payloads. OpenAI
+// counts cached tokens as part of the prompt, so they are split out here.
+func (t *tokens) applyOpenAI(raw json.RawMessage) {
+	var p openAIPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return
+	}
+
+	usage := p.Usage
+	if p.Response != nil {
+		if p.Response.Model != "" {
+			t.model = p.Response.Model
+		}
+		if p.Response.Usage != nil {
+			usage = p.Response.Usage
+		}
+	}
+	if p.Model != "" {
+		t.model = p.Model
+	}
+	if usage == nil {
+		return
+	}
+
+	input := usage.PromptTokens + usage.InputTokens
+	var cached int64
+	if usage.PromptTokensDetails != nil {
+		cached += usage.PromptTokensDetails.CachedTokens
+	}
+	if usage.InputTokensDetails != nil {
+		cached += usage.InputTokensDetails.CachedTokens
+	}
+
+	t.found = true
+	t.input = input - cached
+	t.cacheRead = cached
+	t.output = usage.CompletionTokens + usage.OutputTokens
+}
+
+type googlePayload struct {
+	ModelVersion  string `json:"modelVersion"`
+	UsageMetadata *struct {
+		PromptTokenCount        int64 `json:"promptTokenCount"`
+		CandidatesTokenCount    int64 `json:"candidatesTokenCount"`
+		CachedContentTokenCount int64 `json:"cachedContentTokenCount"`
+		ThoughtsTokenCount      int64 `json:"thoughtsTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+// applyGoogle handles Gemini generateContent responses. Streamed chunks carry
+// cumulative usage, so the last one wins.
+func (t *tokens) applyGoogle(raw json.RawMessage) {
+	var p googlePayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return
+	}
+
+	if p.ModelVersion != "" {
+		t.model = p.ModelVersion
+	}
+	if p.UsageMetadata == nil {
+		return
+	}
+
+	m := p.UsageMetadata
+	t.found = true
+	t.input = m.PromptTokenCount - m.CachedContentTokenCount
+	t.cacheRead = m.CachedContentTokenCount
+	t.output = m.CandidatesTokenCount + m.ThoughtsTokenCount
+}
+
+// geminiModelFromPath extracts "gemini-2.5-pro" from
+// "/v1beta/models/gemini-2.5-pro:streamGenerateContent?alt=sse"
+func geminiModelFromPath(path string) string {
+	idx := strings.Index(path, "/models/")
+	if idx < 0 {
+		return ""
+	}
+
+	model := path[idx+len("/models/"):]
+	if end := strings.IndexAny(model, ":?/"); end >= 0 {
+		model = model[:end]
+	}
+	return model
+}
@@ -0,0 +1,128 @@
+package usage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/alienxp03/rize/internal/config"
+)
+
+// Groupings supported by Summarize
+var Groupings = []string{"model", "project", "agent", "provider", "session"}
+
+// Row is an aggregated usage line
+type Row struct {
+	Key        string  `json:"key"`
+	Requests   int     `json:"requests"`
+	Input      int64   `json:"input_tokens"`
+	Output     int64   `json:"output_tokens"`
+	CacheRead  int64   `json:"cache_read_tokens"`
+	CacheWrite int64   `json:"cache_write_tokens"`
+	Cost       float64 `json:"cost_usd"`
+	// Unpriced is set when some requests used a model missing from the price table
+	Unpriced bool `json:"unpriced,omitempty"`
+}
+
+func (r *Row) add(u Usage, cost float64, priced bool) {
+	r.Requests++
+	r.Input += u.Input
+	r.Output += u.Output
+	r.CacheRead += u.CacheRead
+	r.CacheWrite += u.CacheWrite
+	r.Cost += cost
+	if !priced {
+		r.Unpriced = true
+	}
+}
+
+// Summarize groups usages by the given key and prices them. It returns the
+// rows sorted by cost and the grand total.
+func Summarize(usages []Usage, by string, pricing map[string]config.ModelPrice) ([]Row, Row, error) {
+	keyFn, err := groupKey(by)
+	if err != nil {
+		return nil, Row{}, err
+	}
+
+	rows := map[string]*Row{}
+	total := Row{Key: "TOTAL"}
+	for _, u := range usages {
+		key := keyFn(u)
+		if key == "" {
+			key = "unknown"
+		}
+
+		row, ok := rows[key]
+		if !ok {
+			row = &Row{Key: key}
+			rows[key] = row
+		}
+
+		price, priced := PriceFor(u.Model, pricing)
+		cost := Cost(u, price)
+		row.add(u, cost, priced)
+		total.add(u, cost, priced)
+	}
+
+	result := make([]Row, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, *row)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Cost != result[j].Cost {
+			return result[i].Cost > result[j].Cost
+		}
+		return result[i].Key < result[j].Key
+	})
+
+	return result, total, nil
+}
+
+// PriceFor returns the price of a model, matching the longest price table
+// entry that is the model name or a prefix of it ending at a delimiter, so
+// "o3" prices "o3-2025-04-16" but not "o3x".
+func PriceFor(model string, pricing map[string]config.ModelPrice) (config.ModelPrice, bool) {
+	model = strings.TrimPrefix(model, "models/")
+
+	var best string
+	for name := range pricing {
+		if len(name) > len(best) && matchesModel(model, name) {
+			best = name
+		}
+	}
+
+	if best == "" {
+		return config.ModelPrice{}, false
+	}
+	return pricing[best], true
+}
+
+func matchesModel(model, name string) bool {
+	rest, ok := strings.CutPrefix(model, name)
+	return ok && (rest == "" || strings.ContainsRune("-@:", rune(rest[0])))
+}
+
+// Cost returns the cost of a request in USD
+func Cost(u Usage, price config.ModelPrice) float64 {
+	return (float64(u.Input)*price.Input +
+		float64(u.Output)*price.Output +
+		float64(u.CacheRead)*price.CacheRead +
+		float64(u.CacheWrite)*price.CacheWrite) / 1e6
+}
+
+func groupKey(by string) (func(Usage) string, error) {
+	switch by {
+	case "", "model":
+		return func(u Usage) string { return u.Model }, nil
+	case "project":
+		return func(u Usage) string { return u.Project }, nil
+	case "agent":
+		return func(u Usage) string { return u.Agent }, nil
+	case "provider":
+		return func(u Usage) string { return u.Provider }, nil
+	case "session":
+		return func(u Usage) string { return u.Session }, nil
+	default:
+		return nil, fmt.Errorf("invalid --by value %q (use %s)", by, strings.Join(Groupings, ", "))
+	}
+}
@@ -0,0 +1,193 @@
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/alienxp03/rize/internal/config"
+	"github.com/alienxp03/rize/internal/session"
+)
+
+// Record is an LLM API response captured by the rize mitmproxy usage addon.
+// The addon extracts the model and token counts itself; records of earlier
+// versions carry the response body instead.
+type Record struct {
+	Time       float64 `json:"time"`
+	Session    string  `json:"session"`
+	Provider   string  `json:"provider"`
+	Host       string  `json:"host"`
+	Path       string  `json:"path"`
+	Status     int     `json:"status"`
+	Model      string  `json:"model,omitempty"`
+	Input      int64   `json:"input_tokens,omitempty"`
+	Output     int64   `json:"output_tokens,omitempty"`
+	CacheRead  int64   `json:"cache_read_tokens,omitempty"`
+	CacheWrite int64   `json:"cache_write_tokens,omitempty"`
+
+	RequestModel string `json:"request_model,omitempty"`
+	ContentType  string `json:"content_type,omitempty"`
+	Body         string `json:"body,omitempty"`
+}
+
+// maxLineSize is the longest record read; longer lines are skipped
+var maxLineSize = 64 * 1024 * 1024
+
+// Usage is the token usage of a single API request
+type Usage struct {
+	Time       time.Time `json:"time"`
+	Session    string    `json:"session"`
+	Project    string    `json:"project,omitempty"`
+	Agent      string    `json:"agent,omitempty"`
+	Provider   string    `json:"provider"`
+	Model      string    `json:"model"`
+	Input      int64     `json:"input_tokens"`
+	Output     int64     `json:"output_tokens"`
+	CacheRead  int64     `json:"cache_read_tokens"`
+	CacheWrite int64     `json:"cache_write_tokens"`
+}
+
+// Dir returns the directory captured API responses are stored in (~/.rize/usage)
+func Dir() (string, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dataDir, "usage"), nil
+}
+
+// Load extracts the usage of every captured request since the given time,
+// attributed to the project and agent of its session.
+func Load(since time.Time) ([]Usage, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+
+	var usages []Usage
+	for _, path := range paths {
+		// Records are only appended, so a file last written before since
+		// holds nothing newer
+		if info, err := os.Stat(path); err != nil || info.ModTime().Before(since) {
+			continue
+		}
+
+		records, err := readRecords(path)
+		if err != nil {
+			return nil, err
+		}
+
+		// Each file holds the responses of a single session
+		meta, _ := session.Load(strings.TrimSuffix(filepath.Base(path), ".jsonl"))
+		for _, rec := range records {
+			u, ok := Extract(rec)
+			if !ok || u.Time.Before(since) {
+				continue
+			}
+
+			if meta != nil {
+				u.Project = meta.Project
+				u.Agent = meta.Agent
+			}
+
+			usages = append(usages, u)
+		}
+	}
+
+	return usages, nil
+}
+
+func readRecords(path string) ([]Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReaderSize(file, 64*1024)
+	var records []Record
+	for {
+		line, tooLong, err := readLine(reader)
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return records, err
+		}
+		if tooLong {
+			continue
+		}
+
+		var rec Record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			continue
+		}
+		records = append(records, rec)
+	}
+}
+
+// readLine reads a line, reporting lines over maxLineSize as too long
+// without keeping them in memory
+func readLine(reader *bufio.Reader) ([]byte, bool, error) {
+	var line []byte
+	tooLong := false
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		if !tooLong {
+			line = append(line, chunk...)
+			if len(line) > maxLineSize {
+				line, tooLong = nil, true
+			}
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == io.EOF && (len(line) > 0 || tooLong) {
+			return line, tooLong, nil
+		}
+		return line, tooLong, err
+	}
+}
+
+// Prune removes the usage of sessions last written more than retentionDays
+// ago. Zero keeps everything.
+func Prune(retentionDays int) (int, error) {
+	if retentionDays <= 0 {
+		return 0, nil
+	}
+
+	dir, err := Dir()
+	if err != nil {
+		return 0, err
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -retentionDays)
+	removed := 0
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("failed to remove usage %s: %w", filepath.Base(path), err)
+		}
+		removed++
+	}
+
+	return removed, nil
+}
@@ -0,0 +1,240 @@
+package usage
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alienxp03/rize/internal/config"
+	"github.com/alienxp03/rize/internal/session"
+)
+
+func readFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		fixture  string
+		provider string
+		path     string
+		want     Usage
+	}{
+		{"anthropic_message.json", "anthropic", "/v1/messages", Usage{Model: "claude-sonnet-4-5-20250929", Input: 120, Output: 45, CacheRead: 30000, CacheWrite: 2000}},
+		{"anthropic_stream.sse", "anthropic", "/v1/messages", Usage{Model: "claude-opus-4-5-20251101", Input: 10, Output: 250, CacheRead: 5000}},
+		{"openai_chat.json", "openai", "/v1/chat/completions", Usage{Model: "gpt-4o-2024-08-06", Input: 476, Output: 80, CacheRead: 1024}},
+		{"openai_chat_stream.sse", "openai", "/v1/chat/completions", Usage{Model: "gpt-4o-mini", Input: 200, Output: 20}},
+		{"openai_responses_stream.sse", "openai", "/v1/responses", Usage{Model: "gpt-5-2025-08-07", Input: 1000, Output: 400, CacheRead: 2000}},
+		{"google_stream.sse", "google", "/v1beta/models/gemini-2.5-pro:streamGenerateContent?alt=sse", Usage{Model: "gemini-2.5-pro", Input: 300, Output: 140, CacheRead: 500}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			got, ok := Extract(Record{
+				Provider: tt.provider,
+				Path:     tt.path,
+				Status:   200,
+				Body:     readFixture(t, tt.fixture),
+			})
+			if !ok {
+				t.Fatal("Expected usage to be extracted")
+			}
+
+			if got.Model != tt.want.Model || got.Input != tt.want.Input || got.Output != tt.want.Output ||
+				got.CacheRead != tt.want.CacheRead || got.CacheWrite != tt.want.CacheWrite {
+				t.Errorf("Extract() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractIgnoresErrors(t *testing.T) {
+	body := `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`
+	if _, ok := Extract(Record{Provider: "anthropic", Status: 529, Body: body}); ok {
+		t.Error("Expected error responses to be ignored")
+	}
+}
+
+// TestExtractFromFakeAPI streams a fixture from a local fake API server the
+// way the real APIs do, to make sure chunked SSE bodies parse the same.
+func TestExtractFromFakeAPI(t *testing.T) {
+	fixture := readFixture(t, "anthropic_stream.sse")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		for _, event := range strings.SplitAfter(fixture, "\n\n") {
+			io.WriteString(w, event)
+			flusher.Flush()
+		}
+	}))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/v1/messages", "application/json", strings.NewReader(`{"model":"claude-opus-4-5","stream":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, ok := Extract(Record{
+		Provider:    "anthropic",
+		Status:      resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        string(body),
+	})
+	if !ok || got.Output != 250 || got.CacheRead != 5000 {
+		t.Errorf("Unexpected usage from fake API: %+v", got)
+	}
+}
+
+func TestLoadAndSummarize(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	sess := session.New("api", "claude", nil, "alienxp03/rize:latest")
+	if err := sess.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, _ := Dir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	now := float64(time.Now().Unix())
+	var lines []string
+	for _, rec := range []Record{
+		{Time: now, Session: sess.ID, Provider: "anthropic", Status: 200, Body: readFixture(t, "anthropic_message.json")},
+		{Time: now, Session: sess.ID, Provider: "openai", Status: 200, Body: readFixture(t, "openai_chat.json")},
+		{Time: now - 30*86400, Session: sess.ID, Provider: "openai", Status: 200, Body: readFixture(t, "openai_chat.json")},
+		{Time: now, Session: sess.ID, Provider: "openai", Status: 200, Body: `{"model":"mystery-1","usage":{"prompt_tokens":10,"completion_tokens":1}}`},
+	} {
+		line, _ := json.Marshal(rec)
+		lines = append(lines, string(line))
+	}
+	if err := os.WriteFile(filepath.Join(dir, sess.ID+".jsonl"), []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	usages, err := Load(time.Now().AddDate(0, 0, -7))
+	if err != nil {
+		t.Fatalf("Failed to load usage: %v", err)
+	}
+	if len(usages) != 3 {
+		t.Fatalf("Expected 3 usages within 7 days, got %d", len(usages))
+	}
+	if usages[0].Project != "api" || usages[0].Agent != "claude" {
+		t.Errorf("Expected usage to be attributed to the session, got %+v", usages[0])
+	}
+
+	rows, total, err := Summarize(usages, "project", config.DefaultPricing())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].Requests != 3 || !total.Unpriced {
+		t.Errorf("Unexpected summary: %+v total %+v", rows, total)
+	}
+
+	// claude-sonnet-4: 120*3 + 45*15 + 30000*0.3 + 2000*3.75 per million
+	// gpt-4o: 476*2.5 + 80*10 + 1024*1.25 per million
+	want := (120*3+45*15+30000*0.3+2000*3.75)/1e6 + (476*2.5+80*10+1024*1.25)/1e6
+	if math.Abs(total.Cost-want) > 1e-9 {
+		t.Errorf("Expected total cost %f, got %f", want, total.Cost)
+	}
+
+	if _, _, err := Summarize(usages, "color", nil); err == nil {
+		t.Error("Expected invalid grouping to fail")
+	}
+}
+
+func TestLoadExtractedRecords(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	maxLineSize = 1024
+	t.Cleanup(func() { maxLineSize = 64 * 1024 * 1024 })
+
+	dir, _ := Dir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	now := float64(time.Now().Unix())
+	line, _ := json.Marshal(Record{Time: now, Session: "s1", Provider: "anthropic", Status: 200, Model: "claude-sonnet-4-5", Input: 12, Output: 3, CacheRead: 40})
+	content := strings.Repeat("x", 2048) + "\n" + string(line) + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "s1.jsonl"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	old := filepath.Join(dir, "s0.jsonl")
+	if err := os.WriteFile(old, []byte("not read\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	lastYear := time.Now().AddDate(-1, 0, 0)
+	if err := os.Chtimes(old, lastYear, lastYear); err != nil {
+		t.Fatal(err)
+	}
+
+	usages, err := Load(time.Now().AddDate(0, 0, -7))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(usages) != 1 || usages[0].Model != "claude-sonnet-4-5" || usages[0].Input != 12 || usages[0].CacheRead != 40 {
+		t.Fatalf("Load() = %+v, want the extracted record", usages)
+	}
+
+	removed, err := Prune(30)
+	if err != nil || removed != 1 {
+		t.Fatalf("Prune() = %d, %v, want 1 removed", removed, err)
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Error("Expected the old session to be pruned")
+	}
+}
+
+func TestPriceForLongestPrefix(t *testing.T) {
+	pricing := config.DefaultPricing()
+
+	opus, ok := PriceFor("claude-opus-4-5-20251101", pricing)
+	if !ok || opus.Input != 5 {
+		t.Errorf("Expected claude-opus-4-5 pricing, got %+v", opus)
+	}
+
+	mini, ok := PriceFor("gpt-4o-mini-2024-07-18", pricing)
+	if !ok || mini.Input != 0.15 {
+		t.Errorf("Expected gpt-4o-mini pricing, got %+v", mini)
+	}
+
+	tests := []struct {
+		model string
+		input float64
+		ok    bool
+	}{
+		{"o3", 2, true},
+		{"o3-2025-04-16", 2, true},
+		{"o3-mini-2025-01-31", 1.1, true},
+		{"o3-pro", 20, true},
+		{"o3x", 0, false},
+		{"claude-sonnet-4@20250514", 3, true},
+		{"models/gemini-2.5-flash-lite", 0.1, true},
+	}
+	for _, tt := range tests {
+		price, ok := PriceFor(tt.model, pricing)
+		if ok != tt.ok || price.Input != tt.input {
+			t.Errorf("PriceFor(%q) = %+v, %v, want input %v, %v", tt.model, price, ok, tt.input, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Build metadata, set when building a release with
+// -ldflags "-X github.com/alienxp03/rize/internal/version.Version=v1.2.3 ..."
+var (
+	// Version is a release like v1.2.3, nightly-20260115 for nightly builds,
+	// or dev
+	Version = "dev"
+	// Commit is the git commit the binary was built from
+	Commit = ""
+	// Date is when the binary was built, in RFC 3339
+	Date = ""
+)
+
+// Info describes the running binary
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Date      string `json:"date,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+}
+
+// Get returns the build metadata of the running binary. Fields not set at
+// build time come from the module and VCS information Go embeds.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	// go install github.com/alienxp03/rize/cmd/rize@v1.2.3
+	if info.Version == "dev" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+		info.Version = bi.Main.Version
+	}
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = setting.Value
+			}
+		case "vcs.time":
+			if info.Date == "" {
+				info.Date = setting.Value
+			}
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+
+	return info
+}
+
+// Nightly reports whether v is a nightly build
+func Nightly(v string) bool {
+	return strings.HasPrefix(v, "nightly")
+}
+
+// IsRelease reports whether v is a release version: exactly v1.2.3 or a
+// release candidate like v1.3.0-rc.1. Builds between tags, like
+// v1.2.3-5-gabc1234-dirty from git describe, are not releases.
+func IsRelease(v string) bool {
+	_, ok := parse(v)
+	return ok && strings.HasPrefix(v, "v")
+}
+
+// ImageTag returns the image tag matching a binary version: the release
+// version itself, nightly for nightly builds and latest for anything else
+func ImageTag(v string) string {
+	switch {
+	case IsRelease(v):
+		return v
+	case Nightly(v):
+		return "nightly"
+	default:
+		return "latest"
+	}
+}
+
+// Compare compares two release versions like v1.2.3 or v1.3.0-rc.1, or two
+// nightly versions like nightly-20260115 by their date, and returns -1, 0 or
+// 1. ok is false when the versions cannot be compared, e.g. a development
+// build or a nightly with a release.
+func Compare(a, b string) (result int, ok bool) {
+	if da, okA := nightlyDate(a); okA {
+		db, okB := nightlyDate(b)
+		if !okB {
+			return 0, false
+		}
+		return da.Compare(db), true
+	}
+
+	va, okA := parse(a)
+	vb, okB := parse(b)
+	if !okA || !okB {
+		return 0, false
+	}
+
+	for i := 0; i < 3; i++ {
+		if va.numbers[i] != vb.numbers[i] {
+			if va.numbers[i] < vb.numbers[i] {
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+
+	// A release candidate sorts before the release
+	switch {
+	case va.rc == vb.rc:
+		return 0, true
+	case va.rc == 0:
+		return 1, true
+	case vb.rc == 0:
+		return -1, true
+	case va.rc < vb.rc:
+		return -1, true
+	default:
+		return 1, true
+	}
+}
+
+// nightlyDate returns the date of a nightly version like nightly-20260115
+func nightlyDate(v string) (time.Time, bool) {
+	date, ok := strings.CutPrefix(v, "nightly-")
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse("20060102", date)
+	return t, err == nil
+}
+
+type semver struct {
+	numbers [3]int
+	// rc is the release candidate number, 0 for a release
+	rc int
+}
+
+// parse parses 1.2.3 or 1.2.3-rc.N, with an optional v prefix
+func parse(v string) (semver, bool) {
+	var s semver
+
+	v = strings.TrimPrefix(v, "v")
+	v, pre, hasPre := strings.Cut(v, "-")
+	if hasPre {
+		n, ok := strings.CutPrefix(pre, "rc.")
+		if !ok {
+			return s, false
+		}
+		rc, ok := number(n)
+		if !ok || rc == 0 {
+			return s, false
+		}
+		s.rc = rc
+	}
+
+	parts := strings.Split(v, ".")
+	if len(parts) != 3 {
+		return s, false
+	}
+	for i, part := range parts {
+		n, ok := number(part)
+		if !ok {
+			return s, false
+		}
+		s.numbers[i] = n
+	}
+
+	return s, true
+}
+
+// number parses a non-negative decimal number made of digits only
+func number(s string) (int, bool) {
+	if s == "" || strings.Trim(s, "0123456789") != "" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil
+}
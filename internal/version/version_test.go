@@ -0,0 +1,54 @@
+package version
+
+import "testing"
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+		ok   bool
+	}{
+		{"v1.2.3", "v1.2.3", 0, true},
+		{"v1.2.3", "1.2.4", -1, true},
+		{"v1.10.0", "v1.9.9", 1, true},
+		{"v2.0.0", "v1.99.99", 1, true},
+		{"v1.3.0-rc.1", "v1.3.0", -1, true},
+		{"v1.3.0-rc.2", "v1.3.0-rc.1", 1, true},
+		{"v1.3.0-rc.10", "v1.3.0-rc.2", 1, true},
+		{"nightly-20260115", "nightly-20260114", 1, true},
+		{"nightly-20260115", "nightly-20260115", 0, true},
+		{"nightly-20251231", "nightly-20260101", -1, true},
+		{"nightly-20260115", "v1.0.0", 0, false},
+		{"v1.2.3-5-gabc1234-dirty", "v1.2.3", 0, false},
+		{"v1.3.0+build.5", "v1.3.0", 0, false},
+		{"dev", "v1.0.0", 0, false},
+		{"v1.0", "v1.0.0", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := Compare(tt.a, tt.b)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Compare(%q, %q) = %d, %v, want %d, %v", tt.a, tt.b, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestImageTag(t *testing.T) {
+	tests := map[string]string{
+		"v1.2.3":            "v1.2.3",
+		"v1.3.0-rc.1":       "v1.3.0-rc.1",
+		"nightly-20260115":  "nightly",
+		"dev":               "latest",
+		"1.2.3":             "latest",
+		"v1.2.3-5-gabc1234": "latest",
+		"v1.2.3-dirty":      "latest",
+		"v1.2.3-beta":       "latest",
+		"abc1234":           "latest",
+	}
+
+	for v, want := range tests {
+		if got := ImageTag(v); got != want {
+			t.Errorf("ImageTag(%q) = %q, want %q", v, got, want)
+		}
+	}
+}
@@ -3,8 +3,10 @@ set -e
 
 # Rize installer script
 # Usage: curl -sSL https://raw.githubusercontent.com/alienxp03/rize/master/scripts/install.sh | sh
+# Set RIZE_INSTALL_DIR to pick the install directory; by default rize goes to
+# /usr/local/bin when it is writable and ~/.local/bin otherwise.
 
-INSTALL_DIR="/usr/local/bin"
+INSTALL_DIR="${RIZE_INSTALL_DIR:-}"
 BINARY_NAME="rize"
 REPO="alienxp03/rize"
 GITHUB_URL="https://github.com/${REPO}"
@@ -95,6 +97,18 @@ download_binary() {
     success "Downloaded successfully"
 }
 
+# Pick the install directory without needing sudo
+detect_install_dir() {
+    if [ -z "$INSTALL_DIR" ]; then
+        if [ -w /usr/local/bin ]; then
+            INSTALL_DIR="/usr/local/bin"
+        else
+            INSTALL_DIR="$HOME/.local/bin"
+        fi
+    fi
+    mkdir -p "$INSTALL_DIR" 2>/dev/null || true
+}
+
 # Install binary
 install_binary() {
     info "Installing to ${INSTALL_DIR}/${BINARY_NAME}..."
@@ -112,7 +126,21 @@ install_binary() {
         error "Cannot write to $INSTALL_DIR and sudo is not available"
     fi
 
+    # Record the location for rize update and rize uninstall
+    mkdir -p "$HOME/.rize"
+    printf '{\n  "path": "%s",\n  "installed_at": "%s"\n}\n' \
+        "${INSTALL_DIR}/${BINARY_NAME}" "$(date -u +%Y-%m-%dT%H:%M:%SZ)" > "$HOME/.rize/install.json"
+
     success "Installed to ${INSTALL_DIR}/${BINARY_NAME}"
+
+    case ":$PATH:" in
+        *":$INSTALL_DIR:"*) ;;
+        *)
+            warning "$INSTALL_DIR is not on your PATH"
+            info "Add this line to your shell profile and open a new shell:"
+            echo "  export PATH=\"$INSTALL_DIR:\$PATH\""
+            ;;
+    esac
 }
 
 # Check prerequisites
@@ -126,14 +154,6 @@ check_prerequisites() {
     else
         success "Docker found"
     fi
-
-    # Check for docker compose
-    if ! docker compose version >/dev/null 2>&1; then
-        warning "Docker Compose is not available"
-        info "Please install Docker Compose: https://docs.docker.com/compose/install/"
-    else
-        success "Docker Compose found"
-    fi
 }
 
 # Main installation
@@ -146,6 +166,7 @@ main() {
     detect_platform
     get_latest_version
     download_binary
+    detect_install_dir
     install_binary
     check_prerequisites
 
@@ -0,0 +1,40 @@
+#!/bin/bash
+# Append an audit event for a command run inside the rize container.
+# The audit log (~/.rize/audit.log on the host) is read-only in here; events go
+# to ~/.rize/container/audit-container.log, which rize merges into the log on
+# the host. rize creates that file as the host user, so it is never created
+# from here. Secrets are redacted with the rules rize writes to
+# ~/.rize/mitmproxy/redact.json, mounted read-only at /etc/rize/redact.json;
+# matches are replaced whole since jq cannot keep the text around a capture group.
+#
+# Usage: rize-audit <event-type> <command...>
+
+AUDIT_LOG="${RIZE_AUDIT_LOG:-/home/agent/.local/share/rize/audit-container.log}"
+REDACT_RULES="${RIZE_REDACT_RULES:-/etc/rize/redact.json}"
+
+if [ $# -lt 1 ] || [ ! -f "$AUDIT_LOG" ] || ! command -v jq >/dev/null 2>&1; then
+    exit 0
+fi
+
+EVENT_TYPE="$1"
+shift
+
+RULES='{"patterns": []}'
+if [ -r "$REDACT_RULES" ]; then
+    RULES="$(cat "$REDACT_RULES")"
+fi
+
+jq -cn \
+    --argjson rules "$RULES" \
+    --arg time "$(date -u +%Y-%m-%dT%H:%M:%S.%3NZ)" \
+    --arg type "$EVENT_TYPE" \
+    --arg project "${RIZE_PROJECT_NAME:-}" \
+    --arg session "${RIZE_SESSION_ID:-}" \
+    --arg agent "${RIZE_AGENT:-}" \
+    --arg user "$(id -un)" \
+    --arg cwd "$PWD" \
+    'def redact: reduce $rules.patterns[] as $p (.;
+         (try gsub($p | gsub("\\(\\?P<"; "(?<"); $rules.placeholder) catch .));
+     {time: $time, type: $type, source: "container", project: $project, session: $session, agent: $agent, user: $user, cwd: $cwd, command: ($ARGS.positional | map(redact))}
+     | with_entries(select(.value != "" and .value != []))' \
+    --args "$@" >> "$AUDIT_LOG" 2>/dev/null || true